@@ -0,0 +1,339 @@
+// Package archivefs exposes the contents of archives as read-only
+// afero filesystems, so they can be browsed without being extracted.
+package archivefs
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Fs is a read-only afero.Fs exposing the contents of an archive. The
+// archive is mounted at its own path, which behaves as a directory, so
+// paths outside of the archive use the same form as the parent
+// filesystem and its entries are found under "<archive path>/".
+type Fs struct {
+	prefix string
+	nodes  map[string]*node
+	closer io.Closer
+}
+
+type node struct {
+	name     string
+	size     int64
+	mode     os.FileMode
+	modTime  time.Time
+	children []string
+	open     func() (io.ReadCloser, error)
+}
+
+func newFs(prefix string, closer io.Closer, modTime time.Time) *Fs {
+	prefix = path.Clean("/" + filepath.ToSlash(prefix))
+	return &Fs{
+		prefix: prefix,
+		closer: closer,
+		nodes: map[string]*node{
+			"/": {name: path.Base(prefix), mode: os.ModeDir | 0555, modTime: modTime},
+		},
+	}
+}
+
+// add adds a node to the tree, creating any missing parent directory.
+func (fs *Fs) add(name string, n *node) {
+	name = path.Clean("/" + name)
+	if name == "/" {
+		return
+	}
+
+	if existing, ok := fs.nodes[name]; ok {
+		// a directory may have been created implicitly before.
+		n.children = existing.children
+	} else {
+		dir := path.Dir(name)
+		if _, ok := fs.nodes[dir]; !ok {
+			fs.add(dir, &node{mode: os.ModeDir | 0555, modTime: n.modTime})
+		}
+		parent := fs.nodes[dir]
+		parent.children = append(parent.children, path.Base(name))
+	}
+
+	n.name = path.Base(name)
+	fs.nodes[name] = n
+}
+
+func (fs *Fs) lookup(op, name string) (*node, error) {
+	p := path.Clean("/" + filepath.ToSlash(name))
+
+	switch {
+	case p == fs.prefix:
+		p = "/"
+	case strings.HasPrefix(p, fs.prefix+"/"):
+		p = strings.TrimPrefix(p, fs.prefix)
+	default:
+		return nil, &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	}
+
+	n, ok := fs.nodes[p]
+	if !ok {
+		return nil, &os.PathError{Op: op, Path: name, Err: os.ErrNotExist}
+	}
+
+	return n, nil
+}
+
+// Close closes the underlying archive.
+func (fs *Fs) Close() error {
+	return fs.closer.Close()
+}
+
+// Name implements afero.Fs.
+func (fs *Fs) Name() string {
+	return "ArchiveFs"
+}
+
+// Stat implements afero.Fs.
+func (fs *Fs) Stat(name string) (os.FileInfo, error) {
+	n, err := fs.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	return fileInfo{n}, nil
+}
+
+// Open implements afero.Fs.
+func (fs *Fs) Open(name string) (afero.File, error) {
+	n, err := fs.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &file{fs: fs, name: name, node: n}, nil
+}
+
+// OpenFile implements afero.Fs. Only reading is allowed.
+func (fs *Fs) OpenFile(name string, flag int, _ os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_APPEND|os.O_TRUNC) != 0 {
+		return nil, readOnly("open", name)
+	}
+	return fs.Open(name)
+}
+
+// Create implements afero.Fs.
+func (fs *Fs) Create(name string) (afero.File, error) {
+	return nil, readOnly("create", name)
+}
+
+// Mkdir implements afero.Fs.
+func (fs *Fs) Mkdir(name string, _ os.FileMode) error {
+	return readOnly("mkdir", name)
+}
+
+// MkdirAll implements afero.Fs.
+func (fs *Fs) MkdirAll(name string, _ os.FileMode) error {
+	return readOnly("mkdir", name)
+}
+
+// Remove implements afero.Fs.
+func (fs *Fs) Remove(name string) error {
+	return readOnly("remove", name)
+}
+
+// RemoveAll implements afero.Fs.
+func (fs *Fs) RemoveAll(name string) error {
+	return readOnly("remove", name)
+}
+
+// Rename implements afero.Fs.
+func (fs *Fs) Rename(oldname, _ string) error {
+	return readOnly("rename", oldname)
+}
+
+// Chmod implements afero.Fs.
+func (fs *Fs) Chmod(name string, _ os.FileMode) error {
+	return readOnly("chmod", name)
+}
+
+// Chtimes implements afero.Fs.
+func (fs *Fs) Chtimes(name string, _, _ time.Time) error {
+	return readOnly("chtimes", name)
+}
+
+func readOnly(op, name string) error {
+	return &os.PathError{Op: op, Path: name, Err: os.ErrPermission}
+}
+
+type fileInfo struct {
+	n *node
+}
+
+func (fi fileInfo) Name() string       { return fi.n.name }
+func (fi fileInfo) Size() int64        { return fi.n.size }
+func (fi fileInfo) Mode() os.FileMode  { return fi.n.mode }
+func (fi fileInfo) ModTime() time.Time { return fi.n.modTime }
+func (fi fileInfo) IsDir() bool        { return fi.n.mode.IsDir() }
+func (fi fileInfo) Sys() interface{}   { return nil }
+
+// file is an entry of the archive. Archive entries are usually compressed
+// streams, so seeking is emulated: reading from a position before the
+// current one reopens the entry and skips to the wanted offset.
+type file struct {
+	fs     *Fs
+	name   string
+	node   *node
+	rc     io.ReadCloser
+	rpos   int64
+	pos    int64
+	dirPos int
+}
+
+func (f *file) Name() string {
+	return f.name
+}
+
+func (f *file) Stat() (os.FileInfo, error) {
+	return fileInfo{f.node}, nil
+}
+
+func (f *file) Close() error {
+	if f.rc != nil {
+		return f.rc.Close()
+	}
+	return nil
+}
+
+func (f *file) Read(p []byte) (int, error) {
+	if f.node.mode.IsDir() {
+		return 0, &os.PathError{Op: "read", Path: f.name, Err: os.ErrInvalid}
+	}
+
+	if f.pos >= f.node.size {
+		return 0, io.EOF
+	}
+
+	if f.rc == nil || f.rpos > f.pos {
+		if err := f.reopen(); err != nil {
+			return 0, err
+		}
+	}
+
+	if f.rpos < f.pos {
+		n, err := io.CopyN(ioutil.Discard, f.rc, f.pos-f.rpos)
+		f.rpos += n
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.rc.Read(p)
+	f.rpos += int64(n)
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *file) reopen() error {
+	if f.rc != nil {
+		_ = f.rc.Close()
+		f.rc = nil
+	}
+
+	rc, err := f.node.open()
+	if err != nil {
+		return err
+	}
+
+	f.rc = rc
+	f.rpos = 0
+	return nil
+}
+
+func (f *file) ReadAt(p []byte, off int64) (int, error) {
+	pos := f.pos
+	defer func() { f.pos = pos }()
+
+	if _, err := f.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(f, p)
+}
+
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		offset += f.node.size
+	default:
+		return 0, os.ErrInvalid
+	}
+
+	if offset < 0 {
+		return 0, os.ErrInvalid
+	}
+
+	f.pos = offset
+	return offset, nil
+}
+
+func (f *file) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.node.mode.IsDir() {
+		return nil, &os.PathError{Op: "readdir", Path: f.name, Err: os.ErrInvalid}
+	}
+
+	children := f.node.children
+	sort.Strings(children)
+
+	base := path.Clean("/" + filepath.ToSlash(f.name))
+
+	var infos []os.FileInfo
+	for f.dirPos < len(children) && (count <= 0 || len(infos) < count) {
+		n, err := f.fs.lookup("readdir", path.Join(base, children[f.dirPos]))
+		f.dirPos++
+		if err != nil {
+			continue
+		}
+		infos = append(infos, fileInfo{n})
+	}
+
+	if count > 0 && len(infos) == 0 {
+		return nil, io.EOF
+	}
+
+	return infos, nil
+}
+
+func (f *file) Readdirnames(n int) ([]string, error) {
+	infos, err := f.Readdir(n)
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name()
+	}
+	return names, err
+}
+
+func (f *file) Write([]byte) (int, error) {
+	return 0, readOnly("write", f.name)
+}
+
+func (f *file) WriteAt([]byte, int64) (int, error) {
+	return 0, readOnly("write", f.name)
+}
+
+func (f *file) WriteString(string) (int, error) {
+	return 0, readOnly("write", f.name)
+}
+
+func (f *file) Truncate(int64) error {
+	return readOnly("truncate", f.name)
+}
+
+func (f *file) Sync() error {
+	return nil
+}
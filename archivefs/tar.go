@@ -0,0 +1,314 @@
+package archivefs
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// tarEntry is an entry of the index of a tar archive. The data of the
+// regular files starts at offset in the uncompressed archive, except for
+// the sparse ones, which are read through a tar reader.
+type tarEntry struct {
+	name     string
+	dir      bool
+	size     int64
+	mode     os.FileMode
+	modTime  time.Time
+	offset   int64
+	sparse   bool
+	linkname string
+}
+
+// tarIndex is the index of a version of a tar archive.
+type tarIndex struct {
+	modTime time.Time
+	size    int64
+	entries []tarEntry
+}
+
+// IndexCache keeps the indexes of the most recently opened tar archives.
+// Tar archives can only be read sequentially, so this spares scanning an
+// archive again each time it's opened.
+type IndexCache struct {
+	mu      sync.Mutex
+	size    int
+	indexes map[string]*tarIndex
+	keys    []string // least recently used first
+}
+
+// NewIndexCache creates a cache keeping the indexes of up to size
+// archives.
+func NewIndexCache(size int) *IndexCache {
+	return &IndexCache{size: size, indexes: map[string]*tarIndex{}}
+}
+
+func (c *IndexCache) load(key string, info os.FileInfo) (*tarIndex, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	index, ok := c.indexes[key]
+	if !ok || !index.modTime.Equal(info.ModTime()) || index.size != info.Size() {
+		return nil, false
+	}
+	c.touch(key)
+	return index, true
+}
+
+func (c *IndexCache) store(key string, index *tarIndex) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.indexes[key]; !ok && len(c.keys) >= c.size && len(c.keys) > 0 {
+		delete(c.indexes, c.keys[0])
+		c.keys = c.keys[1:]
+	}
+	c.indexes[key] = index
+	c.touch(key)
+}
+
+func (c *IndexCache) touch(key string) {
+	for i, k := range c.keys {
+		if k == key {
+			c.keys = append(c.keys[:i], c.keys[i+1:]...)
+			break
+		}
+	}
+	c.keys = append(c.keys, key)
+}
+
+// IsTar tells if the name is the one of a tar archive, compressed with
+// gzip or not.
+func IsTar(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".tar") || isTarGz(name)
+}
+
+func isTarGz(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz")
+}
+
+// NewTar opens the tar archive name of base, compressed with gzip if its
+// extension tells so, and mounts it at name. The index of the archive is
+// kept in the cache under key, if any, until the archive is modified.
+func NewTar(base afero.Fs, name string, cache *IndexCache, key string) (*Fs, error) {
+	f, err := base.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	gzipped := isTarGz(name)
+	archive := io.NewSectionReader(f, 0, info.Size())
+
+	var index *tarIndex
+	ok := false
+	if cache != nil && key != "" {
+		index, ok = cache.load(key, info)
+	}
+	if !ok {
+		index, err = readTarIndex(archive, gzipped)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		index.modTime, index.size = info.ModTime(), info.Size()
+		if cache != nil && key != "" {
+			cache.store(key, index)
+		}
+	}
+
+	regular := map[string]tarEntry{}
+	for _, entry := range index.entries {
+		if !entry.dir && entry.linkname == "" {
+			regular[entry.name] = entry
+		}
+	}
+
+	fs := newFs(name, f, info.ModTime())
+	for _, entry := range index.entries {
+		if entry.dir {
+			fs.add(entry.name, &node{mode: os.ModeDir | 0555, modTime: entry.modTime})
+			continue
+		}
+
+		data := entry
+		if entry.linkname != "" {
+			// Hard links share the data of the entry they link to.
+			target, ok := regular[entry.linkname]
+			if !ok {
+				continue
+			}
+			data = target
+		}
+
+		fs.add(entry.name, &node{
+			size:    data.size,
+			mode:    entry.mode.Perm() &^ 0222,
+			modTime: entry.modTime,
+			open: func() (io.ReadCloser, error) {
+				return openTarEntry(archive, gzipped, data)
+			},
+		})
+	}
+
+	return fs, nil
+}
+
+// readTarIndex reads the headers of the archive, noting where the data of
+// each regular file starts.
+func readTarIndex(archive *io.SectionReader, gzipped bool) (*tarIndex, error) {
+	stream, err := uncompressedStream(archive, gzipped)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	// The tar reader only reads the headers when asked for the next
+	// entry, so what it read so far is where the data of the entry
+	// starts.
+	counter := &countingReader{r: stream}
+	reader := tar.NewReader(counter)
+
+	index := &tarIndex{}
+	for {
+		header, err := reader.Next()
+
+		// The data of the sparse files is shorter than their size, so the
+		// next header is found before the end of their data.
+		if n := len(index.entries); n > 0 {
+			last := &index.entries[n-1]
+			if !last.dir && last.linkname == "" && counter.n < last.offset+last.size+blockSize {
+				last.sparse = true
+			}
+		}
+
+		if err == io.EOF {
+			return index, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		entry := tarEntry{
+			name:    tarEntryName(header.Name),
+			mode:    header.FileInfo().Mode(),
+			modTime: header.ModTime,
+		}
+		if entry.name == "/" {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			entry.dir = true
+		case tar.TypeReg, tar.TypeGNUSparse:
+			entry.size = header.Size
+			entry.offset = counter.n
+			entry.sparse = header.Typeflag == tar.TypeGNUSparse || isPAXSparse(header)
+		case tar.TypeLink:
+			entry.linkname = tarEntryName(header.Linkname)
+		default:
+			// Symbolic links and special files have no content.
+			continue
+		}
+
+		index.entries = append(index.entries, entry)
+	}
+}
+
+// openTarEntry opens the data of a regular file of the archive. Gzipped
+// archives are decompressed from their start up to it.
+func openTarEntry(archive *io.SectionReader, gzipped bool, entry tarEntry) (io.ReadCloser, error) {
+	if !gzipped && !entry.sparse {
+		return ioutil.NopCloser(io.NewSectionReader(archive, entry.offset, entry.size)), nil
+	}
+
+	stream, err := uncompressedStream(archive, gzipped)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.sparse {
+		return openSparseTarEntry(stream, entry)
+	}
+
+	if _, err := io.CopyN(ioutil.Discard, stream, entry.offset); err != nil {
+		stream.Close()
+		return nil, err
+	}
+	return readCloser{Reader: io.LimitReader(stream, entry.size), Closer: stream}, nil
+}
+
+// openSparseTarEntry reads the archive up to the sparse entry, which the
+// tar reader expands.
+func openSparseTarEntry(stream io.ReadCloser, entry tarEntry) (io.ReadCloser, error) {
+	reader := tar.NewReader(stream)
+	for {
+		header, err := reader.Next()
+		if err != nil {
+			stream.Close()
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		if tarEntryName(header.Name) == entry.name {
+			return readCloser{Reader: reader, Closer: stream}, nil
+		}
+	}
+}
+
+func uncompressedStream(archive *io.SectionReader, gzipped bool) (io.ReadCloser, error) {
+	raw := io.NewSectionReader(archive, 0, archive.Size())
+	if !gzipped {
+		return ioutil.NopCloser(raw), nil
+	}
+	return gzip.NewReader(raw)
+}
+
+func tarEntryName(name string) string {
+	return path.Clean("/" + strings.Replace(name, "\\", "/", -1))
+}
+
+func isPAXSparse(header *tar.Header) bool {
+	for key := range header.PAXRecords {
+		if strings.HasPrefix(key, "GNU.sparse.") {
+			return true
+		}
+	}
+	return false
+}
+
+// blockSize is the size of the blocks of tar archives, such as headers.
+const blockSize = 512
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
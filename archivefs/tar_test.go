@@ -0,0 +1,134 @@
+package archivefs
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTar(t *testing.T, gzipped bool) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var gz *gzip.Writer
+	if gzipped {
+		gz = gzip.NewWriter(&buf)
+		w = gz
+	}
+
+	tw := tar.NewWriter(w)
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	entries := []struct {
+		header  tar.Header
+		content string
+	}{
+		{header: tar.Header{Name: "./dir/", Typeflag: tar.TypeDir, Mode: 0755}},
+		{header: tar.Header{Name: "./dir/a.txt", Typeflag: tar.TypeReg, Mode: 0644}, content: "alpha"},
+		{header: tar.Header{Name: "deep/nested/b.txt", Typeflag: tar.TypeReg, Mode: 0644}, content: "bravo bravo"},
+		{header: tar.Header{Name: "link.txt", Typeflag: tar.TypeLink, Linkname: "./dir/a.txt"}},
+		{header: tar.Header{Name: "symlink", Typeflag: tar.TypeSymlink, Linkname: "dir"}},
+		{header: tar.Header{Name: "empty.txt", Typeflag: tar.TypeReg, Mode: 0644}},
+	}
+	for _, entry := range entries {
+		header := entry.header
+		header.ModTime = modTime
+		header.Size = int64(len(entry.content))
+		require.NoError(t, tw.WriteHeader(&header))
+		_, err := tw.Write([]byte(entry.content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	if gz != nil {
+		require.NoError(t, gz.Close())
+	}
+	return buf.Bytes()
+}
+
+func TestTar(t *testing.T) {
+	for _, name := range []string{"/archive.tar", "/archive.tar.gz", "/archive.TGZ"} {
+		t.Run(name, func(t *testing.T) {
+			base := afero.NewMemMapFs()
+			require.NoError(t, afero.WriteFile(base, name, writeTar(t, name != "/archive.tar"), 0644))
+
+			fs, err := NewTar(base, name, nil, "")
+			require.NoError(t, err)
+			defer fs.Close()
+
+			infos, err := afero.ReadDir(fs, name)
+			require.NoError(t, err)
+			names := []string{}
+			for _, info := range infos {
+				names = append(names, info.Name())
+			}
+			require.Equal(t, []string{"deep", "dir", "empty.txt", "link.txt"}, names)
+
+			for fPath, want := range map[string]string{
+				"/dir/a.txt":         "alpha",
+				"/deep/nested/b.txt": "bravo bravo",
+				"/link.txt":          "alpha",
+				"/empty.txt":         "",
+			} {
+				content, err := afero.ReadFile(fs, name+fPath)
+				require.NoError(t, err)
+				require.Equal(t, want, string(content))
+			}
+
+			// Entries can be read from any offset.
+			f, err := fs.Open(name + "/deep/nested/b.txt")
+			require.NoError(t, err)
+			buf := make([]byte, 5)
+			_, err = f.ReadAt(buf, 6)
+			require.NoError(t, err)
+			require.Equal(t, "bravo", string(buf))
+			require.NoError(t, f.Close())
+
+			require.Error(t, afero.WriteFile(fs, name+"/dir/new.txt", []byte("x"), 0644))
+		})
+	}
+}
+
+func TestTarIndexCache(t *testing.T) {
+	base := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(base, "/a.tar", writeTar(t, false), 0644))
+	cache := NewIndexCache(1)
+
+	fs, err := NewTar(base, "/a.tar", cache, "a")
+	require.NoError(t, err)
+	require.NoError(t, fs.Close())
+
+	index, ok := cache.indexes["a"]
+	require.True(t, ok)
+	for _, entry := range index.entries {
+		require.False(t, entry.sparse, entry.name)
+	}
+
+	// The cached index is used until the archive changes.
+	fs, err = NewTar(base, "/a.tar", cache, "a")
+	require.NoError(t, err)
+	require.NoError(t, fs.Close())
+	require.Same(t, index, cache.indexes["a"])
+
+	require.NoError(t, base.Chtimes("/a.tar", time.Now(), time.Now().Add(time.Hour)))
+	fs, err = NewTar(base, "/a.tar", cache, "a")
+	require.NoError(t, err)
+	content, err := afero.ReadFile(fs, "/a.tar/dir/a.txt")
+	require.NoError(t, err)
+	require.Equal(t, "alpha", string(content))
+	require.NoError(t, fs.Close())
+	require.NotSame(t, index, cache.indexes["a"])
+
+	// The least recently used index is dropped.
+	require.NoError(t, afero.WriteFile(base, "/b.tar", writeTar(t, false), 0644))
+	fs, err = NewTar(base, "/b.tar", cache, "b")
+	require.NoError(t, err)
+	require.NoError(t, fs.Close())
+	require.Len(t, cache.indexes, 1)
+	require.Contains(t, cache.indexes, "b")
+}
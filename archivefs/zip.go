@@ -0,0 +1,149 @@
+package archivefs
+
+import (
+	"archive/zip"
+	"compress/flate"
+	"errors"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+const (
+	zipFlagEncrypted      = 0x1
+	zipFlagDataDescriptor = 0x8
+	zipMethodAES          = 99
+)
+
+// ErrUnsupportedEncryption is returned when opening an entry encrypted
+// with something other than the traditional PKWARE encryption.
+var ErrUnsupportedEncryption = errors.New("unsupported archive encryption")
+
+// NewZip opens the zip archive name of base and mounts it at name.
+// The password is used to decrypt entries using the traditional PKWARE
+// encryption. Entries can't be read if it is missing or wrong.
+func NewZip(base afero.Fs, name, password string) (*Fs, error) {
+	f, err := base.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	reader, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	fs := newFs(name, f, info.ModTime())
+	for _, zf := range reader.File {
+		zf := zf
+		entryName := strings.Replace(zf.Name, "\\", "/", -1)
+
+		if strings.HasSuffix(entryName, "/") || zf.Mode().IsDir() {
+			fs.add(entryName, &node{mode: os.ModeDir | 0555, modTime: zf.Modified})
+			continue
+		}
+
+		fs.add(entryName, &node{
+			size:    int64(zf.UncompressedSize64),
+			mode:    zf.Mode().Perm() &^ 0222,
+			modTime: zf.Modified,
+			open: func() (io.ReadCloser, error) {
+				return openZipFile(f, zf, password)
+			},
+		})
+	}
+
+	return fs, nil
+}
+
+func openZipFile(archive io.ReaderAt, zf *zip.File, password string) (io.ReadCloser, error) {
+	if zf.Flags&zipFlagEncrypted == 0 {
+		return zf.Open()
+	}
+
+	if zf.Method == zipMethodAES {
+		return nil, ErrUnsupportedEncryption
+	}
+
+	if password == "" {
+		return nil, os.ErrPermission
+	}
+
+	offset, err := zf.DataOffset()
+	if err != nil {
+		return nil, err
+	}
+
+	checkByte := byte(zf.CRC32 >> 24)
+	if zf.Flags&zipFlagDataDescriptor != 0 {
+		checkByte = byte(zf.ModifiedTime >> 8)
+	}
+
+	raw := io.NewSectionReader(archive, offset, int64(zf.CompressedSize64))
+	decrypted, err := newZipCryptoReader(raw, password, checkByte)
+	if err != nil {
+		return nil, err
+	}
+
+	switch zf.Method {
+	case zip.Store:
+		return ioutil.NopCloser(decrypted), nil
+	case zip.Deflate:
+		return flate.NewReader(decrypted), nil
+	default:
+		return nil, zip.ErrAlgorithm
+	}
+}
+
+// zipCrypto implements the traditional PKWARE decryption as described
+// in section 6.1 of the zip APPNOTE.
+type zipCrypto struct {
+	r    io.Reader
+	keys [3]uint32
+}
+
+func newZipCryptoReader(r io.Reader, password string, checkByte byte) (io.Reader, error) {
+	z := &zipCrypto{r: r, keys: [3]uint32{0x12345678, 0x23456789, 0x34567890}}
+	for i := 0; i < len(password); i++ {
+		z.update(password[i])
+	}
+
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(z, header); err != nil {
+		return nil, err
+	}
+
+	if header[11] != checkByte {
+		return nil, os.ErrPermission
+	}
+
+	return z, nil
+}
+
+func (z *zipCrypto) update(b byte) {
+	z.keys[0] = crc32.IEEETable[byte(z.keys[0])^b] ^ (z.keys[0] >> 8)
+	z.keys[1] += z.keys[0] & 0xff
+	z.keys[1] = z.keys[1]*134775813 + 1
+	z.keys[2] = crc32.IEEETable[byte(z.keys[2])^byte(z.keys[1]>>24)] ^ (z.keys[2] >> 8)
+}
+
+func (z *zipCrypto) Read(p []byte) (int, error) {
+	n, err := z.r.Read(p)
+	for i := 0; i < n; i++ {
+		temp := z.keys[2] | 2
+		p[i] ^= byte((temp * (temp ^ 1)) >> 8)
+		z.update(p[i])
+	}
+	return n, err
+}
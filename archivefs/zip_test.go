@@ -0,0 +1,62 @@
+package archivefs
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// testdata/encrypted.zip was made with Info-ZIP: dir/plain.txt is stored
+// unencrypted, stored.txt and deflated.txt are encrypted with the
+// traditional PKWARE encryption, password "hunter2".
+func openTestZip(t *testing.T, password string) *Fs {
+	t.Helper()
+
+	archive, err := ioutil.ReadFile("testdata/encrypted.zip")
+	require.NoError(t, err)
+	base := afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(base, "/a.zip", archive, 0644))
+
+	fs, err := NewZip(base, "/a.zip", password)
+	require.NoError(t, err)
+	t.Cleanup(func() { fs.Close() })
+	return fs
+}
+
+func TestZip(t *testing.T) {
+	fs := openTestZip(t, "hunter2")
+
+	for fPath, want := range map[string]string{
+		"/dir/plain.txt": "alpha",
+		"/stored.txt":    "secret stored",
+		"/deflated.txt":  strings.Repeat("deflated secret ", 50),
+	} {
+		content, err := afero.ReadFile(fs, "/a.zip"+fPath)
+		require.NoError(t, err, fPath)
+		require.Equal(t, want, string(content), fPath)
+	}
+
+	info, err := fs.Stat("/a.zip/dir")
+	require.NoError(t, err)
+	require.True(t, info.IsDir())
+}
+
+func TestZipWrongPassword(t *testing.T) {
+	for _, password := range []string{"", "hunter3", "wrong"} {
+		fs := openTestZip(t, password)
+
+		// The unencrypted entries don't need it.
+		content, err := afero.ReadFile(fs, "/a.zip/dir/plain.txt")
+		require.NoError(t, err)
+		require.Equal(t, "alpha", string(content))
+
+		for _, fPath := range []string{"/stored.txt", "/deflated.txt"} {
+			_, err := afero.ReadFile(fs, "/a.zip"+fPath)
+			require.True(t, os.IsPermission(err), "%q %s: %v", password, fPath, err)
+		}
+	}
+}
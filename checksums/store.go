@@ -0,0 +1,161 @@
+// Package checksums keeps the checksums of files in a JSON sidecar file,
+// so unchanged files aren't hashed again.
+package checksums
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Entry holds the checksums of a file by algorithm, as of its
+// modification time.
+type Entry struct {
+	ModTime   time.Time         `json:"modTime"`
+	Checksums map[string]string `json:"checksums"`
+}
+
+// Store keeps the checksums in memory and writes them to its file
+// periodically, never on the path of a request.
+type Store struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]Entry
+	dirty   bool
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewStore creates a store backed by the JSON file at path, loading the
+// checksums it holds, and flushes them to it every interval.
+func NewStore(path string, interval time.Duration) (*Store, error) {
+	s := &Store{
+		path:    path,
+		entries: map[string]Entry{},
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	content, err := ioutil.ReadFile(path)
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(content, &s.entries); err != nil {
+			return nil, err
+		}
+	}
+
+	go s.flushEvery(interval)
+	return s, nil
+}
+
+// Load returns the checksums of the file by algorithm, unless it was
+// modified since they were stored.
+func (s *Store) Load(path string, modTime time.Time) map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[path]
+	if !ok || !entry.ModTime.Equal(modTime) {
+		return nil
+	}
+
+	checksums := make(map[string]string, len(entry.Checksums))
+	for algo, checksum := range entry.Checksums {
+		checksums[algo] = checksum
+	}
+	return checksums
+}
+
+// Store stores the checksum of the file with the given algorithm. The
+// checksums of an older version of the file are dropped.
+func (s *Store) Store(path, algo, checksum string, modTime time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[path]
+	if !ok || !entry.ModTime.Equal(modTime) {
+		entry = Entry{ModTime: modTime, Checksums: map[string]string{}}
+	}
+
+	entry.Checksums[algo] = checksum
+	s.entries[path] = entry
+	s.dirty = true
+}
+
+// Flush writes the checksums to the file if they changed since the last
+// flush. It writes to a temporary file first so a failed write doesn't
+// lose the stored ones.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	content, err := json.Marshal(s.entries)
+	s.dirty = false
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := writeFile(s.path, content); err != nil {
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Close stops the periodic flushes and flushes the checksums one last
+// time.
+func (s *Store) Close() error {
+	close(s.stop)
+	<-s.done
+	return s.Flush()
+}
+
+func (s *Store) flushEvery(interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				log.Printf("couldn't save the checksums: %v", err)
+			}
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func writeFile(path string, content []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,55 @@
+package checksums
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checksums.json")
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	s, err := NewStore(path, time.Hour)
+	require.NoError(t, err)
+	require.Nil(t, s.Load("/a.txt", modTime))
+
+	s.Store("/a.txt", "md5", "aaa", modTime)
+	s.Store("/a.txt", "sha1", "bbb", modTime)
+	require.Equal(t, map[string]string{"md5": "aaa", "sha1": "bbb"}, s.Load("/a.txt", modTime))
+
+	// Modifying the file invalidates its checksums.
+	later := modTime.Add(time.Second)
+	require.Nil(t, s.Load("/a.txt", later))
+	s.Store("/a.txt", "md5", "ccc", later)
+	require.Equal(t, map[string]string{"md5": "ccc"}, s.Load("/a.txt", later))
+	require.Nil(t, s.Load("/a.txt", modTime))
+
+	require.NoError(t, s.Close())
+
+	s, err = NewStore(path, time.Hour)
+	require.NoError(t, err)
+	defer s.Close()
+	require.Equal(t, map[string]string{"md5": "ccc"}, s.Load("/a.txt", later))
+}
+
+func TestStoreFlushesPeriodically(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "checksums.json")
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	s, err := NewStore(path, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer s.Close()
+	s.Store("/a.txt", "md5", "aaa", modTime)
+
+	require.Eventually(t, func() bool {
+		loaded, err := NewStore(path, time.Hour)
+		if err != nil {
+			return false
+		}
+		defer loaded.Close()
+		return loaded.Load("/a.txt", modTime)["md5"] == "aaa"
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,126 @@
+// Package clipboard keeps the files the users cut or copied until they
+// paste them, so every tab of a user shares the same clipboard.
+package clipboard
+
+import (
+	"path"
+	"sync"
+	"time"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+)
+
+// Mode tells what pasting the paths of a clipboard does.
+type Mode string
+
+const (
+	// Copy copies the paths, which stay in the clipboard.
+	Copy Mode = "copy"
+	// Cut moves the paths, which are removed from the clipboard.
+	Cut Mode = "cut"
+)
+
+// Clipboard holds the paths a user cut or copied.
+type Clipboard struct {
+	Mode    Mode      `json:"mode"`
+	Paths   []string  `json:"paths"`
+	Expires time.Time `json:"expires"`
+}
+
+// Store keeps a clipboard per user, in memory, for a limited time.
+type Store struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[uint]Clipboard
+}
+
+// NewStore creates a store whose clipboards expire after ttl.
+func NewStore(ttl time.Duration) *Store {
+	return &Store{
+		ttl:     ttl,
+		entries: map[uint]Clipboard{},
+	}
+}
+
+// Get returns the clipboard of the user, or false if it is empty.
+func (s *Store) Get(userID uint) (Clipboard, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clip, ok := s.entries[userID]
+	if ok && time.Now().After(clip.Expires) {
+		delete(s.entries, userID)
+		return Clipboard{}, false
+	}
+
+	return clip, ok
+}
+
+// Set replaces the clipboard of the user. It returns
+// errors.ErrInvalidRequestParams if the mode is unknown or there are no
+// paths.
+func (s *Store) Set(userID uint, mode Mode, paths []string) (Clipboard, error) {
+	if (mode != Copy && mode != Cut) || len(paths) == 0 {
+		return Clipboard{}, errors.ErrInvalidRequestParams
+	}
+
+	cleaned := make([]string, 0, len(paths))
+	for _, p := range paths {
+		cleaned = append(cleaned, path.Clean("/"+p))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, clip := range s.entries {
+		if now.After(clip.Expires) {
+			delete(s.entries, id)
+		}
+	}
+
+	clip := Clipboard{Mode: mode, Paths: cleaned, Expires: now.Add(s.ttl)}
+	s.entries[userID] = clip
+	return clip, nil
+}
+
+// Remove removes paths from the clipboard of the user, which is cleared
+// once empty.
+func (s *Store) Remove(userID uint, paths ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	clip, ok := s.entries[userID]
+	if !ok {
+		return
+	}
+
+	kept := clip.Paths[:0:0]
+	for _, p := range clip.Paths {
+		removed := false
+		for _, r := range paths {
+			if p == r {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			kept = append(kept, p)
+		}
+	}
+
+	if len(kept) == 0 {
+		delete(s.entries, userID)
+		return
+	}
+
+	clip.Paths = kept
+	s.entries[userID] = clip
+}
+
+// Clear empties the clipboard of the user.
+func (s *Store) Clear(userID uint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, userID)
+}
@@ -35,20 +35,25 @@ database.
 The path must be for a json or yaml file.`,
 	Args: jsonYamlArg,
 	Run: python(func(cmd *cobra.Command, args []string, d pythonData) {
-		var key []byte
+		var key, shareKey []byte
 		if d.hadDB {
 			settings, err := d.store.Settings.Get()
 			checkErr(err)
 			key = settings.Key
+			shareKey = settings.ShareKey
 		} else {
 			key = generateKey()
 		}
+		if len(shareKey) == 0 {
+			shareKey = generateKey()
+		}
 
 		file := settingsFile{}
 		err := unmarshal(args[0], &file)
 		checkErr(err)
 
 		file.Settings.Key = key
+		file.Settings.ShareKey = shareKey
 		err = d.store.Settings.Save(file.Settings)
 		checkErr(err)
 
@@ -30,6 +30,7 @@ override the options.`,
 
 		s := &settings.Settings{
 			Key:        generateKey(),
+			ShareKey:   generateKey(),
 			Signup:     mustGetBool(flags, "signup"),
 			Shell:      convertCmdStrToCmdArray(mustGetString(flags, "shell")),
 			AuthMethod: authMethod,
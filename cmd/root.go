@@ -1,32 +1,44 @@
 package cmd
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	homedir "github.com/mitchellh/go-homedir"
 	"github.com/spf13/afero"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	v "github.com/spf13/viper"
+	"golang.org/x/text/language"
 	lumberjack "gopkg.in/natefinch/lumberjack.v2"
 
 	"github.com/filebrowser/filebrowser/v2/auth"
 	"github.com/filebrowser/filebrowser/v2/diskcache"
+	"github.com/filebrowser/filebrowser/v2/files"
 	fbhttp "github.com/filebrowser/filebrowser/v2/http"
 	"github.com/filebrowser/filebrowser/v2/img"
+	"github.com/filebrowser/filebrowser/v2/s3fs"
 	"github.com/filebrowser/filebrowser/v2/settings"
 	"github.com/filebrowser/filebrowser/v2/storage"
+	"github.com/filebrowser/filebrowser/v2/timeoutfs"
 	"github.com/filebrowser/filebrowser/v2/users"
+	"github.com/filebrowser/filebrowser/v2/versions"
+	"github.com/filebrowser/filebrowser/v2/warmer"
 )
 
 var (
@@ -57,7 +69,7 @@ func addServerFlags(flags *pflag.FlagSet) {
 	flags.StringP("port", "p", "8080", "port to listen on")
 	flags.StringP("cert", "t", "", "tls certificate")
 	flags.StringP("key", "k", "", "tls key")
-	flags.StringP("root", "r", ".", "root to prepend to relative paths")
+	flags.StringP("root", "r", ".", "root to prepend to relative paths, a local path or an s3://bucket/prefix URL")
 	flags.String("socket", "", "socket to listen to (cannot be used with address, port, cert nor key flags)")
 	flags.Uint32("socket-perm", 0666, "unix socket file permissions")
 	flags.StringP("baseurl", "b", "", "base url")
@@ -67,6 +79,84 @@ func addServerFlags(flags *pflag.FlagSet) {
 	flags.Bool("disable-preview-resize", false, "disable resize of image previews")
 	flags.Bool("disable-exec", false, "disables Command Runner feature")
 	flags.Bool("disable-type-detection-by-header", false, "disables type detection by reading file headers")
+	flags.String("svg-handling", "sanitize", "how to serve SVG files: sanitize, download or raw")
+	flags.String("readme-names", "README.md,README.txt", "comma separated file names rendered as directory readme (disabled if empty)")
+	flags.Int64("readme-max-size", 1024*1024, "maximum size in bytes of a rendered directory readme")
+	flags.Bool("serve-index-files", false, "serve the index file of a directory instead of its listing")
+	flags.String("index-names", "index.html,index.htm", "comma separated file names used as directory index")
+	flags.Bool("show-xattrs", false, "show the extended attributes of files")
+	flags.Int("max-batch-size", 100, "maximum number of operations in a batch request (unlimited if 0)")
+	flags.String("cache-max-age", "", "comma separated glob=seconds pairs setting Cache-Control max-age on raw files, e.g. \"*.js=86400,*.html=0\"")
+	flags.Int("max-heavy-ops", 0, "maximum number of concurrent archive, thumbnail and search operations (unlimited if 0)")
+	flags.Int("heavy-ops-timeout", 30, "seconds a heavy operation waits for a free slot before failing with 503")
+	flags.Bool("follow-symlinks", false, "follow symbolic links pointing outside of the user scope")
+	flags.String("history-log", "", "file logging every move and rename (a hidden .history file in each directory if empty)")
+	flags.String("home-path", "", "path the root of the files redirects to, unless ?nohome=1 is set")
+	flags.String("pins-path", "", "JSON file storing the paths pinned by the users (pins are disabled if empty)")
+	flags.Bool("webdav", false, "serve the files through WebDAV under /dav")
+	flags.String("s3-endpoint", "", "endpoint of the S3 compatible service used when the root is an s3://bucket/prefix URL")
+	flags.Int("s3-cache-ttl", 10, "seconds S3 listings are cached for")
+	flags.String("listing-columns", "icon,name,size,modified", "comma separated columns shown in listings: icon, name, size, modified, mode, owner, type, checksum")
+	flags.Int("operation-timeout", 0, "seconds after which opening, stating or listing files fails with 504 (disabled if 0)")
+	flags.Int("qr-size", 256, "size in pixels of the QR codes of the files and shares")
+	flags.String("upload-allow-extensions", "", "comma separated extensions of the files that can be uploaded (all if empty)")
+	flags.String("upload-block-extensions", "", "comma separated extensions of the files that can't be uploaded, taking precedence over the allowed ones")
+	flags.Int("hex-dump-max-size", 64*1024, "maximum size in bytes of the files shown as hex dumps")
+	flags.String("header-html", "", "trusted HTML shown above every page, or @path of a file holding it")
+	flags.String("footer-html", "", "trusted HTML shown below every page, or @path of a file holding it")
+	flags.Int64("large-file-threshold", 0, "size in bytes above which files are flagged as large (disabled if 0)")
+	flags.Bool("dir-mtime-from-contents", false, "show the modification time of the most recently modified child of the listed directories, which is slow on huge directories")
+	flags.Bool("canonical-slash", false, "redirect the resources of directories to a path with a trailing slash and of files to one without")
+	flags.Bool("image-dimensions", false, "show the dimensions of the images, read from their header")
+	flags.String("default-mime-type", "application/octet-stream", "MIME type of the raw files whose type can't be detected")
+	flags.Bool("show-child-counts", false, "show the number of entries of the subdirectories in listings")
+	flags.Int("max-path-depth", 0, "maximum number of segments of the requested paths, e.g. 64 (0 for unlimited)")
+	flags.String("display-name-pattern", "", "regular expression whose matches in the names of files are replaced when displaying them")
+	flags.String("display-name-replacement", "", "replacement of the matches of the display name pattern, which can refer to its groups as $1")
+	flags.Int64("preview-max-size", 10*1024*1024, "maximum size in bytes of the content of the text files previewed, larger ones are truncated")
+	flags.String("preview-max-sizes", "", "comma separated category=bytes pairs overriding the preview max size by extension, or \"text\" for all text files, e.g. \"json=2097152,log=1048576\"")
+	flags.String("health-path", "", "path of an unauthenticated endpoint telling whether the root can be reached, e.g. /healthz (disabled if empty)")
+	flags.Int("symlink-resolve-depth", 0, "maximum number of symbolic links followed to show where links lead, up to 40 (disabled if 0)")
+	flags.String("office-converter", "", "path to a headless LibreOffice binary converting office documents to PDF to preview them, e.g. /usr/bin/soffice (disabled if empty)")
+	flags.String("collation-locale", "", "BCP 47 tag of the locale whose rules sort the names of the files, e.g. fr (natural order if empty)")
+	flags.Int("thumb-size", 128, "size in pixels of the thumbnails of images and PDFs")
+	flags.String("pdf-renderer", "", "path to pdftoppm, rendering the first page of PDFs as their thumbnail, e.g. /usr/bin/pdftoppm (disabled if empty)")
+	flags.String("checksum-store", "", "path to a JSON file keeping the checksums of the files until they are modified (disabled if empty)")
+	flags.String("cors-origins", "", "comma separated origins browsers can call the API from, e.g. \"https://app.example.com\", or \"*\" for any (disabled if empty)")
+	flags.String("upload-hook", "", "command run on the uploaded files, whose path is in $FILE, e.g. \"clamscan --no-summary $FILE\"")
+	flags.Bool("reject-on-hook-failure", false, "run the upload hook before the upload completes and reject the file with a 422 if the hook fails")
+	flags.String("timezone", "", "IANA name of the timezone the dates are shown in, e.g. Europe/Paris (local timezone if empty)")
+	flags.String("versions-layout", string(versions.DefaultLayout), "path of the versions of the files, with {version}, {name}, {dir} and {path} placeholders, relative to the directory of the file unless it starts with /")
+	flags.Int("keep-versions", 0, "number of previous versions kept when saving or uploading over a file (disabled if 0)")
+	flags.String("text-extensions", ".yaml,.yml,.toml,.ini,.conf,.env", "comma separated extensions of the files previewed as text whatever their MIME type")
+	flags.Bool("require-signed-urls", false, "only serve the files and listings to the requests with a URL signed with the url signing secret, see the sign command (disables the batch, clipboard and compress routes)")
+	flags.String("url-signing-secret", "", "secret the URLs are signed with")
+	flags.String("warm-paths", "", "comma separated directories, relative to the root, listed on startup so their listings are cached, e.g. for S3 or network mounts (disabled if empty)")
+	flags.Int("warm-interval", 0, "interval in seconds the warm paths are listed again at (only on startup if 0)")
+	flags.Float64("binary-threshold", files.DefaultBinaryThreshold, "ratio of non-printable characters in the first bytes of text files above which they are shown as binary instead of previewed (disabled if 0)")
+	flags.String("force-download-extensions", "", "comma separated extensions of the files always downloaded as application/octet-stream, never shown inline, e.g. \".exe,.sh,.html\"")
+	flags.Bool("auto-view", false, "show the directories mostly holding images as a mosaic, whatever the view mode of the user")
+	flags.Bool("disable-fetch", false, "disable fetching remote files into the user directories")
+	flags.Int("fetch-timeout", 300, "seconds after which fetching a remote file fails")
+	flags.Int64("fetch-max-size", 1024*1024*1024, "maximum size in bytes of the remote files fetched (unlimited if 0)")
+	flags.Bool("graceful-preview-errors", false, "still show the files whose content can't be read, with their metadata and a download link, instead of failing")
+	flags.String("ui-locales", "", "comma separated languages the interface is offered in, e.g. \"en,fr,de\" (all if empty)")
+	flags.Int("flatten-max-files", 10000, "maximum number of files of the flattened listings, which list the subdirectories too (unlimited if 0)")
+	flags.Bool("case-insensitive", false, "open the missing files under a name of their directory only differing by case, e.g. /Docs for /docs")
+	flags.Int("max-upload-files", 100, "maximum number of files of a multipart upload (unlimited if 0)")
+	flags.Bool("allow-symlink-creation", false, "allow creating symbolic links to relative targets inside of the user scope")
+	flags.String("save-line-endings", "", "line endings the saved text files are converted to, \"lf\" or \"crlf\", unless the request asks otherwise (kept if empty)")
+	flags.Bool("save-final-newline", false, "make the saved text files end with a single newline, unless the request asks otherwise")
+	flags.Int("tree-max-nodes", 10000, "maximum number of files of the directory trees (unlimited if 0)")
+	flags.Int("stream-buffer-size", 0, "size in bytes of the buffer the downloads are sent through, larger for fast networks and smaller for many concurrent downloads (default of Go if 0)")
+	flags.Int("backlinks-max-depth", 16, "maximum number of directory levels walked looking for the links to a file (unlimited if 0)")
+	flags.Bool("head-exists-header", false, "tell if the files probed with HEAD exist in an X-Exists header")
+	flags.Bool("build-search-index", false, "index the names of the files in memory at startup, kept up to date by watching them, so searches don't walk the tree (local disk only)")
+	flags.Int64("mobile-preview-max-size", 512*1024, "maximum size in bytes of the text previews sent to mobile devices (the desktop one if 0)")
+	flags.Int("mobile-thumb-size", 0, "size in pixels of the thumbnails sent to mobile devices (the desktop one if 0)")
+	flags.Int("mobile-big-preview-size", 720, "size in pixels of the big image previews sent to mobile devices (the desktop one if 0)")
+	flags.String("aliases", "", "comma separated alias=path pairs serving the files of a path under another one too, e.g. \"/latest=/releases/v2.3.1\"")
+	flags.Bool("hash-on-stat", false, "add the modification times to the nanosecond and a hash of the size, modification time and ends of the files to their metadata, for the sync clients")
 }
 
 var rootCmd = &cobra.Command{
@@ -112,6 +202,8 @@ user created with the credentials from options "username" and "password".`,
 
 		if !d.hadDB {
 			quickSetup(cmd.Flags(), d)
+		} else {
+			ensureShareKey(d)
 		}
 
 		// build img service
@@ -135,9 +227,42 @@ user created with the credentials from options "username" and "password".`,
 		server := getRunParams(cmd.Flags(), d.store)
 		setupLog(server.Log)
 
-		root, err := filepath.Abs(server.Root)
-		checkErr(err)
-		server.Root = root
+		if strings.HasPrefix(server.Root, s3fs.Scheme) {
+			ttl := time.Duration(server.S3CacheTTL) * time.Second
+			rootFs, err := s3fs.NewFromURL(server.Root, server.S3Endpoint, ttl) //nolint:govet
+			checkErr(err)
+			users.RootFs = rootFs
+			server.Root = "/"
+		} else {
+			root, err := filepath.Abs(server.Root) //nolint:govet
+			checkErr(err)
+			server.Root = root
+		}
+
+		if server.OperationTimeout > 0 {
+			users.RootFs = timeoutfs.New(users.RootFs, time.Duration(server.OperationTimeout)*time.Second)
+		}
+
+		// Interrupted uploads leave temporary files behind. Looking for
+		// them can take a while on large trees, so it doesn't block.
+		go func() {
+			rootFs := afero.NewBasePathFs(users.RootFs, server.Root)
+			removed, err := files.RemoveUploadTemps(rootFs, "/")
+			if err != nil {
+				log.Printf("couldn't remove the temporary files of interrupted uploads: %v", err)
+			}
+			if removed > 0 {
+				log.Printf("removed %d temporary files of interrupted uploads", removed)
+			}
+		}()
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+
+		if len(server.WarmPaths) > 0 {
+			rootFs := afero.NewBasePathFs(users.RootFs, server.Root)
+			go warmer.Warm(ctx, rootFs, server.WarmPaths, time.Duration(server.WarmInterval)*time.Second)
+		}
 
 		adr := server.Address + ":" + server.Port
 
@@ -250,6 +375,207 @@ func getRunParams(flags *pflag.FlagSet, st *storage.Storage) *settings.Server {
 	_, disableExec := getParamB(flags, "disable-exec")
 	server.EnableExec = !disableExec
 
+	if val, set := getParamB(flags, "svg-handling"); set {
+		server.SVGHandling = val
+	}
+
+	server.ReadmeNames = splitList(getParam(flags, "readme-names"))
+
+	readmeMaxSize, err := strconv.ParseInt(getParam(flags, "readme-max-size"), 10, 64)
+	checkErr(err)
+	server.ReadmeMaxSize = readmeMaxSize
+
+	server.ServeIndexFiles = getParamBool(flags, "serve-index-files")
+	server.IndexNames = splitList(getParam(flags, "index-names"))
+
+	server.ShowXattrs = getParamBool(flags, "show-xattrs")
+	server.MaxBatchSize = getParamInt(flags, "max-batch-size")
+
+	server.CacheMaxAge = map[string]int{}
+	for _, item := range splitList(getParam(flags, "cache-max-age")) {
+		pair := strings.SplitN(item, "=", 2)
+		if len(pair) != 2 {
+			checkErr(fmt.Errorf("invalid cache-max-age entry %q", item))
+		}
+		maxAge, err := strconv.Atoi(strings.TrimSpace(pair[1]))
+		checkErr(err)
+		server.CacheMaxAge[strings.TrimSpace(pair[0])] = maxAge
+	}
+
+	server.MaxConcurrentHeavyOps = getParamInt(flags, "max-heavy-ops")
+	server.HeavyOpsTimeout = getParamInt(flags, "heavy-ops-timeout")
+
+	server.FollowSymlinks = getParamBool(flags, "follow-symlinks")
+
+	server.HistoryLog = getParam(flags, "history-log")
+
+	server.HomePath = getParam(flags, "home-path")
+
+	server.PinsPath = getParam(flags, "pins-path")
+
+	server.EnableWebDAV = getParamBool(flags, "webdav")
+
+	server.S3Endpoint = getParam(flags, "s3-endpoint")
+	server.S3CacheTTL = getParamInt(flags, "s3-cache-ttl")
+
+	server.ListingColumns = files.ParseColumns(splitList(getParam(flags, "listing-columns")))
+
+	server.OperationTimeout = getParamInt(flags, "operation-timeout")
+
+	server.QRSize = getParamInt(flags, "qr-size")
+
+	server.UploadAllowExtensions = normalizeExtensions(splitList(getParam(flags, "upload-allow-extensions")))
+	server.UploadBlockExtensions = normalizeExtensions(splitList(getParam(flags, "upload-block-extensions")))
+
+	server.HexDumpMaxSize = getParamInt(flags, "hex-dump-max-size")
+
+	server.HeaderHTML = readHTMLParam(getParam(flags, "header-html"))
+	server.FooterHTML = readHTMLParam(getParam(flags, "footer-html"))
+
+	largeFileThreshold, err := strconv.ParseInt(getParam(flags, "large-file-threshold"), 10, 64)
+	checkErr(err)
+	server.LargeFileThreshold = largeFileThreshold
+
+	server.DirMTimeFromContents = getParamBool(flags, "dir-mtime-from-contents")
+
+	server.CanonicalSlash = getParamBool(flags, "canonical-slash")
+
+	server.ImageDimensions = getParamBool(flags, "image-dimensions")
+
+	server.DefaultMimeType = getParam(flags, "default-mime-type")
+
+	server.ShowChildCounts = getParamBool(flags, "show-child-counts")
+
+	server.MaxPathDepth = getParamInt(flags, "max-path-depth")
+
+	server.DisplayNamePattern = getParam(flags, "display-name-pattern")
+	_, err = regexp.Compile(server.DisplayNamePattern)
+	checkErr(err)
+	server.DisplayNameReplacement = getParam(flags, "display-name-replacement")
+
+	previewMaxSize, err := strconv.ParseInt(getParam(flags, "preview-max-size"), 10, 64)
+	checkErr(err)
+	server.PreviewMaxSize = previewMaxSize
+	server.PreviewMaxSizes = map[string]int64{}
+	for _, item := range splitList(getParam(flags, "preview-max-sizes")) {
+		pair := strings.SplitN(item, "=", 2)
+		if len(pair) != 2 {
+			checkErr(fmt.Errorf("invalid preview-max-sizes entry %q", item))
+		}
+		maxSize, err := strconv.ParseInt(strings.TrimSpace(pair[1]), 10, 64)
+		checkErr(err)
+		server.PreviewMaxSizes[strings.ToLower(strings.TrimPrefix(strings.TrimSpace(pair[0]), "."))] = maxSize
+	}
+
+	server.HealthPath = getParam(flags, "health-path")
+
+	server.SymlinkResolveDepth = getParamInt(flags, "symlink-resolve-depth")
+
+	server.OfficeConverter = getParam(flags, "office-converter")
+
+	server.Locale = getParam(flags, "collation-locale")
+	if server.Locale != "" {
+		_, err = language.Parse(server.Locale)
+		checkErr(err)
+	}
+
+	server.ThumbSize = getParamInt(flags, "thumb-size")
+	if server.ThumbSize < 1 {
+		checkErr(errors.New("thumb-size must be a positive number of pixels"))
+	}
+
+	server.PDFRenderer = getParam(flags, "pdf-renderer")
+
+	server.ChecksumStorePath = getParam(flags, "checksum-store")
+
+	server.CORSOrigins = splitList(getParam(flags, "cors-origins"))
+
+	server.UploadHook = getParam(flags, "upload-hook")
+	server.RejectOnHookFailure = getParamBool(flags, "reject-on-hook-failure")
+
+	server.Timezone = getParam(flags, "timezone")
+	if server.Timezone != "" {
+		_, err = time.LoadLocation(server.Timezone)
+		checkErr(err)
+	}
+
+	versionsLayout, err := versions.ParseLayout(getParam(flags, "versions-layout"))
+	checkErr(err)
+	server.VersionsLayout = string(versionsLayout)
+
+	server.KeepVersions = getParamInt(flags, "keep-versions")
+
+	server.TextExtensions = normalizeExtensions(splitList(getParam(flags, "text-extensions")))
+
+	server.RequireSignedURLs = getParamBool(flags, "require-signed-urls")
+	server.URLSigningSecret = getParam(flags, "url-signing-secret")
+	if server.RequireSignedURLs && server.URLSigningSecret == "" {
+		checkErr(errors.New("require-signed-urls needs a url-signing-secret"))
+	}
+
+	server.WarmPaths = splitList(getParam(flags, "warm-paths"))
+	server.WarmInterval = getParamInt(flags, "warm-interval")
+
+	server.BinaryThreshold, err = strconv.ParseFloat(getParam(flags, "binary-threshold"), 64)
+	checkErr(err)
+
+	server.ForceDownloadExtensions = normalizeExtensions(splitList(getParam(flags, "force-download-extensions")))
+
+	server.AutoView = getParamBool(flags, "auto-view")
+
+	server.DisableFetch = getParamBool(flags, "disable-fetch")
+	server.FetchTimeout = getParamInt(flags, "fetch-timeout")
+	fetchMaxSize, err := strconv.ParseInt(getParam(flags, "fetch-max-size"), 10, 64)
+	checkErr(err)
+	server.FetchMaxSize = fetchMaxSize
+
+	server.GracefulPreviewErrors = getParamBool(flags, "graceful-preview-errors")
+
+	server.UILocales = splitList(getParam(flags, "ui-locales"))
+
+	server.FlattenMaxFiles = getParamInt(flags, "flatten-max-files")
+
+	server.CaseInsensitive = getParamBool(flags, "case-insensitive")
+
+	server.MaxUploadFiles = getParamInt(flags, "max-upload-files")
+
+	server.AllowSymlinkCreation = getParamBool(flags, "allow-symlink-creation")
+
+	saveLineEndings, err := files.ParseLineEndings(getParam(flags, "save-line-endings"))
+	checkErr(err)
+	server.SaveLineEndings = saveLineEndings
+	server.SaveFinalNewline = getParamBool(flags, "save-final-newline")
+
+	server.TreeMaxNodes = getParamInt(flags, "tree-max-nodes")
+
+	server.StreamBufferSize = getParamInt(flags, "stream-buffer-size")
+
+	server.BacklinksMaxDepth = getParamInt(flags, "backlinks-max-depth")
+
+	server.HeadExistsHeader = getParamBool(flags, "head-exists-header")
+
+	server.BuildSearchIndex = getParamBool(flags, "build-search-index")
+
+	mobilePreviewMaxSize, err := strconv.ParseInt(getParam(flags, "mobile-preview-max-size"), 10, 64)
+	checkErr(err)
+	server.MobileLimits = settings.MobileLimits{
+		PreviewMaxSize: mobilePreviewMaxSize,
+		ThumbSize:      getParamInt(flags, "mobile-thumb-size"),
+		BigPreviewSize: getParamInt(flags, "mobile-big-preview-size"),
+	}
+
+	server.Aliases = map[string]string{}
+	for _, item := range splitList(getParam(flags, "aliases")) {
+		pair := strings.SplitN(item, "=", 2)
+		if len(pair) != 2 {
+			checkErr(fmt.Errorf("invalid aliases entry %q", item))
+		}
+		server.Aliases[path.Join("/", strings.TrimSpace(pair[0]))] = path.Join("/", strings.TrimSpace(pair[1]))
+	}
+	checkErr(files.Aliases(server.Aliases).Validate())
+
+	server.HashOnStat = getParamBool(flags, "hash-on-stat")
+
 	return server
 }
 
@@ -261,7 +587,11 @@ func getRunParams(flags *pflag.FlagSet, st *storage.Storage) *settings.Server {
 // the flag and then the value from env/config/gotten by viper.
 // https://github.com/spf13/viper/pull/331
 func getParamB(flags *pflag.FlagSet, key string) (string, bool) {
-	value, _ := flags.GetString(key)
+	// Use the flag's string value so that non-string flags can be read too.
+	var value string
+	if flag := flags.Lookup(key); flag != nil {
+		value = flag.Value.String()
+	}
 
 	// If set on Flags, use it.
 	if flags.Changed(key) {
@@ -282,6 +612,70 @@ func getParam(flags *pflag.FlagSet, key string) string {
 	return val
 }
 
+// getParamBool returns a boolean parameter, from the flags if set there,
+// else from env/config, else the default of the flag. Unlike the "set"
+// result of getParamB, false values such as --follow-symlinks=false or
+// FB_FOLLOW_SYMLINKS=false are false.
+func getParamBool(flags *pflag.FlagSet, key string) bool {
+	if !flags.Changed(key) && v.IsSet(key) {
+		return v.GetBool(key)
+	}
+
+	val, err := flags.GetBool(key)
+	checkErr(err)
+	return val
+}
+
+func getParamInt(flags *pflag.FlagSet, key string) int {
+	val, err := strconv.Atoi(getParam(flags, key))
+	checkErr(err)
+	return val
+}
+
+// readHTMLParam returns the HTML of a parameter, which is read from a
+// file if it is an @path. Missing files are skipped with a warning.
+func readHTMLParam(value string) string {
+	if !strings.HasPrefix(value, "@") {
+		return value
+	}
+
+	content, err := ioutil.ReadFile(strings.TrimPrefix(value, "@"))
+	if err != nil {
+		log.Printf("skipping HTML snippet: %v", err)
+		return ""
+	}
+
+	return string(content)
+}
+
+// normalizeExtensions lowercases the extensions and makes sure they start
+// with a dot.
+func normalizeExtensions(exts []string) []string {
+	normalized := make([]string, 0, len(exts))
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		normalized = append(normalized, ext)
+	}
+	return normalized
+}
+
+// splitList splits a comma separated list, dropping empty elements.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func setupLog(logMethod string) {
 	switch logMethod {
 	case "stdout":
@@ -303,6 +697,7 @@ func setupLog(logMethod string) {
 func quickSetup(flags *pflag.FlagSet, d pythonData) {
 	set := &settings.Settings{
 		Key:           generateKey(),
+		ShareKey:      generateKey(),
 		Signup:        false,
 		CreateUserDir: false,
 		Defaults: settings.UserDefaults{
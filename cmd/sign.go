@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/filebrowser/filebrowser/v2/signedurl"
+)
+
+func init() {
+	rootCmd.AddCommand(signCmd)
+	signCmd.Flags().String("url-signing-secret", "", "secret the URLs are signed with")
+	signCmd.Flags().Duration("ttl", time.Hour, "time the URL is valid for")
+}
+
+var signCmd = &cobra.Command{
+	Use:   "sign <path>",
+	Short: "Signs a URL",
+	Long: `Signs the path of a URL, below the base URL, such as
+/api/raw/docs/report.pdf, for the servers requiring signed URLs.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		secret := getParam(cmd.Flags(), "url-signing-secret")
+		if secret == "" {
+			checkErr(errors.New("the url-signing-secret is required"))
+		}
+		ttl, err := cmd.Flags().GetDuration("ttl")
+		checkErr(err)
+		fmt.Println(signedurl.SignURL(args[0], ttl, secret))
+	},
+}
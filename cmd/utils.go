@@ -49,6 +49,19 @@ func generateKey() []byte {
 	return k
 }
 
+// ensureShareKey generates the key of the share tokens of the databases
+// made before it existed.
+func ensureShareKey(d pythonData) {
+	set, err := d.store.Settings.Get()
+	checkErr(err)
+	if len(set.ShareKey) > 0 {
+		return
+	}
+
+	set.ShareKey = generateKey()
+	checkErr(d.store.Settings.Save(set))
+}
+
 type cobraFunc func(cmd *cobra.Command, args []string)
 type pythonFunc func(cmd *cobra.Command, args []string, data pythonData)
 
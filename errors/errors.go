@@ -18,4 +18,13 @@ var (
 	ErrInvalidRequestParams = errors.New("invalid request params")
 	ErrSourceIsParent       = errors.New("source is parent")
 	ErrRootUserDeletion     = errors.New("user with id 1 can't be deleted")
+	ErrUnsafeArchivePath    = errors.New("archive entry is outside of the destination")
+	ErrOperationTimeout     = errors.New("operation timed out")
+	ErrChecksumMismatch     = errors.New("checksum mismatch")
+	ErrFileTooLarge         = errors.New("file is too large")
+	ErrSpecialFile          = errors.New("file is a device, named pipe or socket")
+	ErrUploadRejected       = errors.New("the upload hook rejected the file")
+	ErrNameTooLong          = errors.New("file name is too long")
+	ErrAliasLoop            = errors.New("the aliases form a loop")
+	ErrBatchTooLarge        = errors.New("the batch has too many operations")
 )
@@ -0,0 +1,83 @@
+package files
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+)
+
+// Aliases map the paths the files can be requested under to their own,
+// such as "/latest" to "/releases/v2.3.1", for stable links to moving
+// targets. The paths under an alias are the ones under its target.
+type Aliases map[string]string
+
+// Resolve returns the path p stands for, replacing its longest aliased
+// prefix by the target of the alias until none is left. The paths without
+// an alias are returned as they are. It fails with errors.ErrAliasLoop if
+// an alias leads back to itself.
+func (a Aliases) Resolve(p string) (string, error) {
+	visited := map[string]bool{}
+	for {
+		alias, ok := a.match(p)
+		if !ok {
+			return p, nil
+		}
+		if visited[alias] {
+			return "", fmt.Errorf("%s: %w", alias, errors.ErrAliasLoop)
+		}
+		visited[alias] = true
+
+		rest := strings.TrimPrefix(path.Join("/", p), path.Join("/", alias))
+		resolved := path.Join("/", a[alias], rest)
+		if strings.HasSuffix(p, "/") && resolved != "/" {
+			resolved += "/"
+		}
+		p = resolved
+	}
+}
+
+// Validate checks that none of the aliases leads to a loop.
+func (a Aliases) Validate() error {
+	for alias := range a {
+		if _, err := a.Resolve(alias); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// match returns the longest alias which is p or one of its parents.
+func (a Aliases) match(p string) (string, bool) {
+	p = path.Join("/", p)
+
+	match, longest, found := "", -1, false
+	for alias := range a {
+		prefix := path.Join("/", alias)
+		if len(prefix) <= longest {
+			continue
+		}
+		if p == prefix || prefix == "/" || strings.HasPrefix(p, prefix+"/") {
+			match, longest, found = alias, len(prefix), true
+		}
+	}
+	return match, found
+}
+
+// ShowUnder moves the path of the file, and the ones of the items of its
+// listing, under p, the path it was requested under, such as an alias of
+// it. The links of the listing then keep the alias.
+func (i *FileInfo) ShowUnder(p string) {
+	dir := strings.TrimSuffix(i.Path, "/")
+	i.Path = p
+
+	if i.Listing == nil {
+		return
+	}
+	for _, item := range i.Items {
+		if rel := strings.TrimPrefix(item.Path, dir+"/"); rel != item.Path {
+			item.Path = path.Join(p, rel)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package files
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+)
+
+func TestAliasesResolve(t *testing.T) {
+	aliases := Aliases{
+		"/latest":        "/releases/v2.3.1",
+		"/latest/docs":   "/manuals/v2",
+		"/stable/":       "/latest",
+		"/releases/beta": "/releases/v2.4.0-rc1",
+	}
+	require.NoError(t, aliases.Validate())
+
+	for _, tc := range []struct{ path, resolved string }{
+		{"/latest", "/releases/v2.3.1"},
+		{"/latest/", "/releases/v2.3.1/"},
+		{"/latest/bin/app", "/releases/v2.3.1/bin/app"},
+		{"/latest/docs/index.md", "/manuals/v2/index.md"},
+		{"/stable/bin/", "/releases/v2.3.1/bin/"},
+		{"/latestx", "/latestx"},
+		{"/releases/beta/notes.txt", "/releases/v2.4.0-rc1/notes.txt"},
+		{"/other/latest", "/other/latest"},
+		{"/", "/"},
+	} {
+		resolved, err := aliases.Resolve(tc.path)
+		require.NoError(t, err, tc.path)
+		assert.Equal(t, tc.resolved, resolved, tc.path)
+	}
+
+	resolved, err := Aliases(nil).Resolve("/latest")
+	require.NoError(t, err)
+	assert.Equal(t, "/latest", resolved)
+}
+
+func TestAliasesLoop(t *testing.T) {
+	for _, aliases := range []Aliases{
+		{"/a": "/a"},
+		{"/a": "/b", "/b": "/a"},
+		{"/a": "/b/c", "/b": "/a/x"},
+		{"/": "/home"},
+	} {
+		err := aliases.Validate()
+		assert.True(t, stderrors.Is(err, errors.ErrAliasLoop), "%v: %v", aliases, err)
+	}
+
+	_, err := Aliases{"/a": "/b", "/b": "/a"}.Resolve("/a/file.txt")
+	assert.True(t, stderrors.Is(err, errors.ErrAliasLoop), err)
+	assert.NoError(t, Aliases{"/a": "/b/a"}.Validate(), "a target under the alias isn't a loop")
+}
@@ -0,0 +1,89 @@
+package files
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/rules"
+)
+
+// Backlinks walks scope for the symbolic links to the file at target,
+// the ones resolving to it once all the links along their path are
+// followed. Directories deeper than maxDepth levels below scope aren't
+// walked, unless maxDepth is negative, nor the unreadable ones and the
+// ones the checker rejects. The walk stops as soon as ctx is done. Only
+// the OS filesystem has links, there are none on others.
+func Backlinks(ctx context.Context, fs afero.Fs, scope, target string, maxDepth int, checker rules.Checker) ([]string, error) {
+	links := []string{}
+
+	realTarget, ok := realPath(fs, target)
+	if !ok {
+		if _, err := fs.Stat(target); err != nil {
+			return nil, err
+		}
+		return links, nil
+	}
+	realTarget, err := filepath.EvalSymlinks(realTarget)
+	if err != nil {
+		return nil, err
+	}
+
+	scope = path.Join("/", filepath.ToSlash(filepath.Clean(scope)))
+	err = afero.Walk(fs, scope, func(fPath string, f os.FileInfo, err error) error {
+		fPath = path.Join("/", filepath.ToSlash(filepath.Clean(fPath)))
+		if err != nil {
+			if fPath == scope {
+				return err
+			}
+			// An unreadable directory doesn't fail the walk.
+			return nil
+		}
+
+		if err := ctx.Err(); err != nil { //nolint:shadow
+			return err
+		}
+
+		if fPath == scope {
+			return nil
+		}
+
+		if !checker.Check(fPath) {
+			if f.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if f.IsDir() {
+			depth := strings.Count(strings.Trim(strings.TrimPrefix(fPath, scope), "/"), "/") + 1
+			if maxDepth >= 0 && depth > maxDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !IsSymlink(f.Mode()) {
+			return nil
+		}
+
+		p, ok := realPath(fs, fPath)
+		if !ok {
+			return nil
+		}
+		// Broken links and cycles lead nowhere.
+		if resolved, err := filepath.EvalSymlinks(p); err == nil && resolved == realTarget {
+			links = append(links, fPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
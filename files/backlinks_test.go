@@ -0,0 +1,71 @@
+package files
+
+import (
+	"context"
+	stderrors "errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestBacklinks(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{"docs", "sub", "deep/x/y", "denied"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0755))
+	}
+	for _, name := range []string{"docs/a.txt", "docs/b.txt"} {
+		require.NoError(t, afero.WriteFile(afero.NewOsFs(), filepath.Join(root, name), []byte(name), 0644))
+	}
+	for link, target := range map[string]string{
+		"direct":          "docs/a.txt",
+		"sub/relative":    "../docs/a.txt",
+		"absolute":        filepath.Join(root, "docs/a.txt"),
+		"dirlink":         "docs",
+		"via":             "dirlink/a.txt",
+		"chain":           "direct",
+		"other":           "docs/b.txt",
+		"broken":          "docs/missing.txt",
+		"cycle":           "cycle",
+		"deep/x/y/far":    "../../../docs/a.txt",
+		"denied/too":      "../docs/a.txt",
+		"docs/same-place": "a.txt",
+	} {
+		require.NoError(t, os.Symlink(target, filepath.Join(root, link)))
+	}
+
+	fs := afero.NewBasePathFs(afero.NewOsFs(), root)
+	checker := denyPaths{"/denied": true}
+
+	links, err := Backlinks(context.Background(), fs, "/", "/docs/a.txt", -1, checker)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{
+		"/absolute", "/chain", "/deep/x/y/far", "/direct", "/docs/same-place", "/sub/relative", "/via",
+	}, links)
+
+	links, err = Backlinks(context.Background(), fs, "/", "/docs/a.txt", 1, checker)
+	require.NoError(t, err)
+	assert.NotContains(t, links, "/deep/x/y/far")
+	assert.Contains(t, links, "/sub/relative")
+
+	links, err = Backlinks(context.Background(), fs, "/", "/docs", -1, checker)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/dirlink"}, links)
+
+	_, err = Backlinks(context.Background(), fs, "/", "/docs/missing.txt", -1, checker)
+	assert.True(t, os.IsNotExist(err))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = Backlinks(ctx, fs, "/", "/docs/a.txt", -1, checker)
+	assert.True(t, stderrors.Is(err, context.Canceled))
+
+	links, err = Backlinks(context.Background(), testutil.NewFs(t, map[string]string{"/a.txt": "a"}), "/", "/a.txt", -1, checker)
+	require.NoError(t, err)
+	assert.Empty(t, links, "other filesystems have no links")
+}
@@ -0,0 +1,45 @@
+package files
+
+import (
+	"bytes"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultBinaryThreshold is the ratio of non-printable characters above
+// which the content of a text file is considered binary.
+const DefaultBinaryThreshold = 0.3
+
+// looksBinary checks if the first bytes of a file have a null byte or a
+// ratio of non-printable characters, or invalid UTF-8, above threshold.
+// A rune cut at the end of the bytes isn't counted.
+func looksBinary(content []byte, threshold float64) bool {
+	if bytes.IndexByte(content, 0) != -1 {
+		return true
+	}
+
+	total, nonPrintable := 0, 0
+	for len(content) > 0 {
+		r, size := utf8.DecodeRune(content)
+		if r == utf8.RuneError && size <= 1 && !utf8.FullRune(content) {
+			break
+		}
+		content = content[size:]
+
+		total++
+		if (r == utf8.RuneError && size <= 1) || !isPrintable(r) {
+			nonPrintable++
+		}
+	}
+
+	return total > 0 && float64(nonPrintable)/float64(total) > threshold
+}
+
+func isPrintable(r rune) bool {
+	switch r {
+	case '\t', '\n', '\r', '\f', '\v', '\b', 0x1b:
+		// Whitespace, backspaces and the escapes of colored logs.
+		return true
+	}
+	return unicode.IsPrint(r) || unicode.IsSpace(r)
+}
@@ -0,0 +1,70 @@
+package files
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestLooksBinary(t *testing.T) {
+	testCases := map[string]struct {
+		content string
+		binary  bool
+	}{
+		"text":          {content: "hello\tworld\r\n"},
+		"colored log":   {content: "\x1b[31merror\x1b[0m\n"},
+		"multibyte":     {content: "héllo wörld 日本"},
+		"cut rune":      {content: "héllo"[:2]},
+		"null byte":     {content: "hello\x00world", binary: true},
+		"control chars": {content: "ab\x01\x02\x03\x04", binary: true},
+		"invalid utf8":  {content: "a\xff\xfe\xfd\xfc", binary: true},
+		"few controls":  {content: "hello world\x01"},
+		"empty":         {content: ""},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.binary, looksBinary([]byte(tc.content), DefaultBinaryThreshold))
+		})
+	}
+}
+
+func TestBinaryDetected(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/notes.txt": "notes",
+		"/dump.txt":  "dump" + strings.Repeat("\x00", 20),
+	})
+
+	testCases := map[string]struct {
+		typ    string
+		binary bool
+	}{
+		"/notes.txt": {typ: "text"},
+		"/dump.txt":  {typ: "blob", binary: true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			file, err := NewFileInfo(FileOptions{
+				Fs: fs, Path: name, Modify: true, Expand: true, Checker: testutil.AllowAll{},
+				BinaryThreshold: DefaultBinaryThreshold,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.typ, file.Type)
+			assert.Equal(t, tc.binary, file.BinaryDetected)
+			if tc.binary {
+				assert.Empty(t, file.Content)
+			}
+		})
+	}
+
+	// Without threshold, the content is read whatever it is.
+	file, err := NewFileInfo(FileOptions{Fs: fs, Path: "/dump.txt", Modify: true, Expand: true, Checker: testutil.AllowAll{}})
+	require.NoError(t, err)
+	assert.Equal(t, "text", file.Type)
+	assert.False(t, file.BinaryDetected)
+}
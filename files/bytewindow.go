@@ -0,0 +1,45 @@
+package files
+
+import (
+	"io"
+)
+
+// ByteWindow is the window of bytes a text file is opened at.
+type ByteWindow struct {
+	Offset int64
+	// Length is the number of bytes read from the offset, up to the
+	// preview limit of the file. The limit is read if it's zero.
+	Length int64
+}
+
+// readByteWindow sets the content to the bytes of the window instead of
+// the whole file. The window is clamped to the end of the file and PastEnd
+// is set if the offset is at or beyond it. The content being partial, it
+// can't be saved.
+func (i *FileInfo) readByteWindow(reader io.ReaderAt) error {
+	offset := i.byteWindow.Offset
+	length := i.byteWindow.Length
+	if limit := i.previewLimits.limit(i.Extension); length <= 0 || length > limit {
+		length = limit
+	}
+
+	i.Offset = &offset
+	i.Type = "textImmutable"
+
+	if offset >= i.Size {
+		i.PastEnd = true
+		return nil
+	}
+	if length > i.Size-offset {
+		length = i.Size - offset
+	}
+
+	buffer := make([]byte, length)
+	n, err := reader.ReadAt(buffer, offset)
+	if err != nil && err != io.EOF {
+		return err
+	}
+
+	i.Content = string(buffer[:n])
+	return nil
+}
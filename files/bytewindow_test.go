@@ -0,0 +1,45 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestByteWindow(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{"/app.log": "0123456789"})
+
+	testCases := map[string]struct {
+		window  ByteWindow
+		limit   int64
+		content string
+		pastEnd bool
+	}{
+		"middle":         {window: ByteWindow{Offset: 2, Length: 3}, content: "234"},
+		"until the end":  {window: ByteWindow{Offset: 7, Length: 100}, content: "789"},
+		"without length": {window: ByteWindow{Offset: 4}, content: "456789"},
+		"preview limit":  {window: ByteWindow{Offset: 1, Length: 8}, limit: 4, content: "1234"},
+		"at the end":     {window: ByteWindow{Offset: 10, Length: 3}, pastEnd: true},
+		"beyond the end": {window: ByteWindow{Offset: 5000, Length: 3}, pastEnd: true},
+		"from the start": {window: ByteWindow{Length: 1}, content: "0"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			window := tc.window
+			file, err := NewFileInfo(FileOptions{
+				Fs: fs, Path: "/app.log", Modify: true, Expand: true, Checker: testutil.AllowAll{},
+				ByteWindow: &window, PreviewLimits: PreviewLimits{Default: tc.limit},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.content, file.Content)
+			assert.Equal(t, tc.pastEnd, file.PastEnd)
+			require.NotNil(t, file.Offset)
+			assert.Equal(t, tc.window.Offset, *file.Offset)
+			assert.Equal(t, "textImmutable", file.Type)
+		})
+	}
+}
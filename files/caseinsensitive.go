@@ -0,0 +1,55 @@
+package files
+
+import (
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// resolveCase returns the path of the entry of the parent directory whose
+// name matches the last element of the path of a missing file ignoring
+// case, such as "/docs/Notes.txt" for "/docs/notes.txt". Only the parent
+// directory is looked into, and it returns false if no entry, or several,
+// match.
+func resolveCase(fs afero.Fs, p string) (string, bool) {
+	if _, err := fs.Stat(p); !os.IsNotExist(err) {
+		return "", false
+	}
+
+	dir, name := path.Split(strings.TrimSuffix(p, "/"))
+	if name == "" {
+		return "", false
+	}
+
+	file, err := fs.Open(dir)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	names, err := file.Readdirnames(-1)
+	if err != nil {
+		return "", false
+	}
+
+	match := ""
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			if match != "" {
+				return "", false
+			}
+			match = n
+		}
+	}
+	if match == "" {
+		return "", false
+	}
+
+	resolved := path.Join(dir, match)
+	if strings.HasSuffix(p, "/") {
+		resolved += "/"
+	}
+	return resolved, true
+}
@@ -0,0 +1,53 @@
+package files
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestCaseInsensitive(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/Docs/Notes.txt":    "notes",
+		"/Docs/sub/a.txt":    "a",
+		"/twins/readme":      "1",
+		"/twins/README":      "2",
+		"/private/Secret.md": "secret",
+	})
+
+	open := func(p string, caseInsensitive bool) (*FileInfo, error) {
+		return NewFileInfo(FileOptions{
+			Fs: fs, Path: p, Checker: denyPaths{"/private/Secret.md": true}, CaseInsensitive: caseInsensitive,
+		})
+	}
+
+	_, err := open("/Docs/notes.TXT", false)
+	require.True(t, os.IsNotExist(err))
+
+	file, err := open("/Docs/notes.TXT", true)
+	require.NoError(t, err)
+	assert.Equal(t, "/Docs/Notes.txt", file.Path)
+	assert.Equal(t, "Notes.txt", file.Name)
+
+	file, err = open("/docs/", true)
+	require.NoError(t, err)
+	assert.Equal(t, "/Docs/", file.Path)
+
+	// Only the last element is looked for.
+	_, err = open("/docs/SUB/a.txt", true)
+	require.True(t, os.IsNotExist(err))
+
+	file, err = open("/twins/readme", true)
+	require.NoError(t, err)
+	assert.Equal(t, "/twins/readme", file.Path)
+
+	_, err = open("/twins/ReadMe", true)
+	require.True(t, os.IsNotExist(err), "ambiguous names don't match")
+
+	_, err = open("/private/secret.md", true)
+	require.True(t, os.IsPermission(err))
+}
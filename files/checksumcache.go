@@ -0,0 +1,38 @@
+package files
+
+import "time"
+
+// ChecksumCache keeps the checksums of files until they're modified, so
+// they aren't computed again.
+type ChecksumCache interface {
+	// Load returns the checksums of the file by algorithm, unless it was
+	// modified since they were stored.
+	Load(path string, modTime time.Time) map[string]string
+	// Store stores the checksum of the file with the given algorithm.
+	Store(path, algo, checksum string, modTime time.Time)
+}
+
+// RefreshChecksums wraps a cache so the checksums are computed again,
+// and stored, whatever it holds.
+func RefreshChecksums(cache ChecksumCache) ChecksumCache {
+	if cache == nil {
+		return nil
+	}
+	return refreshedChecksums{cache}
+}
+
+type refreshedChecksums struct {
+	ChecksumCache
+}
+
+func (refreshedChecksums) Load(string, time.Time) map[string]string {
+	return nil
+}
+
+// CacheInvalidator is implemented by the filesystems caching what they
+// read, such as listings.
+type CacheInvalidator interface {
+	// InvalidateCache drops what is cached about the file, such as its
+	// listing if it's a directory and the listing of its directory.
+	InvalidateCache(name string)
+}
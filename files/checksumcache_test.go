@@ -0,0 +1,59 @@
+package files
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+type mapChecksums map[string]map[string]string
+
+func (m mapChecksums) Load(path string, _ time.Time) map[string]string {
+	return m[path]
+}
+
+func (m mapChecksums) Store(path, algo, checksum string, _ time.Time) {
+	if m[path] == nil {
+		m[path] = map[string]string{}
+	}
+	m[path][algo] = checksum
+}
+
+func TestChecksumCache(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{"/a.txt": "a", "/dir/b.txt": "b"})
+	cache := mapChecksums{"/dir/b.txt": {"md5": "cached"}}
+
+	file, err := NewFileInfo(FileOptions{Fs: fs, Path: "/a.txt", Checker: testutil.AllowAll{}, Checksums: cache})
+	require.NoError(t, err)
+	require.Nil(t, file.Checksums)
+	require.NoError(t, file.Checksum("md5"))
+	require.Equal(t, "0cc175b9c0f1b6a831c399e269772661", cache["/a.txt"]["md5"])
+
+	file, err = NewFileInfo(FileOptions{Fs: fs, Path: "/a.txt", Checker: testutil.AllowAll{}, Checksums: cache})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"md5": "0cc175b9c0f1b6a831c399e269772661"}, file.Checksums)
+
+	dir, err := NewFileInfo(FileOptions{Fs: fs, Path: "/dir", Expand: true, Checker: testutil.AllowAll{}, Checksums: cache})
+	require.NoError(t, err)
+	require.Len(t, dir.Items, 1)
+	require.Nil(t, dir.Items[0].Checksums)
+	require.NoError(t, dir.Items[0].Checksum("md5"))
+	require.Equal(t, "cached", dir.Items[0].Checksums["md5"])
+}
+
+func TestRefreshChecksums(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{"/a.txt": "a"})
+	cache := mapChecksums{"/a.txt": {"md5": "stale"}}
+
+	file, err := NewFileInfo(FileOptions{Fs: fs, Path: "/a.txt", Checker: testutil.AllowAll{}, Checksums: RefreshChecksums(cache)})
+	require.NoError(t, err)
+	require.Nil(t, file.Checksums)
+	require.NoError(t, file.Checksum("md5"))
+	require.Equal(t, "0cc175b9c0f1b6a831c399e269772661", file.Checksums["md5"])
+	require.Equal(t, "0cc175b9c0f1b6a831c399e269772661", cache["/a.txt"]["md5"])
+
+	require.Nil(t, RefreshChecksums(nil))
+}
@@ -0,0 +1,63 @@
+package files
+
+import (
+	"path"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/rules"
+)
+
+// childCountTimeout is the time given to count the children of each
+// subdirectory of a listing.
+const childCountTimeout = 500 * time.Millisecond
+
+// countChildren counts the entries directly inside dir which a listing of
+// it would show. It returns false if the directory can't be read before
+// the timeout.
+func countChildren(fs afero.Fs, dir string, checker rules.Checker, timeout time.Duration) (int, bool) {
+	// Buffered so the read can finish once its result is abandoned.
+	done := make(chan []string, 1)
+	go func() {
+		file, err := fs.Open(dir)
+		if err != nil {
+			done <- nil
+			return
+		}
+		defer file.Close()
+
+		names, err := file.Readdirnames(-1)
+		if err != nil {
+			done <- nil
+			return
+		}
+		done <- names
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var names []string
+	select {
+	case names = <-done:
+		if names == nil {
+			return 0, false
+		}
+	case <-timer.C:
+		return 0, false
+	}
+
+	count := 0
+	for _, name := range names {
+		if !isHiddenEntry(name) && checker.Check(path.Join(dir, name)) {
+			count++
+		}
+	}
+	return count, true
+}
+
+// isHiddenEntry checks if name is one of the entries listings never show.
+func isHiddenEntry(name string) bool {
+	return name == HistoryFile || name == SortFile || name == ViewFile || name == MetaDir || IsUploadTemp(name)
+}
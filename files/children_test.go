@@ -0,0 +1,54 @@
+package files
+
+import (
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+// slowOpenFs takes a while to open the directories named "slow".
+type slowOpenFs struct {
+	afero.Fs
+}
+
+func (fs slowOpenFs) Open(name string) (afero.File, error) {
+	if info, err := fs.Fs.Stat(name); err == nil && info.IsDir() && info.Name() == "slow" {
+		time.Sleep(2 * childCountTimeout)
+	}
+	return fs.Fs.Open(name)
+}
+
+func TestChildCounts(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/photos/a.jpg":           "",
+		"/photos/b.jpg":           "",
+		MetaPath("/photos/b.jpg"): "",
+		"/photos/" + HistoryFile:  "",
+		"/empty/":                 "",
+		"/slow/a.txt":             "",
+		"/file.txt":               "",
+	})
+
+	file, err := NewFileInfo(FileOptions{
+		Fs: slowOpenFs{fs}, Path: "/", Expand: true, Checker: testutil.AllowAll{}, ShowChildCounts: true,
+	})
+	require.NoError(t, err)
+
+	counts := map[string]*int{}
+	for _, item := range file.Items {
+		counts[item.Name] = item.ChildCount
+	}
+
+	two, zero := 2, 0
+	assert.Equal(t, map[string]*int{"photos": &two, "empty": &zero, "slow": nil, "file.txt": nil}, counts)
+}
+
+func TestChildCountsUnreadable(t *testing.T) {
+	_, ok := countChildren(afero.NewMemMapFs(), "/missing", testutil.AllowAll{}, time.Second)
+	assert.False(t, ok)
+}
@@ -0,0 +1,56 @@
+package files
+
+import (
+	"sort"
+	"sync"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// collators pools the collators of each locale. Building one is costly,
+// but a collator can't be used by several sorts at once.
+var collators sync.Map // locale -> *sync.Pool
+
+func collatorPool(locale string) *sync.Pool {
+	if pool, ok := collators.Load(locale); ok {
+		return pool.(*sync.Pool)
+	}
+
+	tag := language.Make(locale)
+	pool, _ := collators.LoadOrStore(locale, &sync.Pool{
+		New: func() interface{} {
+			return collate.New(tag, collate.IgnoreCase, collate.Numeric)
+		},
+	})
+	return pool.(*sync.Pool)
+}
+
+// byCollation sorts by name following the rules of a locale.
+type byCollation struct {
+	byName
+	collator *collate.Collator
+}
+
+func (l byCollation) Less(i, j int) bool {
+	if l.Items[i].IsDir != l.Items[j].IsDir {
+		return l.byName.Less(i, j)
+	}
+
+	return l.collator.CompareString(l.Items[j].Name, l.Items[i].Name) < 0
+}
+
+func (l Listing) sortByName(reverse bool) {
+	var s sort.Interface = byName(l)
+	if l.Locale != "" {
+		pool := collatorPool(l.Locale)
+		collator := pool.Get().(*collate.Collator)
+		defer pool.Put(collator)
+		s = byCollation{byName: byName(l), collator: collator}
+	}
+
+	if reverse {
+		s = sort.Reverse(s)
+	}
+	sort.Sort(s)
+}
@@ -0,0 +1,35 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sortedNames(locale string, asc bool, names ...string) []string {
+	listing := Listing{Sorting: Sorting{By: "name", Asc: asc}, Locale: locale}
+	for _, name := range names {
+		listing.Items = append(listing.Items, &FileInfo{Name: name})
+	}
+	listing.ApplySort()
+
+	sorted := make([]string, 0, len(names))
+	for _, item := range listing.Items {
+		sorted = append(sorted, item.Name)
+	}
+	return sorted
+}
+
+func TestApplySortLocale(t *testing.T) {
+	names := []string{"f.txt", "été.txt", "e.txt", "z.txt", "Ö.txt", "o.txt", "file10", "file9"}
+
+	require.Equal(t, []string{"e.txt", "f.txt", "file9", "file10", "o.txt", "z.txt", "été.txt", "Ö.txt"},
+		sortedNames("", false, names...))
+	require.Equal(t, []string{"e.txt", "été.txt", "f.txt", "file9", "file10", "o.txt", "Ö.txt", "z.txt"},
+		sortedNames("fr", false, names...))
+	require.Equal(t, []string{"z.txt", "Ö.txt", "o.txt", "file10", "file9", "f.txt", "été.txt", "e.txt"},
+		sortedNames("fr", true, names...))
+
+	// Swedish sorts Ö after Z.
+	require.Equal(t, []string{"o.txt", "z.txt", "Ö.txt"}, sortedNames("sv", false, "Ö.txt", "z.txt", "o.txt"))
+}
@@ -0,0 +1,57 @@
+package files
+
+import (
+	"log"
+	"strings"
+)
+
+// ListingColumns are the columns a listing can be shown with.
+var ListingColumns = []string{"icon", "name", "size", "modified", "mode", "owner", "type", "checksum"}
+
+// DefaultListingColumns are the columns shown when none are configured.
+var DefaultListingColumns = []string{"icon", "name", "size", "modified"}
+
+// sortColumns are the columns a listing can be sorted by.
+var sortColumns = []string{"name", "size", "modified"}
+
+// ParseColumns returns the valid listing columns among names, in order and
+// without duplicates. Invalid names are ignored with a warning. The name
+// column is always included.
+func ParseColumns(names []string) []string {
+	columns := []string{"name"}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "modtime" {
+			name = "modified"
+		}
+
+		if !hasColumn(ListingColumns, name) {
+			log.Printf("ignoring unknown listing column %q", name)
+			continue
+		}
+
+		if !hasColumn(columns, name) {
+			columns = append(columns, name)
+		}
+	}
+
+	return columns
+}
+
+// SortableBy tells if a listing shown with the given columns can be
+// sorted by key. Listings with no configured columns use the default ones.
+func SortableBy(columns []string, key string) bool {
+	if columns == nil {
+		columns = DefaultListingColumns
+	}
+	return hasColumn(sortColumns, key) && hasColumn(columns, key)
+}
+
+func hasColumn(columns []string, name string) bool {
+	for _, column := range columns {
+		if column == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseColumns(t *testing.T) {
+	tests := map[string]struct {
+		names []string
+		want  []string
+	}{
+		"empty":         {nil, []string{"name"}},
+		"valid":         {[]string{"size", "owner"}, []string{"name", "size", "owner"}},
+		"invalid":       {[]string{"size", "color", ""}, []string{"name", "size"}},
+		"duplicates":    {[]string{"name", "Size", "size "}, []string{"name", "size"}},
+		"modtime alias": {[]string{"modtime"}, []string{"name", "modified"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ParseColumns(tt.names))
+		})
+	}
+}
+
+func TestSortableBy(t *testing.T) {
+	columns := []string{"name", "modified", "owner"}
+
+	assert.True(t, SortableBy(columns, "modified"))
+	assert.False(t, SortableBy(columns, "size"))
+	assert.False(t, SortableBy(columns, "owner"))
+	assert.True(t, SortableBy(nil, "size"))
+}
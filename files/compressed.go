@@ -0,0 +1,112 @@
+package files
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"log"
+	"mime"
+	"path/filepath"
+	"strings"
+)
+
+// previewMaxSize is the default maximum size of the content of a text
+// file that is sent to be previewed, see PreviewLimits.
+const previewMaxSize = 10 * 1024 * 1024 // 10 MB
+
+// decompressor returns a function decompressing the files with the given
+// extension, or nil if they can't be previewed decompressed.
+func decompressor(ext string) func(io.Reader) (io.ReadCloser, error) {
+	switch strings.ToLower(ext) {
+	case ".gz":
+		return func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		}
+	case ".bz2":
+		return func(r io.Reader) (io.ReadCloser, error) {
+			return ioutil.NopCloser(bzip2.NewReader(r)), nil
+		}
+	default:
+		return nil
+	}
+}
+
+// detectCompressedText checks if the file is a compressed text file, such
+// as a rotated log, and if so marks it as an immutable text file whose
+// content is the decompressed one, up to its preview limit. It returns false
+// if the file isn't a compressed text file.
+func (i *FileInfo) detectCompressedText(saveContent, readHeader bool) bool {
+	decompress := decompressor(i.Extension)
+	if decompress == nil {
+		return false
+	}
+
+	innerExt := filepath.Ext(strings.TrimSuffix(i.Name, i.Extension))
+	mimetype := mime.TypeByExtension(innerExt)
+	if mimetype != "" && !strings.HasPrefix(mimetype, "text") {
+		return false
+	}
+
+	if mimetype == "" && !readHeader && !saveContent {
+		return false
+	}
+
+	var content []byte
+	if mimetype == "" || saveContent {
+		var err error
+		if saveContent {
+			content, err = i.readDecompressed(decompress, func(r io.Reader) ([]byte, error) {
+				preview, err := i.readPreview(r, innerExt)
+				return []byte(preview), err
+			})
+		} else {
+			content, err = i.readDecompressed(decompress, func(r io.Reader) ([]byte, error) {
+				return ioutil.ReadAll(io.LimitReader(r, 512))
+			})
+		}
+		if err != nil {
+			log.Print(err)
+			return false
+		}
+
+		sniff := content
+		if len(sniff) > 512 {
+			sniff = sniff[:512]
+		}
+
+		if mimetype == "" && isBinary(sniff) {
+			i.PreviewLimit, i.Truncated = 0, false
+			return false
+		}
+	}
+
+	// Saving the decompressed content would replace the compressed file.
+	i.Type = "textImmutable"
+	i.Decompressed = true
+	if saveContent && i.Line > 0 {
+		if err := i.readLineWindow(bytes.NewReader(content)); err != nil {
+			log.Print(err)
+		}
+	} else if saveContent {
+		i.Content = string(content)
+	}
+	return true
+}
+
+func (i *FileInfo) readDecompressed(decompress func(io.Reader) (io.ReadCloser, error), read func(io.Reader) ([]byte, error)) ([]byte, error) {
+	file, err := i.Fs.Open(i.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader, err := decompress(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	return read(reader)
+}
@@ -0,0 +1,48 @@
+package files
+
+import (
+	"log"
+	"regexp"
+	"sync"
+)
+
+// NameTransform turns the names of files into the ones they are displayed
+// with, replacing the matches of a regular expression. It never changes
+// the names used to reach the files.
+type NameTransform struct {
+	Pattern     string
+	Replacement string
+}
+
+var namePatterns sync.Map // pattern string -> *regexp.Regexp, nil if invalid
+
+// Apply returns the display name of the file name. It returns the name
+// itself if there is no pattern or it is invalid.
+func (t NameTransform) Apply(name string) string {
+	if t.Pattern == "" {
+		return name
+	}
+
+	re, ok := namePatterns.Load(t.Pattern)
+	if !ok {
+		compiled, err := regexp.Compile(t.Pattern)
+		if err != nil {
+			log.Printf("invalid display name pattern %q: %v", t.Pattern, err)
+			compiled = nil
+		}
+		re, _ = namePatterns.LoadOrStore(t.Pattern, compiled)
+	}
+
+	if re.(*regexp.Regexp) == nil {
+		return name
+	}
+	return re.(*regexp.Regexp).ReplaceAllString(name, t.Replacement)
+}
+
+// setDisplayName sets the display name of the file if it differs from its
+// name.
+func (i *FileInfo) setDisplayName(t NameTransform) {
+	if display := t.Apply(i.Name); display != i.Name && display != "" {
+		i.DisplayName = display
+	}
+}
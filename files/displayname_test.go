@@ -0,0 +1,49 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestNameTransform(t *testing.T) {
+	testCases := map[string]struct {
+		transform NameTransform
+		name      string
+		want      string
+	}{
+		"no pattern":      {transform: NameTransform{}, name: "a.txt", want: "a.txt"},
+		"strip prefix":    {transform: NameTransform{Pattern: `^export-\d+-`}, name: "export-1612-report.csv", want: "report.csv"},
+		"groups":          {transform: NameTransform{Pattern: `^(\d{4})(\d{2})(\d{2})`, Replacement: "$1-$2-$3"}, name: "20210203.log", want: "2021-02-03.log"},
+		"invalid pattern": {transform: NameTransform{Pattern: `(`}, name: "a.txt", want: "a.txt"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.transform.Apply(tc.name))
+		})
+	}
+}
+
+func TestDisplayName(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/export-1-report.csv": "",
+		"/notes.txt":           "",
+	})
+
+	file, err := NewFileInfo(FileOptions{
+		Fs: fs, Path: "/", Expand: true, Checker: testutil.AllowAll{},
+		DisplayName: NameTransform{Pattern: `^export-\d+-`},
+	})
+	require.NoError(t, err)
+
+	names := map[string]string{}
+	for _, item := range file.Items {
+		names[item.Path] = item.DisplayName
+	}
+	// The paths are the real ones, only the display names change.
+	assert.Equal(t, map[string]string{"/export-1-report.csv": "report.csv", "/notes.txt": ""}, names)
+}
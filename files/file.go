@@ -6,14 +6,15 @@ import (
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/hex"
+	stderrors "errors"
 	"hash"
 	"io"
 	"log"
 	"mime"
-	"net/http"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -38,6 +39,69 @@ type FileInfo struct {
 	Subtitles []string          `json:"subtitles,omitempty"`
 	Content   string            `json:"content,omitempty"`
 	Checksums map[string]string `json:"checksums,omitempty"`
+	Xattrs    map[string]string `json:"xattrs,omitempty"`
+	Owner     string            `json:"owner,omitempty"`
+	// IsSpecial is set for devices, named pipes and sockets, which are
+	// never read.
+	IsSpecial bool `json:"isSpecial,omitempty"`
+	// Decompressed is set when Content is the decompressed content
+	// of a compressed text file.
+	Decompressed bool `json:"decompressed,omitempty"`
+	// Icon is the icon class of the file, see IconClass. It is only set
+	// on the items of listings shown with the icon column.
+	Icon string `json:"icon,omitempty"`
+	// Hex is the hex dump of the file, when asked for.
+	Hex []HexRow `json:"hex,omitempty"`
+	// LargeFile is set on files larger than FileOptions.LargeFileThreshold.
+	LargeFile bool `json:"largeFile,omitempty"`
+	// Error is set on the items of a listing which couldn't be read.
+	Error string `json:"error,omitempty"`
+	// Line is the line the file is opened at, see FileOptions.Line, and
+	// FirstLine the number of the first line of Content then.
+	Line      int `json:"line,omitempty"`
+	FirstLine int `json:"firstLine,omitempty"`
+	// Width and Height are the dimensions of images, when asked for.
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+	// ChildCount is the number of entries of the subdirectories of a
+	// listing, when asked for and they could be read.
+	ChildCount *int `json:"childCount,omitempty"`
+	// DisplayName is the name the file is shown with, when it differs
+	// from Name, see NameTransform.
+	DisplayName string `json:"displayName,omitempty"`
+	// PreviewLimit is the maximum size of Content, see PreviewLimits,
+	// and Truncated is set when the file is larger.
+	PreviewLimit int64 `json:"previewLimit,omitempty"`
+	Truncated    bool  `json:"truncated,omitempty"`
+	// Symlink describes where the file leads if it is a symbolic link,
+	// when asked for.
+	Symlink *SymlinkInfo `json:"symlink,omitempty"`
+	// Offset is the offset of the content when the file is opened at a
+	// byte window, and PastEnd is set when it's beyond the end of the file.
+	Offset  *int64 `json:"offset,omitempty"`
+	PastEnd bool   `json:"pastEnd,omitempty"`
+	// BinaryDetected is set when the content of a text file looks
+	// binary, so it isn't read.
+	BinaryDetected bool `json:"binaryDetected,omitempty"`
+	// PreviewFailed is set when the content couldn't be read, with
+	// FileOptions.GracefulPreviewErrors.
+	PreviewFailed bool `json:"previewFailed,omitempty"`
+	// RenderedHTML is the content rendered as HTML, for the Jupyter
+	// notebooks.
+	RenderedHTML string `json:"renderedHTML,omitempty"`
+	// ModTimeNano is ModTime in nanoseconds since the epoch, and StatHash
+	// a hash telling the changes apart within its resolution, see
+	// FileOptions.HashOnStat.
+	ModTimeNano int64  `json:"modifiedNs,omitempty"`
+	StatHash    string `json:"statHash,omitempty"`
+
+	header          []byte
+	sniffed         string
+	previewLimits   PreviewLimits
+	checksumCache   ChecksumCache
+	textExtensions  []string
+	byteWindow      *ByteWindow
+	binaryThreshold float64
 }
 
 // FileOptions are the options when getting a file info.
@@ -48,16 +112,86 @@ type FileOptions struct {
 	Expand     bool
 	ReadHeader bool
 	Checker    rules.Checker
+	// FollowSymlinks allows symbolic links pointing outside of the
+	// root of the filesystem to be followed.
+	FollowSymlinks bool
+	// Columns are the listing columns shown, see ListingColumns. The
+	// owner and checksum of the items are only computed when their
+	// column is.
+	Columns []string
+	// LargeFileThreshold is the size above which files are flagged as
+	// large. Zero disables it.
+	LargeFileThreshold int64
+	// DirMTimeFromContents makes the modification time of the listed
+	// directories the one of their most recently modified child.
+	DirMTimeFromContents bool
+	// Line opens text files at a line: only the lines around it are read
+	// into the content.
+	Line int
+	// ByteWindow opens text files at a window of bytes: only its bytes
+	// are read into the content.
+	ByteWindow *ByteWindow
+	// BinaryThreshold is the ratio of non-printable characters in the
+	// first bytes of text files above which their content looks binary
+	// and isn't read, see FileInfo.BinaryDetected. Zero disables it.
+	BinaryThreshold float64
+	// GracefulPreviewErrors makes the failures to read the content of
+	// files flag them as PreviewFailed instead of failing, so their
+	// metadata is still returned.
+	GracefulPreviewErrors bool
+	// Flatten lists the files of the subdirectories too, see Flatten.
+	Flatten *Flatten
+	// ImageDimensions reads the dimensions of the images from their
+	// header.
+	ImageDimensions bool
+	// ShowChildCounts counts the entries of the subdirectories of the
+	// listings.
+	ShowChildCounts bool
+	// DisplayName transforms the names the files are shown with.
+	DisplayName NameTransform
+	// PreviewLimits are the maximum sizes of the content of text files.
+	PreviewLimits PreviewLimits
+	// SymlinkDepth is the maximum number of links followed to describe
+	// the symbolic links, see ResolveSymlink. Zero disables it.
+	SymlinkDepth int
+	// Locale collates the names of the listings, see Listing.Locale.
+	Locale string
+	// Checksums caches the checksums of the files. The cached ones are
+	// set on the file, but not on the items of its listing.
+	Checksums ChecksumCache
+	// TextExtensions are the extensions of the files previewed as text
+	// whatever their MIME type, such as ".yaml" or ".env".
+	TextExtensions []string
+	// CaseInsensitive looks for a missing file in its directory under a
+	// name only differing by case, whose path is then the one of the file
+	// info.
+	CaseInsensitive bool
+	// HashOnStat sets ModTimeNano and StatHash on the file and the items
+	// of its listing, for the sync clients to tell apart the changes the
+	// resolution of the modification times misses.
+	HashOnStat bool
 }
 
 // NewFileInfo creates a File object from a path and a given user. This File
 // object will be automatically filled depending on if it is a directory
 // or a file. If it's a video file, it will also detect any subtitles.
 func NewFileInfo(opts FileOptions) (*FileInfo, error) {
+	if opts.CaseInsensitive {
+		if resolved, ok := resolveCase(opts.Fs, opts.Path); ok {
+			opts.Path = resolved
+		}
+	}
+
 	if !opts.Checker.Check(opts.Path) {
 		return nil, os.ErrPermission
 	}
 
+	if !opts.FollowSymlinks {
+		if err := CheckSymlinks(opts.Fs, opts.Path); err != nil {
+			return nil, err
+		}
+	}
+
 	info, err := opts.Fs.Stat(opts.Path)
 	if err != nil {
 		return nil, err
@@ -72,36 +206,86 @@ func NewFileInfo(opts FileOptions) (*FileInfo, error) {
 		IsDir:     info.IsDir(),
 		Size:      info.Size(),
 		Extension: filepath.Ext(info.Name()),
+		IsSpecial: IsSpecial(info.Mode()),
+
+		textExtensions: opts.TextExtensions,
+	}
+	file.LargeFile = isLargeFile(file, opts.LargeFileThreshold)
+	file.setDisplayName(opts.DisplayName)
+	if opts.HashOnStat {
+		file.readStatHash()
+	}
+	if opts.Checksums != nil {
+		file.checksumCache = opts.Checksums
+		if !file.IsDir {
+			file.Checksums = opts.Checksums.Load(file.Path, file.ModTime)
+		}
+	}
+	if opts.SymlinkDepth > 0 {
+		file.Symlink = ResolveSymlink(opts.Fs, opts.Path, opts.SymlinkDepth)
 	}
 
 	if opts.Expand {
 		if file.IsDir {
-			if err := file.readListing(opts.Checker, opts.ReadHeader); err != nil { //nolint:shadow
+			if err := file.readListing(opts); err != nil { //nolint:shadow
 				return nil, err
 			}
 			return file, nil
 		}
 
+		file.Line = opts.Line
+		file.byteWindow = opts.ByteWindow
+		file.binaryThreshold = opts.BinaryThreshold
+		file.previewLimits = opts.PreviewLimits
 		err = file.detectType(opts.Modify, true, true)
+		if err != nil && opts.GracefulPreviewErrors && !stderrors.Is(err, errors.ErrInvalidRequestParams) {
+			log.Printf("couldn't preview %s: %v", opts.Path, err)
+			file.Type = "blob"
+			file.Content = ""
+			file.PreviewFailed = true
+			err = nil
+		}
 		if err != nil {
 			return nil, err
 		}
+		if file.isNotebook() && file.Line == 0 && file.byteWindow == nil {
+			file.renderNotebook()
+		}
+		if file.FirstLine == 0 {
+			// Only text files are opened at a line.
+			file.Line = 0
+		}
+		if opts.ImageDimensions {
+			file.readImageDimensions()
+		}
 	}
 
 	return file, err
 }
 
 // Checksum checksums a given File for a given User, using a specific
-// algorithm. The checksums data is saved on File object.
+// algorithm. The checksums data is saved on File object. Checksums are
+// reused from the cache of the file, if any, unless it was modified.
 func (i *FileInfo) Checksum(algo string) error {
 	if i.IsDir {
 		return errors.ErrIsDirectory
 	}
 
+	if i.IsSpecial {
+		return errors.ErrSpecialFile
+	}
+
 	if i.Checksums == nil {
 		i.Checksums = map[string]string{}
 	}
 
+	if i.checksumCache != nil {
+		if checksum, ok := i.checksumCache.Load(i.Path, i.ModTime)[algo]; ok {
+			i.Checksums[algo] = checksum
+			return nil
+		}
+	}
+
 	reader, err := i.Fs.Open(i.Path)
 	if err != nil {
 		return err
@@ -130,14 +314,17 @@ func (i *FileInfo) Checksum(algo string) error {
 	}
 
 	i.Checksums[algo] = hex.EncodeToString(h.Sum(nil))
+	if i.checksumCache != nil {
+		i.checksumCache.Store(i.Path, algo, i.Checksums[algo], i.ModTime)
+	}
 	return nil
 }
 
 //nolint:goconst
 //TODO: use constants
 func (i *FileInfo) detectType(modify, saveContent, readHeader bool) error {
-	if IsNamedPipe(i.Mode) {
-		i.Type = "blob"
+	if IsSpecial(i.Mode) {
+		i.Type = "special"
 		return nil
 	}
 	// failing to detect the type should not return error.
@@ -145,12 +332,19 @@ func (i *FileInfo) detectType(modify, saveContent, readHeader bool) error {
 	// of files couldn't be opened: we'd have immediately
 	// a 500 even though it doesn't matter. So we just log it.
 
+	if i.detectCompressedText(saveContent, readHeader) {
+		return nil
+	}
+
 	var buffer []byte
 
 	mimetype := mime.TypeByExtension(i.Extension)
+	if IsTextExtension(i.textExtensions, i.Extension) || i.isNotebook() {
+		mimetype = "text/plain"
+	}
 	if mimetype == "" && readHeader {
-		buffer = i.readFirstBytes()
-		mimetype = http.DetectContentType(buffer)
+		buffer = i.firstBytes()
+		mimetype = i.sniffType()
 	}
 
 	switch {
@@ -164,21 +358,51 @@ func (i *FileInfo) detectType(modify, saveContent, readHeader bool) error {
 	case strings.HasPrefix(mimetype, "image"):
 		i.Type = "image"
 		return nil
-	case (strings.HasPrefix(mimetype, "text") || (len(buffer) > 0 && !isBinary(buffer))) && i.Size <= 10*1024*1024: // 10 MB
+	case isTextMimeType(mimetype) || (len(buffer) > 0 && !isBinary(buffer)):
 		i.Type = "text"
 
 		if !modify {
 			i.Type = "textImmutable"
 		}
 
+		// Byte windows are for inspecting binary-ish files.
+		if saveContent && i.byteWindow == nil && i.binaryThreshold > 0 && looksBinary(i.firstBytes(), i.binaryThreshold) {
+			i.Type = "blob"
+			i.BinaryDetected = true
+			return nil
+		}
+
+		if saveContent && i.byteWindow != nil {
+			reader, err := i.Fs.Open(i.Path)
+			if err != nil {
+				return err
+			}
+			defer reader.Close()
+
+			return i.readByteWindow(reader)
+		}
+
+		if saveContent && i.Line > 0 {
+			reader, err := i.Fs.Open(i.Path)
+			if err != nil {
+				return err
+			}
+			defer reader.Close()
+
+			return i.readLineWindow(reader)
+		}
+
 		if saveContent {
-			afs := &afero.Afero{Fs: i.Fs}
-			content, err := afs.ReadFile(i.Path)
+			reader, err := i.Fs.Open(i.Path)
 			if err != nil {
 				return err
 			}
+			defer reader.Close()
 
-			i.Content = string(content)
+			i.Content, err = i.readPreview(reader, i.Extension)
+			if err != nil {
+				return err
+			}
 		}
 		return nil
 	default:
@@ -188,6 +412,39 @@ func (i *FileInfo) detectType(modify, saveContent, readHeader bool) error {
 	return nil
 }
 
+// isTextMimeType checks if the files of the MIME type are text ones, which
+// includes JSON.
+func isTextMimeType(mimetype string) bool {
+	return strings.HasPrefix(mimetype, "text") || strings.HasPrefix(mimetype, "application/json")
+}
+
+// IsTextExtension checks if the extension is one of the given ones, which
+// are lower case and start with a dot.
+func IsTextExtension(exts []string, ext string) bool {
+	ext = strings.ToLower(ext)
+	for _, e := range exts {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func isLargeFile(file *FileInfo, threshold int64) bool {
+	return threshold > 0 && !file.IsDir && file.Size > threshold
+}
+
+// firstBytes returns the first bytes of the file, which are only read once.
+func (i *FileInfo) firstBytes() []byte {
+	if i.header == nil {
+		i.header = i.readFirstBytes()
+		if i.header == nil {
+			i.header = []byte{}
+		}
+	}
+	return i.header
+}
+
 func (i *FileInfo) readFirstBytes() []byte {
 	reader, err := i.Fs.Open(i.Path)
 	if err != nil {
@@ -224,29 +481,45 @@ func (i *FileInfo) detectSubtitles() {
 	}
 }
 
-func (i *FileInfo) readListing(checker rules.Checker, readHeader bool) error {
-	afs := &afero.Afero{Fs: i.Fs}
-	dir, err := afs.ReadDir(i.Path)
+func (i *FileInfo) readListing(opts FileOptions) error {
+	if opts.Flatten != nil {
+		return i.readFlatListing(opts)
+	}
+
+	dir, broken, err := readDir(i.Fs, i.Path)
 	if err != nil {
 		return err
 	}
 
 	listing := &Listing{
-		Items:    []*FileInfo{},
-		NumDirs:  0,
-		NumFiles: 0,
+		Items:      []*FileInfo{},
+		NumDirs:    0,
+		NumFiles:   0,
+		Categories: map[string]int{},
+		Locale:     opts.Locale,
 	}
 
+	showOwner := hasColumn(opts.Columns, "owner")
+	showChecksum := hasColumn(opts.Columns, "checksum")
+	showIcon := hasColumn(opts.Columns, "icon")
+	owners := map[uint32]string{}
+
 	for _, f := range dir {
 		name := f.Name()
 		fPath := path.Join(i.Path, name)
 
-		if !checker.Check(fPath) {
+		if isHiddenEntry(name) || !opts.Checker.Check(fPath) {
 			continue
 		}
 
-		if IsSymlink(f.Mode()) {
+		var symlink *SymlinkInfo
+		if opts.SymlinkDepth > 0 && IsSymlink(f.Mode()) {
+			symlink = ResolveSymlink(i.Fs, fPath, opts.SymlinkDepth)
+		}
+
+		if IsSymlink(f.Mode()) && (opts.FollowSymlinks || CheckSymlinks(i.Fs, fPath) == nil) {
 			// It's a symbolic link. We try to follow it. If it doesn't work,
+			// or it points outside of the root and links can't be followed,
 			// we stay with the link information instead of the target's.
 			info, err := i.Fs.Stat(fPath)
 			if err == nil {
@@ -263,22 +536,182 @@ func (i *FileInfo) readListing(checker rules.Checker, readHeader bool) error {
 			IsDir:     f.IsDir(),
 			Extension: filepath.Ext(name),
 			Path:      fPath,
+			Symlink:   symlink,
+			IsSpecial: IsSpecial(f.Mode()),
+
+			checksumCache:  opts.Checksums,
+			textExtensions: opts.TextExtensions,
+		}
+
+		file.setDisplayName(opts.DisplayName)
+
+		if showOwner {
+			file.Owner = fileOwner(f, owners)
+		}
+
+		if showIcon {
+			// The sniffed type is kept, so detecting the type from the
+			// header afterwards doesn't read the file again.
+			file.Icon = file.IconClass(true)
 		}
 
 		if file.IsDir {
 			listing.NumDirs++
+
+			if opts.ShowChildCounts {
+				if count, ok := countChildren(i.Fs, fPath, opts.Checker, childCountTimeout); ok {
+					file.ChildCount = &count
+				}
+			}
 		} else {
 			listing.NumFiles++
 
-			err := file.detectType(true, false, readHeader)
+			err := file.detectType(true, false, opts.ReadHeader)
 			if err != nil {
 				return err
 			}
+
+			if showChecksum {
+				if err := file.Checksum("sha256"); err != nil { //nolint:shadow
+					log.Printf("couldn't checksum %s: %v", fPath, err)
+				}
+			}
+
+			listing.addStats(file)
+			file.LargeFile = isLargeFile(file, opts.LargeFileThreshold)
+
+			if opts.ImageDimensions {
+				file.readImageDimensions()
+			}
 		}
 
 		listing.Items = append(listing.Items, file)
 	}
 
+	for _, entry := range broken {
+		fPath := path.Join(i.Path, entry.name)
+		if !opts.Checker.Check(fPath) {
+			continue
+		}
+
+		listing.NumFiles++
+		listing.Items = append(listing.Items, &FileInfo{
+			Fs:        i.Fs,
+			Name:      entry.name,
+			Path:      fPath,
+			Extension: filepath.Ext(entry.name),
+			Type:      "blob",
+			Error:     entry.err.Error(),
+		})
+	}
+
+	if opts.DirMTimeFromContents {
+		// The listed directory gets its time from the listing itself,
+		// so it is taken before the one of the subdirectories changes.
+		if latest, ok := latestModTime(listing.Items); ok {
+			i.ModTime = latest
+		}
+
+		for _, item := range listing.Items {
+			if item.IsDir {
+				item.ModTime = childrenModTime(i.Fs, item, opts.Checker)
+			}
+		}
+	}
+
+	if opts.HashOnStat {
+		// After the times of the directories are taken from their contents.
+		i.readStatHash()
+		for _, item := range listing.Items {
+			if item.Error == "" {
+				item.readStatHash()
+			}
+		}
+	}
+
 	i.Listing = listing
 	return nil
 }
+
+// brokenEntry is an entry of a directory which couldn't be stat'd.
+type brokenEntry struct {
+	name string
+	err  error
+}
+
+// readDir reads the entries of the directory sorted by name. If reading
+// them all at once fails, e.g. because one of them is a dangling mount,
+// their names are read and each is stat'd on its own. Those which can't
+// be are returned apart, so one bad entry doesn't break the listing.
+func readDir(fs afero.Fs, dir string) ([]os.FileInfo, []brokenEntry, error) {
+	infos, err := afero.ReadDir(fs, dir)
+	if err == nil {
+		return infos, nil, nil
+	}
+
+	file, openErr := fs.Open(dir)
+	if openErr != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	names, namesErr := file.Readdirnames(-1)
+	if namesErr != nil {
+		return nil, nil, err
+	}
+	sort.Strings(names)
+
+	infos = make([]os.FileInfo, 0, len(names))
+	var broken []brokenEntry
+	for _, name := range names {
+		info, err := lstatIfPossible(fs, path.Join(dir, name))
+		if err != nil {
+			log.Printf("couldn't stat %s: %v", path.Join(dir, name), err)
+			broken = append(broken, brokenEntry{name: name, err: err})
+			continue
+		}
+		infos = append(infos, info)
+	}
+
+	return infos, broken, nil
+}
+
+func lstatIfPossible(fs afero.Fs, name string) (os.FileInfo, error) {
+	if lstater, ok := fs.(afero.Lstater); ok {
+		info, _, err := lstater.LstatIfPossible(name)
+		return info, err
+	}
+	return fs.Stat(name)
+}
+
+func latestModTime(items []*FileInfo) (time.Time, bool) {
+	var latest time.Time
+	for _, item := range items {
+		if item.ModTime.After(latest) {
+			latest = item.ModTime
+		}
+	}
+	return latest, !latest.IsZero()
+}
+
+// childrenModTime returns the modification time of the most recently
+// modified child of dir the checker allows, or the one of dir if there is
+// none.
+func childrenModTime(fs afero.Fs, dir *FileInfo, checker rules.Checker) time.Time {
+	children, err := afero.ReadDir(fs, dir.Path)
+	if err != nil {
+		return dir.ModTime
+	}
+
+	var latest time.Time
+	for _, child := range children {
+		if checker.Check(path.Join(dir.Path, child.Name())) && child.ModTime().After(latest) {
+			latest = child.ModTime()
+		}
+	}
+
+	if latest.IsZero() {
+		return dir.ModTime
+	}
+	return latest
+}
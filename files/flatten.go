@@ -0,0 +1,96 @@
+package files
+
+import "strings"
+
+// Flatten lists the files of the subdirectories of a directory along with
+// its own, named after their path relative to it.
+type Flatten struct {
+	// Depth is the number of levels of directories listed, 1 being the
+	// directory itself. Zero is unlimited.
+	Depth int
+	// MaxFiles is the number of files after which the listing stops and
+	// is flagged as truncated. Zero is unlimited.
+	MaxFiles int
+}
+
+// readFlatListing lists the files of the directory and its subdirectories
+// breadth-first, so the files closest to the directory are kept once the
+// listing is truncated. The directories themselves aren't listed.
+func (i *FileInfo) readFlatListing(opts FileOptions) error {
+	flatten := opts.Flatten
+	opts.Flatten = nil
+	opts.ShowChildCounts = false
+	opts.DirMTimeFromContents = false
+
+	listing := &Listing{
+		Items:      []*FileInfo{},
+		Categories: map[string]int{},
+		Locale:     opts.Locale,
+	}
+
+	type dir struct {
+		path  string
+		depth int
+	}
+	queue := []dir{{path: i.Path, depth: 1}}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		sub := &FileInfo{Fs: i.Fs, Path: current.path, textExtensions: i.textExtensions}
+		if err := sub.readListing(opts); err != nil {
+			if current.path == i.Path {
+				return err
+			}
+			// An unreadable subdirectory doesn't fail the listing.
+			continue
+		}
+
+		descend := flatten.Depth == 0 || current.depth < flatten.Depth
+		items := sub.Listing.Items
+		for n, item := range items {
+			if item.IsDir {
+				if descend {
+					queue = append(queue, dir{path: item.Path, depth: current.depth + 1})
+				}
+				continue
+			}
+
+			if flatten.MaxFiles > 0 && listing.NumFiles >= flatten.MaxFiles {
+				listing.Truncated = true
+				listing.Omitted = omittedFiles(items[n:], len(queue) > 0, descend)
+				i.Listing = listing
+				return nil
+			}
+
+			item.Name = strings.TrimPrefix(strings.TrimPrefix(item.Path, i.Path), "/")
+			listing.NumFiles++
+			listing.addStats(item)
+			listing.Items = append(listing.Items, item)
+		}
+	}
+
+	i.Listing = listing
+	return nil
+}
+
+// omittedFiles counts the files left out of a truncated listing, which
+// are only all known if no directory is left to list.
+func omittedFiles(rest []*FileInfo, queued, descend bool) *int {
+	if queued {
+		return nil
+	}
+
+	omitted := 0
+	for _, item := range rest {
+		if item.IsDir {
+			if descend {
+				return nil
+			}
+			continue
+		}
+		omitted++
+	}
+	return &omitted
+}
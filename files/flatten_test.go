@@ -0,0 +1,107 @@
+package files
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestFlatten(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/root/a.txt":         "a",
+		"/root/b/c.txt":       "c",
+		"/root/b/d/e.txt":     "e",
+		"/root/b/d/f/g.txt":   "g",
+		"/root/h/i.jpg":       "i",
+		"/root/h/.hidden.txt": "hidden",
+	})
+
+	list := func(flatten Flatten) *Listing {
+		file, err := NewFileInfo(FileOptions{
+			Fs: fs, Path: "/root", Expand: true, Checker: testutil.AllowAll{}, Flatten: &flatten,
+		})
+		require.NoError(t, err)
+		return file.Listing
+	}
+
+	names := func(listing *Listing) []string {
+		names := []string{}
+		for _, item := range listing.Items {
+			names = append(names, item.Name)
+		}
+		return names
+	}
+
+	intPtr := func(n int) *int { return &n }
+
+	testCases := map[string]struct {
+		flatten   Flatten
+		names     []string
+		truncated bool
+		omitted   *int
+	}{
+		"all": {
+			names: []string{"a.txt", "b/c.txt", "h/.hidden.txt", "h/i.jpg", "b/d/e.txt", "b/d/f/g.txt"},
+		},
+		"depth": {
+			flatten: Flatten{Depth: 2},
+			names:   []string{"a.txt", "b/c.txt", "h/.hidden.txt", "h/i.jpg"},
+		},
+		"closest first": {
+			flatten:   Flatten{MaxFiles: 3},
+			names:     []string{"a.txt", "b/c.txt", "h/.hidden.txt"},
+			truncated: true,
+		},
+		"omitted count": {
+			flatten:   Flatten{Depth: 2, MaxFiles: 3},
+			names:     []string{"a.txt", "b/c.txt", "h/.hidden.txt"},
+			truncated: true,
+			omitted:   intPtr(1),
+		},
+		"exactly the maximum": {
+			flatten: Flatten{Depth: 2, MaxFiles: 4},
+			names:   []string{"a.txt", "b/c.txt", "h/.hidden.txt", "h/i.jpg"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			listing := list(tc.flatten)
+			assert.Equal(t, tc.names, names(listing))
+			assert.Equal(t, len(tc.names), listing.NumFiles)
+			assert.Zero(t, listing.NumDirs)
+			assert.Equal(t, tc.truncated, listing.Truncated)
+			assert.Equal(t, tc.omitted, listing.Omitted)
+		})
+	}
+
+	listing := list(Flatten{})
+	assert.Equal(t, "/root/b/d/f/g.txt", listing.Items[5].Path)
+	assert.Equal(t, 1, listing.Categories["image"])
+}
+
+func TestFlattenJSON(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/root/a.txt":   "a",
+		"/root/b/c.txt": "c",
+		"/root/b/d.txt": "d",
+	})
+
+	file, err := NewFileInfo(FileOptions{
+		Fs: fs, Path: "/root", Expand: true, Checker: testutil.AllowAll{}, Flatten: &Flatten{Depth: 2, MaxFiles: 2},
+	})
+	require.NoError(t, err)
+
+	content, err := json.Marshal(file)
+	require.NoError(t, err)
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(content, &got))
+	assert.Equal(t, true, got["listingTruncated"])
+	assert.EqualValues(t, 1, got["omitted"])
+	assert.NotContains(t, got, "truncated")
+}
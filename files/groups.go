@@ -0,0 +1,48 @@
+package files
+
+import "time"
+
+// DateGroup is a section of a listing holding the files modified on the
+// same day.
+type DateGroup struct {
+	// Date is the day, as 2006-01-02.
+	Date string `json:"date"`
+	// Label is "Today", "Yesterday" or the date.
+	Label string `json:"label"`
+	// Items are the indexes of the files in the items of the listing.
+	Items []int `json:"items"`
+}
+
+// GroupByDate groups the items by the day they were modified on in the
+// given location, relative to now. Groups are in the order of their first
+// item and keep the order of the items, so it's meant to be called once
+// sorted.
+func (l *Listing) GroupByDate(now time.Time, loc *time.Location) []DateGroup {
+	today := now.In(loc).Format("2006-01-02")
+	yesterday := now.In(loc).AddDate(0, 0, -1).Format("2006-01-02")
+
+	groups := []DateGroup{}
+	indexes := map[string]int{}
+	for i, item := range l.Items {
+		date := item.ModTime.In(loc).Format("2006-01-02")
+
+		g, ok := indexes[date]
+		if !ok {
+			label := date
+			switch date {
+			case today:
+				label = "Today"
+			case yesterday:
+				label = "Yesterday"
+			}
+
+			g = len(groups)
+			indexes[date] = g
+			groups = append(groups, DateGroup{Date: date, Label: label})
+		}
+
+		groups[g].Items = append(groups[g].Items, i)
+	}
+
+	return groups
+}
@@ -0,0 +1,37 @@
+package files
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupByDate(t *testing.T) {
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	now := time.Date(2021, 3, 10, 12, 0, 0, 0, paris)
+	listing := Listing{Items: []*FileInfo{
+		{Name: "a.jpg", ModTime: now.Add(-time.Hour)},
+		// Yesterday in UTC, but today in Paris.
+		{Name: "b.jpg", ModTime: time.Date(2021, 3, 9, 23, 30, 0, 0, time.UTC)},
+		{Name: "c.jpg", ModTime: now.AddDate(0, 0, -1)},
+		{Name: "d.jpg", ModTime: now.AddDate(0, 0, -5)},
+		{Name: "e.jpg", ModTime: now.AddDate(0, 0, -1).Add(-time.Hour)},
+	}}
+
+	require.Equal(t, []DateGroup{
+		{Date: "2021-03-10", Label: "Today", Items: []int{0, 1}},
+		{Date: "2021-03-09", Label: "Yesterday", Items: []int{2, 4}},
+		{Date: "2021-03-05", Label: "2021-03-05", Items: []int{3}},
+	}, listing.GroupByDate(now, paris))
+
+	require.Equal(t, []DateGroup{
+		{Date: "2021-03-10", Label: "Today", Items: []int{0}},
+		{Date: "2021-03-09", Label: "Yesterday", Items: []int{1, 2, 4}},
+		{Date: "2021-03-05", Label: "2021-03-05", Items: []int{3}},
+	}, listing.GroupByDate(now, time.UTC))
+
+	require.Empty(t, (&Listing{}).GroupByDate(now, paris))
+}
@@ -0,0 +1,83 @@
+package files
+
+import (
+	"encoding/hex"
+	"io"
+	"strings"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+)
+
+// hexRowSize is the number of bytes of each row of a hex dump.
+const hexRowSize = 16
+
+// HexRow is a row of a hex dump, like the ones of xxd.
+type HexRow struct {
+	Offset int64  `json:"offset"`
+	Hex    string `json:"hex"`
+	ASCII  string `json:"ascii"`
+}
+
+// HexDump returns the hex dump of the file, whatever its type. The bytes
+// are grouped by two in the hex column and the non printable ones are
+// shown as dots in the ASCII one. It returns errors.ErrFileTooLarge if
+// the file is larger than limit bytes.
+func (i *FileInfo) HexDump(limit int64) ([]HexRow, error) {
+	if i.IsDir {
+		return nil, errors.ErrIsDirectory
+	}
+
+	if i.IsSpecial {
+		return nil, errors.ErrSpecialFile
+	}
+
+	if i.Size > limit {
+		return nil, errors.ErrFileTooLarge
+	}
+
+	file, err := i.Fs.Open(i.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	rows := []HexRow{}
+	reader := io.LimitReader(file, limit)
+	buffer := make([]byte, hexRowSize)
+
+	for offset := int64(0); ; offset += hexRowSize {
+		n, err := io.ReadFull(reader, buffer)
+		if n > 0 {
+			rows = append(rows, hexRow(offset, buffer[:n]))
+		}
+
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func hexRow(offset int64, data []byte) HexRow {
+	var groups []string
+	for start := 0; start < len(data); start += 2 {
+		end := start + 2
+		if end > len(data) {
+			end = len(data)
+		}
+		groups = append(groups, hex.EncodeToString(data[start:end]))
+	}
+
+	ascii := make([]byte, len(data))
+	for j, b := range data {
+		if b >= 0x20 && b < 0x7f {
+			ascii[j] = b
+		} else {
+			ascii[j] = '.'
+		}
+	}
+
+	return HexRow{Offset: offset, Hex: strings.Join(groups, " "), ASCII: string(ascii)}
+}
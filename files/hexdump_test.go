@@ -0,0 +1,30 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestHexDump(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/header.bin": "\x7fELF\x02\x01\x01\x00hello, world!\n\x00",
+	})
+
+	file, err := NewFileInfo(FileOptions{Fs: fs, Path: "/header.bin", Checker: testutil.AllowAll{}})
+	require.NoError(t, err)
+
+	rows, err := file.HexDump(1024)
+	require.NoError(t, err)
+	assert.Equal(t, []HexRow{
+		{Offset: 0, Hex: "7f45 4c46 0201 0100 6865 6c6c 6f2c 2077", ASCII: ".ELF....hello, w"},
+		{Offset: 16, Hex: "6f72 6c64 210a 00", ASCII: "orld!.."},
+	}, rows)
+
+	_, err = file.HexDump(8)
+	assert.Equal(t, errors.ErrFileTooLarge, err)
+}
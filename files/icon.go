@@ -0,0 +1,84 @@
+package files
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// iconExtensions maps the extensions whose mime type doesn't tell their
+// icon class, such as source code, to it.
+var iconExtensions = map[string]string{
+	".c": "code", ".cpp": "code", ".cs": "code", ".css": "code", ".go": "code",
+	".h": "code", ".html": "code", ".java": "code", ".js": "code", ".json": "code",
+	".php": "code", ".pl": "code", ".py": "code", ".rb": "code", ".rs": "code",
+	".sh": "code", ".ts": "code", ".vue": "code", ".xml": "code", ".yaml": "code",
+	".yml": "code",
+	".7z":  "archive", ".bz2": "archive", ".gz": "archive", ".rar": "archive",
+	".tar": "archive", ".tgz": "archive", ".xz": "archive", ".zip": "archive",
+	".pdf": "pdf",
+}
+
+// IconClass returns the class of the icon of the file: folder, image,
+// audio, video, code, text, archive, pdf or file. It is guessed from the
+// extension and, if that is inconclusive and sniff is set, from the first
+// bytes of the file, so e.g. an extensionless shell script is code.
+func (i *FileInfo) IconClass(sniff bool) string {
+	if i.IsDir {
+		return "folder"
+	}
+
+	ext := strings.ToLower(i.Extension)
+	if class, ok := iconExtensions[ext]; ok {
+		return class
+	}
+
+	if class := mimeIconClass(mime.TypeByExtension(ext)); class != "" {
+		return class
+	}
+
+	if !sniff || IsSpecial(i.Mode) {
+		return "file"
+	}
+
+	if bytes.HasPrefix(i.firstBytes(), []byte("#!")) {
+		return "code"
+	}
+
+	if class := mimeIconClass(i.sniffType()); class != "" {
+		return class
+	}
+
+	return "file"
+}
+
+func mimeIconClass(mimetype string) string {
+	switch {
+	case mimetype == "":
+		return ""
+	case strings.HasPrefix(mimetype, "image"):
+		return "image"
+	case strings.HasPrefix(mimetype, "audio"):
+		return "audio"
+	case strings.HasPrefix(mimetype, "video"):
+		return "video"
+	case strings.HasPrefix(mimetype, "text"):
+		return "text"
+	case mimetype == "application/pdf":
+		return "pdf"
+	case mimetype == "application/zip", mimetype == "application/x-gzip":
+		return "archive"
+	default:
+		return ""
+	}
+}
+
+// sniffType returns the content type of the file sniffed from its first
+// bytes. The result is kept so the file is only read once.
+func (i *FileInfo) sniffType() string {
+	if i.sniffed == "" {
+		i.sniffed = http.DetectContentType(i.firstBytes())
+	}
+	return i.sniffed
+}
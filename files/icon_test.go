@@ -0,0 +1,46 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestIconClass(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/dir/":       "",
+		"/main.go":    "package main",
+		"/photo.png":  "",
+		"/deploy":     "#!/bin/sh\necho hi\n",
+		"/notes":      "just some notes",
+		"/data.bin":   "\x00\x01\x02",
+		"/archive.gz": "",
+	})
+
+	testCases := map[string]struct {
+		sniff bool
+		want  string
+	}{
+		"/dir":        {false, "folder"},
+		"/main.go":    {false, "code"},
+		"/photo.png":  {false, "image"},
+		"/archive.gz": {false, "archive"},
+		"/deploy":     {true, "code"},
+		"/notes":      {true, "text"},
+		"/data.bin":   {true, "file"},
+	}
+
+	for fPath, tc := range testCases {
+		t.Run(fPath, func(t *testing.T) {
+			file, err := NewFileInfo(FileOptions{Fs: fs, Path: fPath, Checker: testutil.AllowAll{}})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, file.IconClass(tc.sniff))
+		})
+	}
+
+	file, err := NewFileInfo(FileOptions{Fs: fs, Path: "/deploy", Checker: testutil.AllowAll{}})
+	assert.NoError(t, err)
+	assert.Equal(t, "file", file.IconClass(false), "extensionless files are only sniffed if asked")
+}
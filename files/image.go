@@ -0,0 +1,36 @@
+package files
+
+import (
+	"image"
+	_ "image/gif"  // register the gif decoder
+	_ "image/jpeg" // register the jpeg decoder
+	_ "image/png"  // register the png decoder
+	"log"
+
+	_ "golang.org/x/image/bmp"  // register the bmp decoder
+	_ "golang.org/x/image/webp" // register the webp decoder
+)
+
+// readImageDimensions sets the dimensions of the image from its header,
+// without decoding it. They are left to zero for the images whose format
+// isn't supported or which are corrupt.
+func (i *FileInfo) readImageDimensions() {
+	if i.Type != "image" {
+		return
+	}
+
+	file, err := i.Fs.Open(i.Path)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		return
+	}
+
+	i.Width = config.Width
+	i.Height = config.Height
+}
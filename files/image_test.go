@@ -0,0 +1,43 @@
+package files
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestImageDimensions(t *testing.T) {
+	var encoded bytes.Buffer
+	require.NoError(t, png.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 640, 480))))
+
+	fs := testutil.NewFs(t, map[string]string{
+		"/photo.png":   encoded.String(),
+		"/corrupt.png": "not a png",
+		"/notes.txt":   "640x480",
+	})
+
+	file, err := NewFileInfo(FileOptions{
+		Fs: fs, Path: "/", Expand: true, Checker: testutil.AllowAll{}, ImageDimensions: true,
+	})
+	require.NoError(t, err)
+
+	dimensions := map[string][2]int{}
+	for _, item := range file.Items {
+		dimensions[item.Name] = [2]int{item.Width, item.Height}
+	}
+	assert.Equal(t, map[string][2]int{
+		"photo.png":   {640, 480},
+		"corrupt.png": {0, 0},
+		"notes.txt":   {0, 0},
+	}, dimensions)
+
+	file, err = NewFileInfo(FileOptions{Fs: fs, Path: "/photo.png", Expand: true, Checker: testutil.AllowAll{}})
+	require.NoError(t, err)
+	assert.Zero(t, file.Width)
+}
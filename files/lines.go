@@ -0,0 +1,75 @@
+package files
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// lineWindowRadius is the number of lines read before and after the
+// target line of the files opened at a line.
+const lineWindowRadius = 500
+
+// readLineWindow sets the content to the lines around Line instead of the
+// whole file. Line is clamped to the lines of the file and FirstLine is
+// set to the number of the first line of the content. The content being
+// partial, it can't be saved.
+func (i *FileInfo) readLineWindow(reader io.Reader) error {
+	content, first, line, err := lineWindow(reader, i.Line, lineWindowRadius)
+	if err != nil {
+		return err
+	}
+
+	i.Content = content
+	i.FirstLine = first
+	i.Line = line
+	i.Type = "textImmutable"
+	return nil
+}
+
+// lineWindow reads the lines from line-radius to line+radius, numbered
+// from 1. The line is clamped to the lines of the reader, which are only
+// read until the end of the window.
+func lineWindow(reader io.Reader, line, radius int) (content string, first, target int, err error) {
+	if line < 1 {
+		line = 1
+	}
+
+	buffered := bufio.NewReader(reader)
+	window := []string{}
+	read := 0
+
+	for read < line+radius {
+		text, err := buffered.ReadString('\n')
+		if text != "" {
+			read++
+			window = append(window, text)
+			// Before reaching the line, its window is not known yet.
+			if len(window) > 2*radius+1 {
+				window = window[1:]
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", 0, 0, err
+		}
+	}
+
+	if line > read {
+		line = read
+		if line < 1 {
+			line = 1
+		}
+	}
+
+	first = read - len(window) + 1
+	if start := line - radius; start > first {
+		window = window[start-first:]
+		first = start
+	}
+
+	return strings.Join(window, ""), first, line, nil
+}
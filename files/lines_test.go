@@ -0,0 +1,69 @@
+package files
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestLineWindow(t *testing.T) {
+	content := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10"
+
+	testCases := map[string]struct {
+		line          int
+		content       string
+		first, target int
+	}{
+		"middle":        {line: 5, content: "3\n4\n5\n6\n7\n", first: 3, target: 5},
+		"start":         {line: 1, content: "1\n2\n3\n", first: 1, target: 1},
+		"end":           {line: 10, content: "8\n9\n10", first: 8, target: 10},
+		"before start":  {line: -3, content: "1\n2\n3\n", first: 1, target: 1},
+		"after the end": {line: 5000, content: "8\n9\n10", first: 8, target: 10},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			window, first, target, err := lineWindow(strings.NewReader(content), tc.line, 2)
+			require.NoError(t, err)
+			assert.Equal(t, tc.content, window)
+			assert.Equal(t, tc.first, first)
+			assert.Equal(t, tc.target, target)
+		})
+	}
+
+	window, first, target, err := lineWindow(strings.NewReader(""), 3, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "", window)
+	assert.Equal(t, 1, first)
+	assert.Equal(t, 1, target)
+}
+
+func TestOpenAtLine(t *testing.T) {
+	var content strings.Builder
+	for line := 1; line <= 10000; line++ {
+		fmt.Fprintf(&content, "line %d\n", line)
+	}
+
+	fs := testutil.NewFs(t, map[string]string{"/big.txt": content.String()})
+
+	file, err := NewFileInfo(FileOptions{
+		Fs: fs, Path: "/big.txt", Modify: true, Expand: true, Checker: testutil.AllowAll{}, Line: 5000,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 5000, file.Line)
+	assert.Equal(t, 5000-lineWindowRadius, file.FirstLine)
+	assert.True(t, strings.HasPrefix(file.Content, fmt.Sprintf("line %d\n", file.FirstLine)))
+	assert.Equal(t, 2*lineWindowRadius+1, strings.Count(file.Content, "\n"))
+	assert.Equal(t, "textImmutable", file.Type)
+
+	file, err = NewFileInfo(FileOptions{
+		Fs: fs, Path: "/", Expand: true, Checker: testutil.AllowAll{}, Line: 5000,
+	})
+	require.NoError(t, err)
+	assert.Zero(t, file.Line)
+}
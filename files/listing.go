@@ -7,12 +7,59 @@ import (
 	"github.com/maruel/natural"
 )
 
+// HistoryFile is the name of the file logging the moves made in a
+// directory. It is never listed.
+const HistoryFile = ".history"
+
 // Listing is a collection of files.
 type Listing struct {
-	Items    []*FileInfo `json:"items"`
-	NumDirs  int         `json:"numDirs"`
-	NumFiles int         `json:"numFiles"`
-	Sorting  Sorting     `json:"sorting"`
+	Items          []*FileInfo `json:"items"`
+	NumDirs        int         `json:"numDirs"`
+	NumFiles       int         `json:"numFiles"`
+	Sorting        Sorting     `json:"sorting"`
+	RenderedReadme string      `json:"renderedReadme,omitempty"`
+	// TotalSize is the size of the files of the listing. Directories
+	// are not counted.
+	TotalSize int64 `json:"totalSize"`
+	// Locale is the BCP 47 tag of the locale whose rules sort the names,
+	// such as "fr". Without one, names are in natural order ignoring case.
+	Locale string `json:"-"`
+	// Categories counts the files by category: image, video, text or other.
+	Categories map[string]int `json:"categories"`
+	// Groups are the items grouped by day, when asked for, see
+	// GroupByDate.
+	Groups []DateGroup `json:"groups,omitempty"`
+	// Largest is the name of the largest file.
+	Largest     string `json:"largest,omitempty"`
+	largestSize int64
+	// View is the view mode the listing is shown in, such as "list" or
+	// "mosaic".
+	View string `json:"view,omitempty"`
+	// Truncated is set when a flattened listing stopped at its maximum
+	// number of files, see Flatten. Omitted is the number of files left
+	// out, if known. The JSON key isn't "truncated", which is taken by
+	// the FileInfo the listing is part of.
+	Truncated bool `json:"listingTruncated,omitempty"`
+	Omitted   *int `json:"omitted,omitempty"`
+}
+
+// MostlyImages tells if more than half of the files are images.
+func (l *Listing) MostlyImages() bool {
+	return l.NumFiles > 0 && l.Categories["image"]*2 > l.NumFiles
+}
+
+// FindFile returns the first file, by order of names, whose name matches
+// one of names (case insensitive). Directories are ignored.
+func (l *Listing) FindFile(names ...string) *FileInfo {
+	for _, name := range names {
+		for _, item := range l.Items {
+			if !item.IsDir && !item.IsSpecial && strings.EqualFold(item.Name, name) {
+				return item
+			}
+		}
+	}
+
+	return nil
 }
 
 // ApplySort applies the sort order using .Order and .Sort
@@ -23,11 +70,13 @@ func (l Listing) ApplySort() {
 	if !l.Sorting.Asc {
 		switch l.Sorting.By {
 		case "name":
-			sort.Sort(sort.Reverse(byName(l)))
+			l.sortByName(true)
 		case "size":
 			sort.Sort(sort.Reverse(bySize(l)))
 		case "modified":
 			sort.Sort(sort.Reverse(byModified(l)))
+		case "category":
+			l.sortByCategory()
 		default:
 			// If not one of the above, do nothing
 			return
@@ -35,13 +84,15 @@ func (l Listing) ApplySort() {
 	} else { // If we had more Orderings we could add them here
 		switch l.Sorting.By {
 		case "name":
-			sort.Sort(byName(l))
+			l.sortByName(false)
 		case "size":
 			sort.Sort(bySize(l))
 		case "modified":
 			sort.Sort(byModified(l))
+		case "category":
+			l.sortByCategory()
 		default:
-			sort.Sort(byName(l))
+			l.sortByName(false)
 			return
 		}
 	}
@@ -51,6 +102,7 @@ func (l Listing) ApplySort() {
 type byName Listing
 type bySize Listing
 type byModified Listing
+type byCategory Listing
 
 // By Name
 func (l byName) Len() int {
@@ -108,3 +160,75 @@ func (l byModified) Less(i, j int) bool {
 	iModified, jModified := l.Items[i].ModTime, l.Items[j].ModTime
 	return iModified.Sub(jModified) < 0
 }
+
+// By Category
+func (l byCategory) Len() int {
+	return len(l.Items)
+}
+
+func (l byCategory) Swap(i, j int) {
+	l.Items[i], l.Items[j] = l.Items[j], l.Items[i]
+}
+
+func (l byCategory) Less(i, j int) bool {
+	iRank, jRank := categoryRank(l.Items[i]), categoryRank(l.Items[j])
+	if l.Sorting.Asc {
+		return iRank < jRank
+	}
+	return iRank > jRank
+}
+
+// sortByCategory sorts the items by category, and the items of each by
+// name. The order only reverses the order of the categories, the names are
+// always ascending.
+func (l Listing) sortByCategory() {
+	// Unless reversed, byName sorts the names in descending order.
+	byNameAsc := l
+	byNameAsc.Sorting.Asc = true
+	byNameAsc.sortByName(true)
+
+	sort.Stable(byCategory(l))
+}
+
+// categoryRank is the position of the category of the file when sorting
+// by category: directories, then images, videos, audio, text and the
+// other files.
+func categoryRank(file *FileInfo) int {
+	if file.IsDir {
+		return 0
+	}
+	switch file.Type {
+	case "image":
+		return 1
+	case "video":
+		return 2
+	case "audio":
+		return 3
+	case "text", "textImmutable":
+		return 4
+	default:
+		return 5
+	}
+}
+
+// addStats accounts the file in the aggregate statistics of the listing.
+func (l *Listing) addStats(file *FileInfo) {
+	l.TotalSize += file.Size
+	l.Categories[fileCategory(file.Type)]++
+
+	if l.Largest == "" || file.Size > l.largestSize {
+		l.Largest = file.Name
+		l.largestSize = file.Size
+	}
+}
+
+func fileCategory(fileType string) string {
+	switch fileType {
+	case "image", "video":
+		return fileType
+	case "text", "textImmutable":
+		return "text"
+	default:
+		return "other"
+	}
+}
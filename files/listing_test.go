@@ -0,0 +1,102 @@
+package files
+
+import (
+	"errors"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestDirMTimeFromContents(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/root/old.txt":     "",
+		"/root/sub/new.txt": "",
+		"/root/empty/":      "",
+	})
+
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	recent := old.Add(24 * time.Hour)
+	for _, name := range []string{"/root", "/root/old.txt", "/root/sub", "/root/empty"} {
+		require.NoError(t, fs.Chtimes(name, old, old))
+	}
+	require.NoError(t, fs.Chtimes("/root/sub/new.txt", recent, recent))
+
+	opts := FileOptions{Fs: fs, Path: "/root", Expand: true, Checker: testutil.AllowAll{}}
+
+	file, err := NewFileInfo(opts)
+	require.NoError(t, err)
+	assert.Equal(t, old, file.ModTime.UTC())
+	for _, item := range file.Items {
+		assert.Equal(t, old, item.ModTime.UTC(), item.Name)
+	}
+
+	opts.DirMTimeFromContents = true
+	file, err = NewFileInfo(opts)
+	require.NoError(t, err)
+
+	// The listed directory only looks at its own children, before the
+	// time of the subdirectories changes.
+	assert.Equal(t, old, file.ModTime.UTC())
+	times := map[string]time.Time{}
+	for _, item := range file.Items {
+		times[item.Name] = item.ModTime.UTC()
+	}
+	assert.Equal(t, map[string]time.Time{"old.txt": old, "sub": recent, "empty": old}, times)
+}
+
+// brokenEntryFs fails to stat the entries named "broken", which makes
+// reading their directory all at once fail too.
+type brokenEntryFs struct {
+	afero.Fs
+}
+
+var errBrokenEntry = errors.New("transport endpoint is not connected")
+
+func (fs brokenEntryFs) Stat(name string) (os.FileInfo, error) {
+	if path.Base(name) == "broken" {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: errBrokenEntry}
+	}
+	return fs.Fs.Stat(name)
+}
+
+func (fs brokenEntryFs) Open(name string) (afero.File, error) {
+	file, err := fs.Fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return brokenEntryDir{file}, nil
+}
+
+type brokenEntryDir struct {
+	afero.File
+}
+
+func (brokenEntryDir) Readdir(int) ([]os.FileInfo, error) {
+	return nil, errBrokenEntry
+}
+
+func TestListingWithBrokenEntry(t *testing.T) {
+	fs := brokenEntryFs{testutil.NewFs(t, map[string]string{
+		"/b.txt":  "b",
+		"/broken": "",
+		"/a/":     "",
+	})}
+
+	file, err := NewFileInfo(FileOptions{Fs: fs, Path: "/", Expand: true, Checker: testutil.AllowAll{}})
+	require.NoError(t, err)
+
+	errs := map[string]string{}
+	for _, item := range file.Items {
+		errs[item.Name] = item.Error
+	}
+	assert.Equal(t, map[string]string{"a": "", "b.txt": "", "broken": "stat /broken: " + errBrokenEntry.Error()}, errs)
+	assert.Equal(t, 1, file.NumDirs)
+	assert.Equal(t, 2, file.NumFiles)
+}
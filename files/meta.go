@@ -0,0 +1,65 @@
+package files
+
+import (
+	"encoding/json"
+	"os"
+	"path"
+
+	"github.com/spf13/afero"
+)
+
+// MetaDir is the hidden directory, next to the files, of the sidecars
+// storing the metadata supplied by clients along with a file, e.g.
+// encryption parameters. Keeping them apart from the files means they
+// never clash with the files of the users, and it isn't listed.
+const MetaDir = ".meta"
+
+// MetaPath returns the path of the sidecar of the file.
+func MetaPath(fPath string) string {
+	return path.Join(path.Dir(fPath), MetaDir, path.Base(fPath))
+}
+
+// ReadMeta reads the metadata stored for the file. It returns nil if
+// there is none, or if the sidecar can't be parsed.
+func ReadMeta(fs afero.Fs, fPath string) (map[string]string, error) {
+	content, err := afero.ReadFile(fs, MetaPath(fPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var meta map[string]string
+	if json.Unmarshal(content, &meta) != nil {
+		return nil, nil
+	}
+	return meta, nil
+}
+
+// WriteMeta replaces the metadata stored for the file. An empty meta
+// removes the sidecar, and the directory of the sidecars once empty.
+func WriteMeta(fs afero.Fs, fPath string, meta map[string]string) error {
+	metaPath := MetaPath(fPath)
+	if len(meta) == 0 {
+		err := fs.Remove(metaPath)
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		// Fails if there are other sidecars left.
+		_ = fs.Remove(path.Dir(metaPath))
+		return nil
+	}
+
+	content, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	if err := fs.MkdirAll(path.Dir(metaPath), 0775); err != nil {
+		return err
+	}
+	return afero.WriteFile(fs, metaPath, content, 0640)
+}
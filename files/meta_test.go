@@ -0,0 +1,55 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestMeta(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/foo":      "foo",
+		"/foo.meta": "not json",
+	})
+
+	meta, err := ReadMeta(fs, "/foo")
+	require.NoError(t, err)
+	require.Nil(t, meta, "the files of the users aren't sidecars")
+
+	require.NoError(t, WriteMeta(fs, "/foo", map[string]string{"iv": "abc"}))
+	meta, err = ReadMeta(fs, "/foo")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"iv": "abc"}, meta)
+
+	require.NoError(t, afero.WriteFile(fs, MetaPath("/foo"), []byte("{"), 0644))
+	meta, err = ReadMeta(fs, "/foo")
+	require.NoError(t, err)
+	require.Nil(t, meta, "broken sidecars count as none")
+
+	require.NoError(t, WriteMeta(fs, "/foo", nil))
+	require.NoError(t, WriteMeta(fs, "/bar", nil))
+	require.Equal(t, map[string]string{
+		"/foo":      "foo",
+		"/foo.meta": "not json",
+	}, testutil.Tree(t, fs))
+}
+
+func TestMetaListing(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/foo":      "foo",
+		"/foo.meta": "{}",
+	})
+	require.NoError(t, WriteMeta(fs, "/foo", map[string]string{"iv": "abc"}))
+
+	file, err := NewFileInfo(FileOptions{Fs: fs, Path: "/", Expand: true, Checker: testutil.AllowAll{}})
+	require.NoError(t, err)
+
+	names := []string{}
+	for _, item := range file.Items {
+		names = append(names, item.Name)
+	}
+	require.ElementsMatch(t, []string{"foo", "foo.meta"}, names)
+}
@@ -0,0 +1,63 @@
+package files
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+)
+
+// defaultNameMax is the maximum length in bytes of the names of the
+// files on most filesystems, assumed when it can't be queried.
+const defaultNameMax = 255
+
+// NameMax returns the maximum length in bytes of the names of the files
+// in the directory, or in its closest existing parent. It's queried from
+// the OS filesystem, defaultNameMax is returned for the others.
+func NameMax(fs afero.Fs, dir string) int {
+	p, ok := realPath(fs, "/")
+	if !ok {
+		return defaultNameMax
+	}
+
+	for dir = path.Clean("/" + dir); ; dir = path.Dir(dir) {
+		if max, err := nameMax(path.Join(p, dir)); err == nil && max > 0 {
+			return max
+		}
+		if dir == "/" {
+			return defaultNameMax
+		}
+	}
+}
+
+// CheckNameLength returns errors.ErrNameTooLong if an element of the path
+// is longer than max, see NameMax. The lengths are in bytes, so multibyte
+// characters count as several.
+func CheckNameLength(fPath string, max int) error {
+	for _, elem := range strings.Split(fPath, "/") {
+		if len(elem) > max {
+			return fmt.Errorf("the name %q is %d bytes long, the maximum is %d: %w",
+				truncateName(elem, 32)+"…", len(elem), max, errors.ErrNameTooLong)
+		}
+	}
+	return nil
+}
+
+// truncateName truncates the name to at most max bytes, without cutting a
+// multibyte character.
+func truncateName(name string, max int) string {
+	if len(name) <= max {
+		return name
+	}
+	if max < 0 {
+		return ""
+	}
+	for max > 0 && !utf8.RuneStart(name[max]) {
+		max--
+	}
+	return name[:max]
+}
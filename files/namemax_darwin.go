@@ -0,0 +1,12 @@
+package files
+
+import "golang.org/x/sys/unix"
+
+// pcNameMax is _PC_NAME_MAX, which x/sys doesn't define for Darwin.
+const pcNameMax = 4
+
+// nameMax returns the maximum length of the names of the filesystem of
+// the directory.
+func nameMax(dir string) (int, error) {
+	return unix.Pathconf(dir, pcNameMax)
+}
@@ -0,0 +1,13 @@
+package files
+
+import "golang.org/x/sys/unix"
+
+// nameMax returns the maximum length of the names of the filesystem of
+// the directory, the one pathconf would return.
+func nameMax(dir string) (int, error) {
+	var stat unix.Statfs_t
+	if err := unix.Statfs(dir, &stat); err != nil {
+		return 0, err
+	}
+	return int(stat.Namelen), nil
+}
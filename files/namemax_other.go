@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package files
+
+import "errors"
+
+// nameMax can't be queried on this platform, defaultNameMax is assumed.
+func nameMax(string) (int, error) {
+	return 0, errors.New("unsupported")
+}
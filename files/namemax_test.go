@@ -0,0 +1,39 @@
+package files
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+)
+
+func TestCheckNameLength(t *testing.T) {
+	testCases := map[string]bool{
+		"/" + strings.Repeat("a", 255):              true,
+		"/" + strings.Repeat("a", 300):              false,
+		"/" + strings.Repeat("a", 300) + "/b.txt":   false,
+		"/dir/" + strings.Repeat("日", 85):           true,
+		"/dir/" + strings.Repeat("日", 100) + ".txt": false,
+	}
+
+	for fPath, ok := range testCases {
+		err := CheckNameLength(fPath, 255)
+		if ok {
+			require.NoError(t, err)
+		} else {
+			require.True(t, stderrors.Is(err, errors.ErrNameTooLong), err)
+		}
+	}
+}
+
+func TestNameMax(t *testing.T) {
+	require.Equal(t, defaultNameMax, NameMax(afero.NewMemMapFs(), "/"))
+
+	// The closest existing parent is queried.
+	fs := afero.NewBasePathFs(afero.NewOsFs(), t.TempDir())
+	require.Greater(t, NameMax(fs, "/missing/dir"), 0)
+}
@@ -0,0 +1,74 @@
+package files
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+)
+
+// Line endings the saved text files can be normalized to.
+const (
+	LineEndingsLF   = "lf"
+	LineEndingsCRLF = "crlf"
+)
+
+// TextNormalization tells how the content of a saved text file is
+// normalized. The zero value keeps the exact bytes.
+type TextNormalization struct {
+	// LineEndings is LineEndingsLF or LineEndingsCRLF to convert all the
+	// line endings to, or empty to keep them.
+	LineEndings string
+	// FinalNewline makes the content end with exactly one line ending,
+	// unless empty.
+	FinalNewline bool
+}
+
+// ParseLineEndings parses line endings, "preserve" being the same as
+// empty. It returns errors.ErrInvalidRequestParams for unknown ones.
+func ParseLineEndings(value string) (string, error) {
+	switch value = strings.ToLower(strings.TrimSpace(value)); value {
+	case LineEndingsLF, LineEndingsCRLF:
+		return value, nil
+	case "", "preserve":
+		return "", nil
+	default:
+		return "", fmt.Errorf("invalid line endings %q: %w", value, errors.ErrInvalidRequestParams)
+	}
+}
+
+// IsZero tells if the normalization keeps the content as is.
+func (n TextNormalization) IsZero() bool {
+	return n == TextNormalization{}
+}
+
+// Apply returns the content normalized. Binary content is never changed.
+// The final newline uses the line endings converted to, or else the
+// first of the content, or else LF.
+func (n TextNormalization) Apply(content []byte) []byte {
+	if n.IsZero() || len(content) == 0 || isBinary(content) {
+		return content
+	}
+
+	switch n.LineEndings {
+	case LineEndingsLF:
+		content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+	case LineEndingsCRLF:
+		content = bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+		content = bytes.ReplaceAll(content, []byte("\n"), []byte("\r\n"))
+	}
+
+	if n.FinalNewline {
+		newline := []byte("\n")
+		if i := bytes.IndexByte(content, '\n'); n.LineEndings == LineEndingsCRLF ||
+			(n.LineEndings == "" && i > 0 && content[i-1] == '\r') {
+			newline = []byte("\r\n")
+		}
+
+		trimmed := bytes.TrimRight(content, "\r\n")
+		content = append(trimmed[:len(trimmed):len(trimmed)], newline...)
+	}
+
+	return content
+}
@@ -0,0 +1,48 @@
+package files
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+)
+
+func TestTextNormalization(t *testing.T) {
+	testCases := map[string]struct {
+		normalization TextNormalization
+		content, want string
+	}{
+		"zero":                {TextNormalization{}, "a\r\nb\n\n\n", "a\r\nb\n\n\n"},
+		"crlf to lf":          {TextNormalization{LineEndings: LineEndingsLF}, "a\r\nb\r\n", "a\nb\n"},
+		"lf to crlf":          {TextNormalization{LineEndings: LineEndingsCRLF}, "a\nb\n", "a\r\nb\r\n"},
+		"mixed to crlf":       {TextNormalization{LineEndings: LineEndingsCRLF}, "a\r\nb\nc", "a\r\nb\r\nc"},
+		"missing newline":     {TextNormalization{FinalNewline: true}, "a\nb", "a\nb\n"},
+		"extra newlines":      {TextNormalization{FinalNewline: true}, "a\nb\n\n\n", "a\nb\n"},
+		"crlf newline":        {TextNormalization{FinalNewline: true}, "a\r\nb", "a\r\nb\r\n"},
+		"newline after lf":    {TextNormalization{LineEndings: LineEndingsLF, FinalNewline: true}, "a\r\nb\r\n\r\n", "a\nb\n"},
+		"newline after crlf":  {TextNormalization{LineEndings: LineEndingsCRLF, FinalNewline: true}, "a\nb", "a\r\nb\r\n"},
+		"single line":         {TextNormalization{FinalNewline: true}, "a", "a\n"},
+		"empty":               {TextNormalization{LineEndings: LineEndingsCRLF, FinalNewline: true}, "", ""},
+		"binary is untouched": {TextNormalization{LineEndings: LineEndingsCRLF, FinalNewline: true}, "\x00\x01\n", "\x00\x01\n"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, string(tc.normalization.Apply([]byte(tc.content))))
+		})
+	}
+}
+
+func TestParseLineEndings(t *testing.T) {
+	for value, want := range map[string]string{"": "", "preserve": "", "LF": LineEndingsLF, " crlf ": LineEndingsCRLF} {
+		lineEndings, err := ParseLineEndings(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, lineEndings, value)
+	}
+
+	_, err := ParseLineEndings("cr")
+	assert.True(t, stderrors.Is(err, errors.ErrInvalidRequestParams))
+}
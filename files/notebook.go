@@ -0,0 +1,170 @@
+package files
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// notebookMaxCells is the number of cells of a notebook rendered, the
+// others are only counted.
+const notebookMaxCells = 200
+
+// notebook is the part of a Jupyter notebook which is rendered.
+type notebook struct {
+	Cells []notebookCell `json:"cells"`
+}
+
+type notebookCell struct {
+	CellType string           `json:"cell_type"`
+	Source   notebookText     `json:"source"`
+	Outputs  []notebookOutput `json:"outputs"`
+}
+
+type notebookOutput struct {
+	OutputType string                     `json:"output_type"`
+	Text       notebookText               `json:"text"`
+	Data       map[string]json.RawMessage `json:"data"`
+	Ename      string                     `json:"ename"`
+	Evalue     string                     `json:"evalue"`
+	Traceback  []string                   `json:"traceback"`
+}
+
+// notebookText is the text of a notebook, either a string or the list of
+// its lines.
+type notebookText string
+
+func (t *notebookText) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*t = notebookText(s)
+		return nil
+	}
+
+	var lines []string
+	if err := json.Unmarshal(b, &lines); err != nil {
+		return err
+	}
+	*t = notebookText(strings.Join(lines, ""))
+	return nil
+}
+
+// ansiEscapes matches the terminal color codes of the tracebacks.
+var ansiEscapes = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// isNotebook tells if the file is a Jupyter notebook.
+func (i *FileInfo) isNotebook() bool {
+	return strings.EqualFold(i.Extension, ".ipynb")
+}
+
+// renderNotebook renders the cells of the notebook of the content into
+// RenderedHTML. Markdown goes through RenderMarkdown and any other text is
+// escaped, so the outputs can't carry scripts: HTML outputs are shown as
+// their plain text alternative. Content which is JSON but not a notebook
+// is shown indented instead.
+func (i *FileInfo) renderNotebook() {
+	if i.Truncated || i.Content == "" {
+		return
+	}
+
+	var nb notebook
+	if err := json.Unmarshal([]byte(i.Content), &nb); err != nil || nb.Cells == nil {
+		var indented bytes.Buffer
+		if json.Indent(&indented, []byte(i.Content), "", "  ") == nil {
+			i.RenderedHTML = "<pre>" + html.EscapeString(indented.String()) + "</pre>"
+		}
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(`<div class="notebook">`)
+	for n, cell := range nb.Cells {
+		if n == notebookMaxCells {
+			fmt.Fprintf(&b, `<p class="notebook-more">%d more cells</p>`, len(nb.Cells)-n)
+			break
+		}
+		renderNotebookCell(&b, cell)
+	}
+	b.WriteString(`</div>`)
+	i.RenderedHTML = b.String()
+}
+
+func renderNotebookCell(b *strings.Builder, cell notebookCell) {
+	switch cell.CellType {
+	case "markdown":
+		b.WriteString(`<div class="notebook-markdown">`)
+		b.WriteString(RenderMarkdown([]byte(cell.Source)))
+		b.WriteString(`</div>`)
+	case "code":
+		b.WriteString(`<pre class="notebook-code"><code>`)
+		b.WriteString(html.EscapeString(string(cell.Source)))
+		b.WriteString(`</code></pre>`)
+		for _, output := range cell.Outputs {
+			renderNotebookOutput(b, output)
+		}
+	default:
+		b.WriteString(`<pre class="notebook-raw">`)
+		b.WriteString(html.EscapeString(string(cell.Source)))
+		b.WriteString(`</pre>`)
+	}
+}
+
+func renderNotebookOutput(b *strings.Builder, output notebookOutput) {
+	switch output.OutputType {
+	case "stream":
+		writeOutputText(b, string(output.Text))
+	case "error":
+		text := output.Ename + ": " + output.Evalue
+		if len(output.Traceback) > 0 {
+			text = strings.Join(output.Traceback, "\n")
+		}
+		b.WriteString(`<pre class="notebook-output notebook-error">`)
+		b.WriteString(html.EscapeString(ansiEscapes.ReplaceAllString(text, "")))
+		b.WriteString(`</pre>`)
+	case "execute_result", "display_data":
+		for _, mimetype := range []string{"image/png", "image/jpeg", "image/gif"} {
+			if data, ok := output.data(mimetype); ok {
+				// Only valid base64 goes into the data URL.
+				encoded := strings.Join(strings.Fields(data), "")
+				if _, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+					fmt.Fprintf(b, `<img class="notebook-output" src="data:%s;base64,%s">`, mimetype, encoded)
+					return
+				}
+			}
+		}
+		if data, ok := output.data("text/markdown"); ok {
+			b.WriteString(`<div class="notebook-output">`)
+			b.WriteString(RenderMarkdown([]byte(data)))
+			b.WriteString(`</div>`)
+			return
+		}
+		if data, ok := output.data("text/plain"); ok {
+			writeOutputText(b, data)
+		}
+	}
+}
+
+// data returns the text of the output in the MIME type, if any. The
+// outputs in JSON types aren't text.
+func (o notebookOutput) data(mimetype string) (string, bool) {
+	raw, ok := o.Data[mimetype]
+	if !ok {
+		return "", false
+	}
+
+	var text notebookText
+	if err := json.Unmarshal(raw, &text); err != nil {
+		return "", false
+	}
+	return string(text), true
+}
+
+func writeOutputText(b *strings.Builder, text string) {
+	b.WriteString(`<pre class="notebook-output">`)
+	b.WriteString(html.EscapeString(text))
+	b.WriteString(`</pre>`)
+}
@@ -0,0 +1,74 @@
+package files
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+const testNotebook = `{
+ "cells": [
+  {"cell_type": "markdown", "source": ["# Title\n", "<script>alert(1)</script>"]},
+  {"cell_type": "code", "source": "print('<b>')", "outputs": [
+   {"output_type": "stream", "name": "stdout", "text": ["<b>\n"]},
+   {"output_type": "display_data", "data": {"text/html": "<script>alert(2)</script>", "text/plain": "<HTML object>"}},
+   {"output_type": "execute_result", "data": {"image/png": "aGVsbG8=\n", "text/plain": "image"}},
+   {"output_type": "display_data", "data": {"application/json": {"a": 1}, "text/plain": "{'a': 1}"}},
+   {"output_type": "error", "ename": "ValueError", "evalue": "bad", "traceback": ["\u001b[31mValueError\u001b[0m: bad"]}
+  ]}
+ ],
+ "metadata": {},
+ "nbformat": 4
+}`
+
+func TestRenderNotebook(t *testing.T) {
+	var many strings.Builder
+	many.WriteString(`{"cells": [`)
+	for n := 0; n < notebookMaxCells+5; n++ {
+		if n > 0 {
+			many.WriteString(",")
+		}
+		fmt.Fprintf(&many, `{"cell_type": "raw", "source": "cell %d"}`, n)
+	}
+	many.WriteString(`]}`)
+
+	fs := testutil.NewFs(t, map[string]string{
+		"/analysis.ipynb": testNotebook,
+		"/many.ipynb":     many.String(),
+		"/other.ipynb":    `{"not":"a notebook"}`,
+		"/broken.ipynb":   `{"cells": [`,
+	})
+
+	open := func(name string) *FileInfo {
+		file, err := NewFileInfo(FileOptions{Fs: fs, Path: name, Modify: true, Expand: true, Checker: testutil.AllowAll{}})
+		require.NoError(t, err)
+		return file
+	}
+
+	file := open("/analysis.ipynb")
+	assert.Equal(t, "text", file.Type)
+	assert.Equal(t, testNotebook, file.Content)
+	rendered := file.RenderedHTML
+	assert.Contains(t, rendered, "<h1>Title</h1>")
+	assert.Contains(t, rendered, `<pre class="notebook-code"><code>print(&#39;&lt;b&gt;&#39;)</code></pre>`)
+	assert.Contains(t, rendered, `<pre class="notebook-output">&lt;b&gt;`+"\n</pre>")
+	assert.Contains(t, rendered, "&lt;HTML object&gt;")
+	assert.Contains(t, rendered, `<img class="notebook-output" src="data:image/png;base64,aGVsbG8=">`)
+	assert.Contains(t, rendered, "{&#39;a&#39;: 1}")
+	assert.Contains(t, rendered, "ValueError: bad")
+	assert.NotContains(t, rendered, "<script")
+	assert.NotContains(t, rendered, "\x1b")
+
+	rendered = open("/many.ipynb").RenderedHTML
+	assert.Contains(t, rendered, fmt.Sprintf("cell %d<", notebookMaxCells-1))
+	assert.NotContains(t, rendered, fmt.Sprintf("cell %d<", notebookMaxCells))
+	assert.Contains(t, rendered, "5 more cells")
+
+	assert.Equal(t, "<pre>{\n  &#34;not&#34;: &#34;a notebook&#34;\n}</pre>", open("/other.ipynb").RenderedHTML)
+	assert.Empty(t, open("/broken.ipynb").RenderedHTML)
+}
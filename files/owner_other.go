@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package files
+
+import "os"
+
+// fileOwner is a no-op on platforms without numeric file owners.
+func fileOwner(os.FileInfo, map[uint32]string) string {
+	return ""
+}
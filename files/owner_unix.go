@@ -0,0 +1,34 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package files
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// fileOwner returns the name of the owner of the file, or its uid if it
+// can't be looked up. Lookups are cached in names. It returns an empty
+// string if the file isn't on the OS filesystem.
+func fileOwner(info os.FileInfo, names map[uint32]string) string {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return ""
+	}
+
+	if name, ok := names[stat.Uid]; ok {
+		return name
+	}
+
+	uid := strconv.FormatUint(uint64(stat.Uid), 10)
+	name := uid
+	if u, err := user.LookupId(uid); err == nil {
+		name = u.Username
+	}
+
+	names[stat.Uid] = name
+	return name
+}
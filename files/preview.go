@@ -0,0 +1,90 @@
+package files
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"unicode/utf8"
+)
+
+// PreviewLimits are the maximum sizes of the content of the text files
+// sent to be previewed. The larger files are truncated.
+type PreviewLimits struct {
+	// Default is the limit of the categories without one, previewMaxSize
+	// if zero.
+	Default int64
+	// ByCategory are the limits by category: the extension of the files
+	// without the dot, such as "json" or "log", or "text" for all of them.
+	ByCategory map[string]int64
+}
+
+// limit returns the limit of the files with the given extension.
+func (l PreviewLimits) limit(ext string) int64 {
+	if category := strings.ToLower(strings.TrimPrefix(ext, ".")); category != "" {
+		if limit, ok := l.ByCategory[category]; ok {
+			return limit
+		}
+	}
+	if limit, ok := l.ByCategory["text"]; ok {
+		return limit
+	}
+	if l.Default > 0 {
+		return l.Default
+	}
+	return previewMaxSize
+}
+
+// Capped returns the limits lowered to max, such as for the devices
+// which can't show large previews.
+func (l PreviewLimits) Capped(max int64) PreviewLimits {
+	capped := PreviewLimits{Default: l.Default, ByCategory: make(map[string]int64, len(l.ByCategory))}
+	if capped.Default <= 0 {
+		capped.Default = previewMaxSize
+	}
+	if capped.Default > max {
+		capped.Default = max
+	}
+	for category, limit := range l.ByCategory {
+		if limit > max {
+			limit = max
+		}
+		capped.ByCategory[category] = limit
+	}
+	return capped
+}
+
+// readPreview reads the content of a text file up to the limit of its
+// category, and records the limit and whether the content was truncated.
+// Truncated content can't be saved.
+func (i *FileInfo) readPreview(reader io.Reader, ext string) (string, error) {
+	limit := i.previewLimits.limit(ext)
+
+	content, err := ioutil.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return "", err
+	}
+
+	i.PreviewLimit = limit
+	if int64(len(content)) > limit {
+		content = trimPartialRune(content[:limit])
+		i.Truncated = true
+		i.Type = "textImmutable"
+	}
+
+	return string(content), nil
+}
+
+// trimPartialRune removes the incomplete character the truncated content
+// may end with.
+func trimPartialRune(content []byte) []byte {
+	for n := 1; n <= utf8.UTFMax && n <= len(content); n++ {
+		start := len(content) - n
+		if utf8.RuneStart(content[start]) {
+			if !utf8.FullRune(content[start:]) {
+				return content[:start]
+			}
+			break
+		}
+	}
+	return content
+}
@@ -0,0 +1,117 @@
+package files
+
+import (
+	stderrors "errors"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestPreviewLimits(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/app.log":     strings.Repeat("x", 20),
+		"/data.json":   strings.Repeat("x", 20),
+		"/notes.txt":   strings.Repeat("x", 20),
+		"/accents.txt": "aaaaaaéé",
+	})
+
+	limits := PreviewLimits{Default: 16, ByCategory: map[string]int64{"log": 8, "json": 100}}
+
+	testCases := map[string]struct {
+		content   string
+		truncated bool
+		limit     int64
+	}{
+		"/app.log":     {content: strings.Repeat("x", 8), truncated: true, limit: 8},
+		"/data.json":   {content: strings.Repeat("x", 20), limit: 100},
+		"/notes.txt":   {content: strings.Repeat("x", 16), truncated: true, limit: 16},
+		"/accents.txt": {content: "aaaaaaéé", limit: 16},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			file, err := NewFileInfo(FileOptions{
+				Fs: fs, Path: name, Modify: true, Expand: true, Checker: testutil.AllowAll{}, PreviewLimits: limits,
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.content, file.Content)
+			assert.Equal(t, tc.truncated, file.Truncated)
+			assert.Equal(t, tc.limit, file.PreviewLimit)
+			if tc.truncated {
+				assert.Equal(t, "textImmutable", file.Type)
+			} else {
+				assert.Equal(t, "text", file.Type)
+			}
+		})
+	}
+
+	// A character isn't cut in two.
+	file, err := NewFileInfo(FileOptions{
+		Fs: fs, Path: "/accents.txt", Expand: true, Checker: testutil.AllowAll{},
+		PreviewLimits: PreviewLimits{Default: 9},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "aaaaaaé", file.Content)
+	assert.True(t, file.Truncated)
+}
+
+func TestTextExtensions(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/app.YAML": "debug: true\n",
+		"/.env":     "TOKEN=\x00\n",
+		"/data.bin": "\x00\x01\x02",
+	})
+
+	testCases := map[string]struct {
+		typ     string
+		content string
+	}{
+		"/app.YAML": {typ: "text", content: "debug: true\n"},
+		"/.env":     {typ: "text", content: "TOKEN=\x00\n"},
+		"/data.bin": {typ: "blob"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			file, err := NewFileInfo(FileOptions{
+				Fs: fs, Path: name, Modify: true, Expand: true, Checker: testutil.AllowAll{},
+				TextExtensions: []string{".yaml", ".env"},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tc.typ, file.Type)
+			assert.Equal(t, tc.content, file.Content)
+		})
+	}
+}
+
+// unreadableFs fails to open the files, which can still be stated.
+type unreadableFs struct {
+	afero.Fs
+}
+
+func (fs unreadableFs) Open(name string) (afero.File, error) {
+	return nil, &os.PathError{Op: "open", Path: name, Err: syscall.EIO}
+}
+
+func TestGracefulPreviewErrors(t *testing.T) {
+	fs := unreadableFs{testutil.NewFs(t, map[string]string{"/notes.txt": "notes"})}
+	opts := FileOptions{Fs: fs, Path: "/notes.txt", Modify: true, Expand: true, Checker: testutil.AllowAll{}}
+
+	_, err := NewFileInfo(opts)
+	require.True(t, stderrors.Is(err, syscall.EIO), err)
+
+	opts.GracefulPreviewErrors = true
+	file, err := NewFileInfo(opts)
+	require.NoError(t, err)
+	assert.True(t, file.PreviewFailed)
+	assert.Equal(t, "blob", file.Type)
+	assert.Equal(t, int64(5), file.Size)
+	assert.Empty(t, file.Content)
+}
@@ -0,0 +1,58 @@
+package files
+
+import (
+	"html"
+	"log"
+	"strings"
+
+	"github.com/russross/blackfriday/v2"
+	"github.com/spf13/afero"
+)
+
+// readmeFlags makes blackfriday drop any raw HTML embedded in the
+// markdown and only emit links with safe protocols, so the rendered
+// output can't carry scripts.
+const readmeFlags = blackfriday.SkipHTML | blackfriday.Safelink |
+	blackfriday.NofollowLinks | blackfriday.NoreferrerLinks | blackfriday.HrefTargetBlank
+
+// RenderReadme looks for the first file of the listing matching one of
+// names (case insensitive) and renders it into Listing.RenderedReadme.
+// Markdown files are converted to HTML, any other file is shown as
+// preformatted text. Files bigger than maxSize are skipped.
+func (i *FileInfo) RenderReadme(names []string, maxSize int64) {
+	if i.Listing == nil {
+		return
+	}
+
+	item := i.Listing.FindFile(names...)
+	if item == nil || item.Size > maxSize {
+		return
+	}
+
+	content, err := afero.ReadFile(i.Fs, item.Path)
+	if err != nil {
+		// a broken readme shouldn't break the listing.
+		log.Print(err)
+		return
+	}
+
+	i.Listing.RenderedReadme = renderReadme(item.Extension, content)
+}
+
+func renderReadme(extension string, content []byte) string {
+	switch strings.ToLower(extension) {
+	case ".md", ".markdown":
+		return RenderMarkdown(content)
+	default:
+		return "<pre>" + html.EscapeString(string(content)) + "</pre>"
+	}
+}
+
+// RenderMarkdown converts markdown to HTML, without any of the raw HTML
+// it embeds nor links with unsafe protocols.
+func RenderMarkdown(content []byte) string {
+	renderer := blackfriday.NewHTMLRenderer(blackfriday.HTMLRendererParameters{
+		Flags: readmeFlags,
+	})
+	return string(blackfriday.Run(content, blackfriday.WithRenderer(renderer)))
+}
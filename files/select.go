@@ -0,0 +1,55 @@
+package files
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/rules"
+)
+
+// Select returns the paths of the entries of the directory whose name
+// matches the pattern, see path.Match, ignoring case, sorted by name. All
+// of them are selected if the pattern is empty. The entries listings never
+// show, and those the checker denies, aren't selected, so the selection is
+// what the listing of the directory shows.
+func Select(fs afero.Fs, dir, pattern string, checker rules.Checker) ([]string, error) {
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, errors.ErrInvalidRequestParams)
+	}
+
+	file, err := fs.Open(dir)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	names, err := file.Readdirnames(-1)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+
+	paths := []string{}
+	for _, name := range names {
+		fPath := path.Join(dir, name)
+		if isHiddenEntry(name) || !checker.Check(fPath) {
+			continue
+		}
+
+		if pattern != "" {
+			if ok, _ := path.Match(pattern, strings.ToLower(name)); !ok {
+				continue
+			}
+		}
+
+		paths = append(paths, fPath)
+	}
+
+	return paths, nil
+}
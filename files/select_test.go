@@ -0,0 +1,49 @@
+package files
+
+import (
+	stderrors "errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestSelect(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/photos/a.jpg":          "",
+		"/photos/B.JPG":          "",
+		"/photos/notes.txt":      "",
+		"/photos/" + SortFile:    "",
+		"/photos/sub/c.jpg":      "",
+		"/photos/secret.jpg":     "",
+		"/photos/.hidden.jpg":    "",
+		"/photos/" + HistoryFile: "",
+	})
+	checker := denyPaths{"/photos/secret.jpg": true, "/photos/.hidden.jpg": true}
+
+	testCases := map[string][]string{
+		"*.jpg": {"/photos/B.JPG", "/photos/a.jpg"},
+		"":      {"/photos/B.JPG", "/photos/a.jpg", "/photos/notes.txt", "/photos/sub"},
+		"*.png": {},
+	}
+
+	for pattern, want := range testCases {
+		t.Run(pattern, func(t *testing.T) {
+			paths, err := Select(fs, "/photos", pattern, checker)
+			require.NoError(t, err)
+			require.Equal(t, want, paths)
+		})
+	}
+
+	_, err := Select(fs, "/photos", "[", checker)
+	require.True(t, stderrors.Is(err, errors.ErrInvalidRequestParams))
+}
+
+// denyPaths is a rules.Checker denying the paths set.
+type denyPaths map[string]bool
+
+func (d denyPaths) Check(p string) bool {
+	return !d[p]
+}
@@ -1,7 +1,110 @@
 package files
 
+import (
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// SortFile is the name of the file a directory declares its preferred
+// sorting with, such as "modified desc". It is never listed.
+const SortFile = ".sort"
+
 // Sorting contains a sorting order.
 type Sorting struct {
 	By  string `json:"by"`
 	Asc bool   `json:"asc"`
 }
+
+// ParseSorting parses a sorting such as "size desc": a sort key (name,
+// size, modified, also spelled modtime or mtime, or category) optionally
+// followed by an order (asc or desc, ascending by default). It returns
+// false if the sorting is malformed.
+func ParseSorting(value string) (Sorting, bool) {
+	fields := strings.Fields(strings.ToLower(value))
+	if len(fields) == 0 || len(fields) > 2 {
+		return Sorting{}, false
+	}
+
+	sorting := Sorting{By: fields[0], Asc: true}
+	switch sorting.By {
+	case "name", "size", "modified", "category":
+	case "modtime", "mtime", "date":
+		sorting.By = "modified"
+	default:
+		return Sorting{}, false
+	}
+
+	if len(fields) == 2 {
+		switch fields[1] {
+		case "asc", "ascending":
+		case "desc", "descending":
+			sorting.Asc = false
+		default:
+			return Sorting{}, false
+		}
+	}
+
+	return sorting, true
+}
+
+// ReadSortFile reads the sorting the directory declares in its SortFile.
+// It returns false if there is none or it is malformed. Lines starting
+// with # are comments.
+func ReadSortFile(fs afero.Fs, dir string) (Sorting, bool) {
+	line, ok := readDeclaration(fs, path.Join(dir, SortFile))
+	if !ok {
+		return Sorting{}, false
+	}
+	return ParseSorting(line)
+}
+
+// ViewFile is the name of the file a directory declares the view mode it
+// is shown in with, such as "mosaic" for a gallery. It is never listed.
+const ViewFile = ".view"
+
+// ReadViewFile reads the view mode the directory declares in its
+// ViewFile. It returns false if there is none or it isn't one of views.
+// Lines starting with # are comments.
+func ReadViewFile(fs afero.Fs, dir string, views ...string) (string, bool) {
+	line, ok := readDeclaration(fs, path.Join(dir, ViewFile))
+	if !ok {
+		return "", false
+	}
+
+	line = strings.ToLower(line)
+	for _, view := range views {
+		if line == view {
+			return view, true
+		}
+	}
+	return "", false
+}
+
+// readDeclaration returns the first line of the file which isn't empty
+// nor a comment, starting with #.
+func readDeclaration(fs afero.Fs, name string) (string, bool) {
+	file, err := fs.Open(name)
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	content, err := ioutil.ReadAll(io.LimitReader(file, 1024))
+	if err != nil {
+		return "", false
+	}
+
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		return line, true
+	}
+
+	return "", false
+}
@@ -0,0 +1,110 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestParseSorting(t *testing.T) {
+	testCases := map[string]struct {
+		want Sorting
+		ok   bool
+	}{
+		"name":           {Sorting{By: "name", Asc: true}, true},
+		"modtime desc":   {Sorting{By: "modified", Asc: false}, true},
+		"  SIZE   Desc ": {Sorting{By: "size", Asc: false}, true},
+		"modified asc":   {Sorting{By: "modified", Asc: true}, true},
+		"":               {Sorting{}, false},
+		"color":          {Sorting{}, false},
+		"size sideways":  {Sorting{}, false},
+		"category desc":  {Sorting{By: "category", Asc: false}, true},
+		"size desc now":  {Sorting{}, false},
+	}
+
+	for value, tc := range testCases {
+		t.Run(value, func(t *testing.T) {
+			sorting, ok := ParseSorting(value)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.want, sorting)
+		})
+	}
+}
+
+func TestReadSortFile(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/logs/.sort":     "# newest first\nmodtime desc\n",
+		"/logs/a.log":     "a",
+		"/broken/.sort":   "whatever",
+		"/unsorted/b.txt": "b",
+	})
+
+	sorting, ok := ReadSortFile(fs, "/logs")
+	require.True(t, ok)
+	assert.Equal(t, Sorting{By: "modified", Asc: false}, sorting)
+
+	_, ok = ReadSortFile(fs, "/broken")
+	assert.False(t, ok)
+
+	_, ok = ReadSortFile(fs, "/unsorted")
+	assert.False(t, ok)
+
+	file, err := NewFileInfo(FileOptions{Fs: fs, Path: "/logs", Expand: true, Checker: testutil.AllowAll{}})
+	require.NoError(t, err)
+	require.Len(t, file.Items, 1, "the sort file isn't listed")
+	assert.Equal(t, "a.log", file.Items[0].Name)
+}
+
+func TestReadViewFile(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/photos/.view": "# gallery\nMosaic\n",
+		"/photos/a.jpg": "a",
+		"/escape/.view": "../../templates/admin",
+		"/plain/b.txt":  "b",
+	})
+
+	view, ok := ReadViewFile(fs, "/photos", "list", "mosaic")
+	require.True(t, ok)
+	assert.Equal(t, "mosaic", view)
+
+	_, ok = ReadViewFile(fs, "/escape", "list", "mosaic")
+	assert.False(t, ok, "only the given views are declared")
+
+	_, ok = ReadViewFile(fs, "/plain", "list", "mosaic")
+	assert.False(t, ok)
+
+	file, err := NewFileInfo(FileOptions{Fs: fs, Path: "/photos", Expand: true, Checker: testutil.AllowAll{}})
+	require.NoError(t, err)
+	require.Len(t, file.Items, 1, "the view file isn't listed")
+	assert.Equal(t, "a.jpg", file.Items[0].Name)
+}
+
+func TestSortByCategory(t *testing.T) {
+	items := []*FileInfo{
+		{Name: "b.txt", Type: "text"},
+		{Name: "song.mp3", Type: "audio"},
+		{Name: "z.png", Type: "image"},
+		{Name: "docs", IsDir: true},
+		{Name: "clip.mp4", Type: "video"},
+		{Name: "a.bin", Type: "blob"},
+		{Name: "A.jpg", Type: "image"},
+		{Name: "a.txt", Type: "text"},
+	}
+
+	names := func(asc bool) []string {
+		listing := Listing{Items: append([]*FileInfo(nil), items...), Sorting: Sorting{By: "category", Asc: asc}}
+		listing.ApplySort()
+
+		var names []string
+		for _, item := range listing.Items {
+			names = append(names, item.Name)
+		}
+		return names
+	}
+
+	assert.Equal(t, []string{"docs", "A.jpg", "z.png", "clip.mp4", "song.mp3", "a.txt", "b.txt", "a.bin"}, names(true))
+	assert.Equal(t, []string{"a.bin", "a.txt", "b.txt", "song.mp3", "clip.mp4", "A.jpg", "z.png", "docs"}, names(false))
+}
@@ -0,0 +1,68 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package files
+
+import (
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestSpecialFiles(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, syscall.Mkfifo(filepath.Join(dir, "fifo.txt"), 0600))
+	fs := afero.NewBasePathFs(afero.NewOsFs(), dir)
+
+	// Nothing writes to the pipe, so reading it would block forever.
+	done := make(chan struct{})
+	var (
+		file, listing *FileInfo
+		err, dirErr   error
+	)
+	go func() {
+		defer close(done)
+		file, err = NewFileInfo(FileOptions{
+			Fs:         fs,
+			Path:       "/fifo.txt",
+			Expand:     true,
+			ReadHeader: true,
+			Checker:    testutil.AllowAll{},
+		})
+		listing, dirErr = NewFileInfo(FileOptions{
+			Fs:         fs,
+			Path:       "/",
+			Expand:     true,
+			ReadHeader: true,
+			Checker:    testutil.AllowAll{},
+			Columns:    []string{"icon", "checksum"},
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("previewing a named pipe blocked")
+	}
+
+	require.NoError(t, err)
+	require.True(t, file.IsSpecial)
+	require.Equal(t, "special", file.Type)
+	require.Empty(t, file.Content)
+	require.Equal(t, errors.ErrSpecialFile, file.Checksum("sha256"))
+	_, err = file.HexDump(1024)
+	require.Equal(t, errors.ErrSpecialFile, err)
+
+	require.NoError(t, dirErr)
+	require.Len(t, listing.Items, 1)
+	require.True(t, listing.Items[0].IsSpecial)
+	require.Equal(t, "special", listing.Items[0].Type)
+	require.Empty(t, listing.Items[0].Checksums)
+}
@@ -0,0 +1,59 @@
+package files
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"log"
+)
+
+// statHashSample is the number of bytes hashed by readStatHash at each end
+// of the files.
+const statHashSample = 4 << 10
+
+// readStatHash sets the modification time of the file to the nanosecond
+// and, for the regular files, the hash of their size, modification time
+// and first and last bytes. It is cheaper than a checksum, and tells a
+// change apart even when the filesystem only keeps the modification time
+// to the second, unless the size and both ends stayed the same.
+func (i *FileInfo) readStatHash() {
+	i.ModTimeNano = i.ModTime.UnixNano()
+	if i.IsDir || i.IsSpecial {
+		return
+	}
+
+	hash := sha256.New()
+	var stat [16]byte
+	binary.BigEndian.PutUint64(stat[:8], uint64(i.Size))
+	binary.BigEndian.PutUint64(stat[8:], uint64(i.ModTimeNano))
+	hash.Write(stat[:])
+
+	file, err := i.Fs.Open(i.Path)
+	if err != nil {
+		log.Printf("couldn't hash %s: %v", i.Path, err)
+		return
+	}
+	defer file.Close()
+
+	if _, err := io.CopyN(hash, file, statHashSample); err != nil && err != io.EOF {
+		log.Printf("couldn't hash %s: %v", i.Path, err)
+		return
+	}
+	if i.Size > statHashSample {
+		offset := i.Size - statHashSample
+		if offset < statHashSample {
+			offset = statHashSample
+		}
+		if _, err := file.Seek(offset, io.SeekStart); err != nil {
+			log.Printf("couldn't hash %s: %v", i.Path, err)
+			return
+		}
+		if _, err := io.Copy(hash, file); err != nil {
+			log.Printf("couldn't hash %s: %v", i.Path, err)
+			return
+		}
+	}
+
+	i.StatHash = hex.EncodeToString(hash.Sum(nil)[:16])
+}
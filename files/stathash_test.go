@@ -0,0 +1,68 @@
+package files
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestHashOnStat(t *testing.T) {
+	large := strings.Repeat("a", 3*statHashSample)
+	fs := testutil.NewFs(t, map[string]string{
+		"/docs/a.txt":     "hello",
+		"/docs/large.bin": large,
+		"/docs/sub/b.txt": "b",
+	})
+	second := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, fs.Chtimes("/docs/a.txt", second, second))
+	require.NoError(t, fs.Chtimes("/docs/large.bin", second, second))
+
+	stat := func(p string) *FileInfo {
+		file, err := NewFileInfo(FileOptions{Fs: fs, Path: p, Checker: testutil.AllowAll{}, HashOnStat: true})
+		require.NoError(t, err)
+		return file
+	}
+
+	file := stat("/docs/a.txt")
+	require.Equal(t, second.UnixNano(), file.ModTimeNano)
+	require.Len(t, file.StatHash, 32)
+	hash := file.StatHash
+	assert.Equal(t, hash, stat("/docs/a.txt").StatHash, "the hash is stable")
+
+	// Same size, same second: only the content tells the change.
+	require.NoError(t, afero.WriteFile(fs, "/docs/a.txt", []byte("jello"), 0644))
+	require.NoError(t, fs.Chtimes("/docs/a.txt", second, second))
+	assert.NotEqual(t, hash, stat("/docs/a.txt").StatHash)
+
+	hash = stat("/docs/large.bin").StatHash
+	tail := large[:len(large)-1] + "b"
+	require.NoError(t, afero.WriteFile(fs, "/docs/large.bin", []byte(tail), 0644))
+	require.NoError(t, fs.Chtimes("/docs/large.bin", second, second))
+	assert.NotEqual(t, hash, stat("/docs/large.bin").StatHash, "the end is hashed")
+
+	hash = stat("/docs/large.bin").StatHash
+	middle := tail[:len(tail)/2] + "c" + tail[len(tail)/2+1:]
+	require.NoError(t, afero.WriteFile(fs, "/docs/large.bin", []byte(middle), 0644))
+	require.NoError(t, fs.Chtimes("/docs/large.bin", second.Add(time.Nanosecond), second.Add(time.Nanosecond)))
+	assert.NotEqual(t, hash, stat("/docs/large.bin").StatHash, "the time is hashed to the nanosecond")
+
+	dir, err := NewFileInfo(FileOptions{Fs: fs, Path: "/docs", Checker: testutil.AllowAll{}, Expand: true, HashOnStat: true})
+	require.NoError(t, err)
+	assert.NotZero(t, dir.ModTimeNano)
+	assert.Empty(t, dir.StatHash)
+	for _, item := range dir.Items {
+		assert.Equal(t, item.ModTime.UnixNano(), item.ModTimeNano, item.Path)
+		assert.Equal(t, item.IsDir, item.StatHash == "", item.Path)
+	}
+
+	file, err = NewFileInfo(FileOptions{Fs: fs, Path: "/docs/a.txt", Checker: testutil.AllowAll{}})
+	require.NoError(t, err)
+	assert.Zero(t, file.ModTimeNano)
+	assert.Empty(t, file.StatHash)
+}
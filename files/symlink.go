@@ -0,0 +1,160 @@
+package files
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// CheckSymlinks makes sure that the real location of the file, once the
+// symbolic links are resolved, is inside of the root of the filesystem.
+// It returns os.ErrPermission if it isn't. Only OS filesystems scoped
+// with afero.BasePathFs are checked, others can't have escaping links.
+func CheckSymlinks(fs afero.Fs, fPath string) error {
+	if _, ok := fs.(*afero.BasePathFs); !ok {
+		return nil
+	}
+
+	root, ok := realPath(fs, "/")
+	if !ok {
+		return nil
+	}
+
+	p, ok := realPath(fs, fPath)
+	if !ok {
+		return os.ErrPermission
+	}
+
+	root, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return err
+	}
+
+	p, err = filepath.EvalSymlinks(p)
+	if os.IsNotExist(err) {
+		// Nothing to follow, the caller will find out it doesn't exist.
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	rel, err := filepath.Rel(root, p)
+	if err != nil {
+		return err
+	}
+
+	if rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return os.ErrPermission
+	}
+
+	return nil
+}
+
+// maxSymlinkDepth caps the number of links followed when resolving a
+// chain of symbolic links, like the kernel does.
+const maxSymlinkDepth = 40
+
+// SymlinkInfo describes where a chain of symbolic links leads. The paths
+// are relative to the root of the filesystem, and empty when they point
+// outside of it.
+type SymlinkInfo struct {
+	// Target is the immediate target of the link.
+	Target string `json:"target"`
+	// Resolved is the path the chain ends at, after Depth links.
+	Resolved string `json:"resolved"`
+	Depth    int    `json:"depth"`
+	IsDir    bool   `json:"isDir"`
+	// Outside is set when the chain leads outside of the root.
+	Outside bool `json:"outside,omitempty"`
+	// Broken is set when the chain leads to a file which doesn't exist.
+	Broken bool `json:"broken,omitempty"`
+	// Cycle is set when the chain loops.
+	Cycle bool `json:"cycle,omitempty"`
+	// DepthExceeded is set when the chain is longer than the maximum
+	// depth, Resolved being where it stopped.
+	DepthExceeded bool `json:"depthExceeded,omitempty"`
+}
+
+// ResolveSymlink follows the chain of symbolic links starting at the file,
+// up to maxDepth links, lstating each of them to detect cycles. It returns
+// nil if the file isn't a link or the filesystem isn't the OS one.
+func ResolveSymlink(fs afero.Fs, fPath string, maxDepth int) *SymlinkInfo {
+	if maxDepth > maxSymlinkDepth {
+		maxDepth = maxSymlinkDepth
+	}
+
+	root, ok := realPath(fs, "/")
+	if !ok {
+		return nil
+	}
+	current, ok := realPath(fs, fPath)
+	if !ok {
+		return nil
+	}
+
+	if info, err := os.Lstat(current); err != nil || !IsSymlink(info.Mode()) {
+		return nil
+	}
+
+	link := &SymlinkInfo{}
+	visited := map[string]bool{}
+	for {
+		info, err := os.Lstat(current)
+		if err != nil {
+			link.Broken = true
+			break
+		}
+		if !IsSymlink(info.Mode()) {
+			link.IsDir = info.IsDir()
+			break
+		}
+		if visited[current] {
+			link.Cycle = true
+			break
+		}
+		if link.Depth == maxDepth {
+			link.DepthExceeded = true
+			break
+		}
+		visited[current] = true
+
+		target, err := os.Readlink(current)
+		if err != nil {
+			link.Broken = true
+			break
+		}
+
+		if link.Depth == 0 {
+			link.Target = target
+			if filepath.IsAbs(target) {
+				link.Target, _ = scopedPath(root, target)
+			}
+		}
+
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(current), target)
+		}
+		current = filepath.Clean(target)
+		link.Depth++
+	}
+
+	link.Resolved, link.Outside = scopedPath(root, current)
+	return link
+}
+
+// scopedPath returns the path of the real path relative to the root, or
+// true if it is outside of it.
+func scopedPath(root, p string) (string, bool) {
+	rel, err := filepath.Rel(root, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		if evaluated, err := filepath.EvalSymlinks(root); err == nil && evaluated != root {
+			return scopedPath(evaluated, p)
+		}
+		return "", true
+	}
+	return path.Join("/", filepath.ToSlash(rel)), false
+}
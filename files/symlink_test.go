@@ -0,0 +1,140 @@
+package files
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestNewFileInfoSymlinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filebrowser")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "root")
+	outside := filepath.Join(dir, "outside")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "inside"), 0755))
+	require.NoError(t, os.MkdirAll(outside, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(root, "inside", "file.txt"), []byte("inside"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0644))
+
+	require.NoError(t, os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "secret.txt")))
+	require.NoError(t, os.Symlink(outside, filepath.Join(root, "outside")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "inside"), filepath.Join(root, "link")))
+
+	fs := afero.NewBasePathFs(afero.NewOsFs(), root)
+
+	testCases := map[string]struct {
+		path           string
+		followSymlinks bool
+		wantErr        error
+	}{
+		"link to file outside":           {path: "/secret.txt", wantErr: os.ErrPermission},
+		"link to dir outside":            {path: "/outside", wantErr: os.ErrPermission},
+		"file through link outside":      {path: "/outside/secret.txt", wantErr: os.ErrPermission},
+		"link inside":                    {path: "/link/file.txt"},
+		"regular file":                   {path: "/inside/file.txt"},
+		"link to file outside, followed": {path: "/secret.txt", followSymlinks: true},
+		"link to dir outside, followed":  {path: "/outside/secret.txt", followSymlinks: true},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			file, err := NewFileInfo(FileOptions{
+				Fs:             fs,
+				Path:           tt.path,
+				Expand:         true,
+				Checker:        testutil.AllowAll{},
+				FollowSymlinks: tt.followSymlinks,
+			})
+			if tt.wantErr != nil {
+				require.Equal(t, tt.wantErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotEmpty(t, file.Content)
+		})
+	}
+}
+
+func TestReadListingSymlinks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filebrowser")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "root")
+	require.NoError(t, os.MkdirAll(root, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0644))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "secret.txt"), filepath.Join(root, "secret.txt")))
+
+	file, err := NewFileInfo(FileOptions{
+		Fs:      afero.NewBasePathFs(afero.NewOsFs(), root),
+		Path:    "/",
+		Expand:  true,
+		Checker: testutil.AllowAll{},
+	})
+	require.NoError(t, err)
+	require.Len(t, file.Items, 1)
+
+	// The target outside of the root is not followed.
+	require.True(t, IsSymlink(file.Items[0].Mode))
+}
+
+func TestResolveSymlink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "filebrowser")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	root := filepath.Join(dir, "root")
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "real"), 0755))
+	require.NoError(t, os.Symlink("real", filepath.Join(root, "first")))
+	require.NoError(t, os.Symlink(filepath.Join(root, "first"), filepath.Join(root, "second")))
+	require.NoError(t, os.Symlink("second", filepath.Join(root, "third")))
+	require.NoError(t, os.Symlink("loop-b", filepath.Join(root, "loop-a")))
+	require.NoError(t, os.Symlink("loop-a", filepath.Join(root, "loop-b")))
+	require.NoError(t, os.Symlink("missing", filepath.Join(root, "broken")))
+	require.NoError(t, os.Symlink(dir, filepath.Join(root, "outside")))
+
+	fs := afero.NewBasePathFs(afero.NewOsFs(), root)
+
+	testCases := map[string]struct {
+		path     string
+		maxDepth int
+		want     *SymlinkInfo
+	}{
+		"chain": {
+			path: "/third", maxDepth: 10,
+			want: &SymlinkInfo{Target: "second", Resolved: "/real", Depth: 3, IsDir: true},
+		},
+		"depth exceeded": {
+			path: "/third", maxDepth: 2,
+			want: &SymlinkInfo{Target: "second", Resolved: "/first", Depth: 2, DepthExceeded: true},
+		},
+		"cycle": {
+			path: "/loop-a", maxDepth: 10,
+			want: &SymlinkInfo{Target: "loop-b", Resolved: "/loop-a", Depth: 2, Cycle: true},
+		},
+		"broken": {
+			path: "/broken", maxDepth: 10,
+			want: &SymlinkInfo{Target: "missing", Resolved: "/missing", Depth: 1, Broken: true},
+		},
+		"outside": {
+			path: "/outside", maxDepth: 10,
+			want: &SymlinkInfo{Depth: 1, IsDir: true, Outside: true},
+		},
+		"not a link": {path: "/real", maxDepth: 10},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.want, ResolveSymlink(fs, tc.path, tc.maxDepth))
+		})
+	}
+}
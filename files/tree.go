@@ -0,0 +1,91 @@
+package files
+
+// TreeNode is a file of a Tree. The directories which were listed have
+// their children, possibly none, the others have nil.
+type TreeNode struct {
+	Name     string      `json:"name"`
+	Path     string      `json:"path"`
+	IsDir    bool        `json:"isDir"`
+	Size     int64       `json:"size"`
+	Children []*TreeNode `json:"children,omitempty"`
+}
+
+// Tree is the nested listing of a directory and its subdirectories.
+type Tree struct {
+	*TreeNode
+	// Nodes is the number of nodes below the root.
+	Nodes int `json:"nodes"`
+	// Truncated is set when the tree stopped at the maximum number of
+	// nodes, leaving directories unlisted.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// NewTree returns the tree of the directory of the options, listed down
+// to depth levels, 1 being the directory itself, or all of them if zero.
+// The directories are listed breadth-first, so when the tree is capped to
+// maxNodes nodes the ones closest to the root are kept. The files are
+// the ones a listing of their directory has.
+func NewTree(opts FileOptions, depth, maxNodes int) (*Tree, error) {
+	opts.Expand = false
+	root, err := NewFileInfo(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Flatten = nil
+	opts.Columns = nil
+	opts.ShowChildCounts = false
+	opts.DirMTimeFromContents = false
+
+	tree := &Tree{TreeNode: newTreeNode(root)}
+	if !root.IsDir {
+		return tree, nil
+	}
+
+	type dir struct {
+		node  *TreeNode
+		depth int
+	}
+	queue := []dir{{node: tree.TreeNode, depth: 1}}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		sub := &FileInfo{Fs: root.Fs, Path: current.node.Path, textExtensions: root.textExtensions}
+		if err := sub.readListing(opts); err != nil {
+			if current.node == tree.TreeNode {
+				return nil, err
+			}
+			// An unreadable subdirectory is left unlisted.
+			continue
+		}
+
+		if maxNodes > 0 && tree.Nodes+len(sub.Listing.Items) > maxNodes {
+			tree.Truncated = true
+			break
+		}
+
+		descend := depth == 0 || current.depth < depth
+		current.node.Children = make([]*TreeNode, 0, len(sub.Listing.Items))
+		for _, item := range sub.Listing.Items {
+			node := newTreeNode(item)
+			current.node.Children = append(current.node.Children, node)
+			tree.Nodes++
+			if item.IsDir && descend {
+				queue = append(queue, dir{node: node, depth: current.depth + 1})
+			}
+		}
+	}
+
+	return tree, nil
+}
+
+func newTreeNode(file *FileInfo) *TreeNode {
+	return &TreeNode{
+		Name:  file.Name,
+		Path:  file.Path,
+		IsDir: file.IsDir,
+		Size:  file.Size,
+	}
+}
@@ -0,0 +1,76 @@
+package files
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestNewTree(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/root/a.txt":       "a",
+		"/root/b/c.txt":     "cc",
+		"/root/b/d/e.txt":   "e",
+		"/root/b/secret.db": "secret",
+		"/root/h/i.jpg":     "i",
+		"/root/empty/":      "",
+		"/root/.history":    "",
+	})
+
+	// paths lists the paths of the nodes depth-first, the directories
+	// left unlisted ending with "?".
+	var paths func(node *TreeNode) []string
+	paths = func(node *TreeNode) []string {
+		list := []string{}
+		for _, child := range node.Children {
+			p := child.Path
+			if child.IsDir && child.Children == nil {
+				p += "?"
+			}
+			list = append(list, p)
+			list = append(list, paths(child)...)
+		}
+		return list
+	}
+
+	testCases := map[string]struct {
+		depth, maxNodes int
+		paths           []string
+		truncated       bool
+	}{
+		"all": {
+			paths: []string{"/root/a.txt", "/root/b", "/root/b/c.txt", "/root/b/d", "/root/b/d/e.txt", "/root/empty", "/root/h", "/root/h/i.jpg"},
+		},
+		"depth": {
+			depth: 2,
+			paths: []string{"/root/a.txt", "/root/b", "/root/b/c.txt", "/root/b/d?", "/root/empty", "/root/h", "/root/h/i.jpg"},
+		},
+		"capped": {
+			maxNodes:  6,
+			paths:     []string{"/root/a.txt", "/root/b", "/root/b/c.txt", "/root/b/d?", "/root/empty", "/root/h?"},
+			truncated: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			tree, err := NewTree(FileOptions{
+				Fs: fs, Path: "/root", Checker: denyPaths{"/root/b/secret.db": true},
+			}, tc.depth, tc.maxNodes)
+			require.NoError(t, err)
+			assert.Equal(t, tc.paths, paths(tree.TreeNode))
+			assert.Equal(t, tc.truncated, tree.Truncated)
+			assert.Equal(t, len(tc.paths), tree.Nodes)
+		})
+	}
+
+	tree, err := NewTree(FileOptions{Fs: fs, Path: "/root", Checker: testutil.AllowAll{}}, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "root", tree.Name)
+	assert.Equal(t, 9, tree.Nodes, "the history file isn't in the tree")
+	assert.Equal(t, int64(1), tree.Children[0].Size)
+	assert.NotNil(t, tree.Children[2].Children, "the listed empty directories have no children")
+}
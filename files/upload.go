@@ -0,0 +1,58 @@
+package files
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+// UploadTempPrefix starts the names of the temporary files uploads are
+// written to before being moved into place. They are never listed.
+const UploadTempPrefix = ".upload-"
+
+// UploadTempPath returns a path, in the same directory as fPath, for the
+// temporary file of an upload to fPath. Being in the same directory, it
+// can be renamed into place atomically. The name of the file is truncated
+// so the one of the temporary file is at most nameMax bytes long.
+func UploadTempPath(fPath string, nameMax int) (string, error) {
+	random := make([]byte, 8)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	dir, name := path.Split(fPath)
+	prefix := UploadTempPrefix + hex.EncodeToString(random) + "-"
+	return path.Join(dir, prefix+truncateName(name, nameMax-len(prefix))), nil
+}
+
+// IsUploadTemp tells if the file name is the one of the temporary file
+// of an upload.
+func IsUploadTemp(name string) bool {
+	return strings.HasPrefix(name, UploadTempPrefix)
+}
+
+// RemoveUploadTemps removes the temporary files interrupted uploads left
+// under root and returns how many were removed.
+func RemoveUploadTemps(fs afero.Fs, root string) (int, error) {
+	removed := 0
+	err := afero.Walk(fs, root, func(fPath string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Unreadable files are skipped rather than stopping the cleanup.
+			return nil
+		}
+
+		if info.Mode().IsRegular() && IsUploadTemp(info.Name()) {
+			if err := fs.Remove(fPath); err != nil { //nolint:govet
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+
+	return removed, err
+}
@@ -0,0 +1,36 @@
+package files
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestRemoveUploadTemps(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/a.txt":                          "a",
+		"/.upload-0123456789abcdef-b":     "partial",
+		"/dir/.upload-fedcba9876543210-c": "partial",
+		"/dir/.uploaded":                  "not a temporary file",
+	})
+
+	removed, err := RemoveUploadTemps(fs, "/")
+	require.NoError(t, err)
+	require.Equal(t, 2, removed)
+	require.Equal(t, map[string]string{
+		"/a.txt": "a", "/dir/": "", "/dir/.uploaded": "not a temporary file",
+	}, testutil.Tree(t, fs))
+}
+
+func TestUploadTempPathLength(t *testing.T) {
+	name := strings.Repeat("é", 127)
+	tmpPath, err := UploadTempPath("/dir/"+name, 255)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(tmpPath, "/dir/"+UploadTempPrefix))
+	require.LessOrEqual(t, len(tmpPath)-len("/dir/"), 255)
+	require.True(t, utf8.ValidString(tmpPath))
+}
@@ -0,0 +1,31 @@
+package files
+
+import (
+	"net/url"
+	"path"
+	"strings"
+)
+
+// RawURL returns the URL of the raw content of the file, served by the
+// /api/raw endpoint under baseURL, with the given query parameters, such
+// as "inline" or "algo". The path is escaped and each parameter appears
+// once, even if set several times.
+func (i *FileInfo) RawURL(baseURL string, params url.Values) string {
+	p := path.Join("/", strings.TrimSuffix(baseURL, "/"), "/api/raw", i.Path)
+	if i.IsDir && !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+
+	u := url.URL{Path: p}
+	if len(params) > 0 {
+		query := url.Values{}
+		for key, values := range params {
+			if len(values) > 0 {
+				query.Set(key, values[len(values)-1])
+			}
+		}
+		u.RawQuery = query.Encode()
+	}
+
+	return u.String()
+}
@@ -0,0 +1,50 @@
+package files
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRawURL(t *testing.T) {
+	testCases := map[string]struct {
+		file    FileInfo
+		baseURL string
+		params  url.Values
+		want    string
+	}{
+		"file": {
+			file: FileInfo{Path: "/docs/a.txt"},
+			want: "/api/raw/docs/a.txt",
+		},
+		"base url": {
+			file: FileInfo{Path: "/docs/a.txt"}, baseURL: "/fb/",
+			want: "/fb/api/raw/docs/a.txt",
+		},
+		"escaped": {
+			file: FileInfo{Path: "/my docs/50% #1?.txt"},
+			want: "/api/raw/my%20docs/50%25%20%231%3F.txt",
+		},
+		"directory": {
+			file: FileInfo{Path: "/docs", IsDir: true},
+			want: "/api/raw/docs/",
+		},
+		"parameters": {
+			file:   FileInfo{Path: "/a.txt"},
+			params: url.Values{"inline": {"true"}, "algo": {"zip"}},
+			want:   "/api/raw/a.txt?algo=zip&inline=true",
+		},
+		"repeated parameter": {
+			file:   FileInfo{Path: "/a.txt"},
+			params: url.Values{"inline": {"false", "true"}},
+			want:   "/api/raw/a.txt?inline=true",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, tc.file.RawURL(tc.baseURL, tc.params))
+		})
+	}
+}
@@ -54,6 +54,13 @@ func IsNamedPipe(mode os.FileMode) bool {
 	return mode&os.ModeNamedPipe != 0
 }
 
+// IsSpecial tells whether the mode is the one of a device, named pipe or
+// socket, which can't be read like regular files: reading them may block
+// or never end.
+func IsSpecial(mode os.FileMode) bool {
+	return mode&(os.ModeDevice|os.ModeNamedPipe|os.ModeSocket) != 0
+}
+
 func IsSymlink(mode os.FileMode) bool {
 	return mode&os.ModeSymlink != 0
 }
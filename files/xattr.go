@@ -0,0 +1,56 @@
+package files
+
+import (
+	"encoding/hex"
+	"log"
+	"unicode/utf8"
+
+	"github.com/spf13/afero"
+)
+
+// ReadXattrs reads the extended attributes of the file into Xattrs. It
+// only works on filesystems backed by the OS. Failing to read them,
+// e.g. because the filesystem doesn't support them, is only logged.
+func (i *FileInfo) ReadXattrs() {
+	realPath, ok := realPath(i.Fs, i.Path)
+	if !ok {
+		return
+	}
+
+	attrs, err := readXattrs(realPath)
+	if err != nil {
+		log.Printf("couldn't read extended attributes of %s: %v", i.Path, err)
+		return
+	}
+
+	if len(attrs) == 0 {
+		return
+	}
+
+	i.Xattrs = map[string]string{}
+	for name, value := range attrs {
+		if utf8.Valid(value) {
+			i.Xattrs[name] = string(value)
+		} else {
+			i.Xattrs[name] = hex.EncodeToString(value)
+		}
+	}
+}
+
+// realPath returns the path of a file on the OS filesystem. It returns
+// false if the filesystem isn't on the OS one.
+func realPath(fs afero.Fs, fPath string) (string, bool) {
+	switch fs := fs.(type) {
+	case *afero.BasePathFs:
+		// Only the OS filesystem can lstat, others are e.g. S3.
+		if _, lstat, _ := fs.LstatIfPossible("/"); !lstat {
+			return "", false
+		}
+		p, err := fs.RealPath(fPath)
+		return p, err == nil
+	case *afero.OsFs:
+		return fPath, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package files
+
+// readXattrs is a no-op on platforms without extended attributes support.
+func readXattrs(string) (map[string][]byte, error) {
+	return nil, nil
+}
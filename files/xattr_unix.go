@@ -0,0 +1,62 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package files
+
+import (
+	"bytes"
+	"runtime"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// readXattrs returns the user extended attributes of a file. On Linux,
+// only the attributes in the "user." namespace are returned.
+func readXattrs(fPath string) (map[string][]byte, error) {
+	size, err := unix.Listxattr(fPath, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+
+	buf := make([]byte, size)
+	size, err = unix.Listxattr(fPath, buf)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := map[string][]byte{}
+	for _, name := range bytes.Split(buf[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+
+		key := string(name)
+		if runtime.GOOS == "linux" && !strings.HasPrefix(key, "user.") {
+			continue
+		}
+
+		value, err := getXattr(fPath, key)
+		if err != nil {
+			return nil, err
+		}
+		attrs[key] = value
+	}
+
+	return attrs, nil
+}
+
+func getXattr(fPath, name string) ([]byte, error) {
+	size, err := unix.Getxattr(fPath, name, nil)
+	if err != nil || size == 0 {
+		return nil, err
+	}
+
+	value := make([]byte, size)
+	size, err = unix.Getxattr(fPath, name, value)
+	if err != nil {
+		return nil, err
+	}
+
+	return value[:size], nil
+}
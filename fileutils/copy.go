@@ -1,6 +1,7 @@
 package fileutils
 
 import (
+	"context"
 	"os"
 	"path"
 
@@ -9,6 +10,12 @@ import (
 
 // Copy copies a file or folder from one place to another.
 func Copy(fs afero.Fs, src, dst string) error {
+	return CopyContext(context.Background(), fs, src, dst, nil)
+}
+
+// CopyContext is Copy, stopped when the context is done. The progress,
+// if not nil, is given the bytes copied as the copy goes.
+func CopyContext(ctx context.Context, fs afero.Fs, src, dst string, progress Progress) error {
 	if src = path.Clean("/" + src); src == "" {
 		return os.ErrNotExist
 	}
@@ -32,8 +39,8 @@ func Copy(fs afero.Fs, src, dst string) error {
 	}
 
 	if info.IsDir() {
-		return CopyDir(fs, src, dst)
+		return copyDir(ctx, fs, src, dst, progress)
 	}
 
-	return CopyFile(fs, src, dst)
+	return copyFile(ctx, fs, src, dst, progress)
 }
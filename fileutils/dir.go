@@ -1,6 +1,7 @@
 package fileutils
 
 import (
+	"context"
 	"errors"
 
 	"github.com/spf13/afero"
@@ -10,6 +11,10 @@ import (
 // of its sub-directories. It doesn't stop if it finds an error
 // during the copy. Returns an error if any.
 func CopyDir(fs afero.Fs, source, dest string) error {
+	return copyDir(context.Background(), fs, source, dest, nil)
+}
+
+func copyDir(ctx context.Context, fs afero.Fs, source, dest string, progress Progress) error {
 	// Get properties of source.
 	srcinfo, err := fs.Stat(source)
 	if err != nil {
@@ -31,18 +36,22 @@ func CopyDir(fs afero.Fs, source, dest string) error {
 	var errs []error
 
 	for _, obj := range obs {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
 		fsource := source + "/" + obj.Name()
 		fdest := dest + "/" + obj.Name()
 
 		if obj.IsDir() {
 			// Create sub-directories, recursively.
-			err = CopyDir(fs, fsource, fdest)
+			err = copyDir(ctx, fs, fsource, fdest, progress)
 			if err != nil {
 				errs = append(errs, err)
 			}
 		} else {
 			// Perform the file copy.
-			err = CopyFile(fs, fsource, fdest)
+			err = copyFile(ctx, fs, fsource, fdest, progress)
 			if err != nil {
 				errs = append(errs, err)
 			}
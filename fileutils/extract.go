@@ -0,0 +1,253 @@
+package fileutils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/rules"
+)
+
+// IsExtractable checks if the file can be extracted by Extract.
+func IsExtractable(name string) bool {
+	name = strings.ToLower(name)
+	for _, ext := range []string{".zip", ".tar", ".tar.gz", ".tgz"} {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExtractOptions are the options of Extract.
+type ExtractOptions struct {
+	// Checker allows the paths the entries are written to. The entries
+	// it denies are skipped. Everything is allowed if nil.
+	Checker rules.Checker
+	// Override overwrites the existing files, which are skipped otherwise.
+	Override bool
+}
+
+// Extraction lists the files written by Extract, and the entries it
+// skipped because they are denied or already exist.
+type Extraction struct {
+	Extracted []string `json:"extracted"`
+	Skipped   []string `json:"skipped"`
+}
+
+// Extract extracts the zip or tar(.gz) archive src into the directory dst.
+// Entries that would be written outside of dst make the whole extraction
+// fail with errors.ErrUnsafeArchivePath. Symbolic links are skipped.
+func Extract(fs afero.Fs, src, dst string, opts ExtractOptions) (*Extraction, error) {
+	dst = path.Clean("/" + dst)
+	x := &extractor{fs: fs, opts: opts, Extraction: &Extraction{Extracted: []string{}, Skipped: []string{}}}
+
+	if err := fs.MkdirAll(dst, 0775); err != nil {
+		return x.Extraction, err
+	}
+
+	file, err := fs.Open(src)
+	if err != nil {
+		return x.Extraction, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return x.Extraction, err
+	}
+
+	name := strings.ToLower(info.Name())
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		err = x.extractZip(file, info.Size(), dst)
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		gz, err := gzip.NewReader(file) //nolint:govet
+		if err != nil {
+			return x.Extraction, err
+		}
+		defer gz.Close()
+		err = x.extractTar(gz, dst)
+	case strings.HasSuffix(name, ".tar"):
+		err = x.extractTar(file, dst)
+	default:
+		err = errors.ErrInvalidOption
+	}
+	return x.Extraction, err
+}
+
+type extractor struct {
+	*Extraction
+	fs   afero.Fs
+	opts ExtractOptions
+}
+
+// allowed tells if the entry can be written to target, and else records
+// it as skipped.
+func (x *extractor) allowed(target string, isDir bool) bool {
+	if x.opts.Checker != nil && !x.opts.Checker.Check(target) {
+		x.Skipped = append(x.Skipped, target)
+		return false
+	}
+
+	if !isDir && !x.opts.Override {
+		if _, err := x.fs.Stat(target); !os.IsNotExist(err) {
+			x.Skipped = append(x.Skipped, target)
+			return false
+		}
+	}
+
+	return true
+}
+
+func (x *extractor) extractZip(src io.ReaderAt, size int64, dst string) error {
+	reader, err := zip.NewReader(src, size)
+	if err != nil {
+		return err
+	}
+
+	// Validate every entry before writing anything.
+	for _, f := range reader.File {
+		if _, err := archiveTarget(dst, f.Name); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range reader.File {
+		target, _ := archiveTarget(dst, f.Name)
+		mode := f.Mode()
+
+		switch {
+		case mode.IsDir():
+			if !x.allowed(target, true) {
+				continue
+			}
+			err = x.fs.MkdirAll(target, dirPerm(mode))
+		case mode&os.ModeSymlink != 0:
+			continue
+		case mode.IsRegular():
+			if !x.allowed(target, false) {
+				continue
+			}
+			err = extractZipFile(x.fs, f, target)
+			x.Extracted = append(x.Extracted, target)
+		default:
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func extractZipFile(fs afero.Fs, f *zip.File, target string) error {
+	reader, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	return writeArchiveFile(fs, reader, target, f.Mode())
+}
+
+func (x *extractor) extractTar(src io.Reader, dst string) error {
+	reader := tar.NewReader(src)
+
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Tar archives are read sequentially so entries are validated as
+		// they come. Anything already written stays inside dst.
+		target, err := archiveTarget(dst, header.Name)
+		if err != nil {
+			return err
+		}
+
+		mode := header.FileInfo().Mode()
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if !x.allowed(target, true) {
+				continue
+			}
+			err = x.fs.MkdirAll(target, dirPerm(mode))
+		case tar.TypeReg, tar.TypeRegA: //nolint:staticcheck
+			if !x.allowed(target, false) {
+				continue
+			}
+			err = writeArchiveFile(x.fs, reader, target, mode)
+			x.Extracted = append(x.Extracted, target)
+		default:
+			// Symbolic links, hard links and special files are skipped.
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// archiveTarget returns the path where an archive entry should be
+// written, making sure it stays inside of dst.
+func archiveTarget(dst, name string) (string, error) {
+	name = strings.Replace(name, "\\", "/", -1)
+	if path.IsAbs(name) {
+		return "", errors.ErrUnsafeArchivePath
+	}
+
+	for _, elem := range strings.Split(name, "/") {
+		if elem == ".." {
+			return "", errors.ErrUnsafeArchivePath
+		}
+	}
+
+	target := path.Join(dst, name)
+	if target != dst && !strings.HasPrefix(target, strings.TrimSuffix(dst, "/")+"/") {
+		return "", errors.ErrUnsafeArchivePath
+	}
+
+	return target, nil
+}
+
+func writeArchiveFile(fs afero.Fs, src io.Reader, target string, mode os.FileMode) error {
+	if err := fs.MkdirAll(path.Dir(target), 0775); err != nil {
+		return err
+	}
+
+	file, err := fs.OpenFile(target, os.O_RDWR|os.O_CREATE|os.O_TRUNC, filePerm(mode))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, src)
+	return err
+}
+
+// filePerm keeps the permission bits of an archived file, dropping
+// setuid, setgid and sticky bits and making sure the owner can
+// read and write it.
+func filePerm(mode os.FileMode) os.FileMode {
+	return mode.Perm() | 0600
+}
+
+func dirPerm(mode os.FileMode) os.FileMode {
+	return mode.Perm() | 0700
+}
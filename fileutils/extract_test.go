@@ -0,0 +1,151 @@
+package fileutils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+)
+
+func TestExtract(t *testing.T) {
+	testCases := map[string]struct {
+		archive string
+		entries []string
+		want    []string
+		wantErr error
+	}{
+		"zip": {
+			archive: "/archive.zip",
+			entries: []string{"a.txt", "dir/b.txt"},
+			want:    []string{"/dst/a.txt", "/dst/dir/b.txt"},
+		},
+		"tar": {
+			archive: "/archive.tar",
+			entries: []string{"a.txt", "dir/b.txt"},
+			want:    []string{"/dst/a.txt", "/dst/dir/b.txt"},
+		},
+		"zip parent traversal": {
+			archive: "/archive.zip",
+			entries: []string{"a.txt", "../evil.txt"},
+			wantErr: errors.ErrUnsafeArchivePath,
+		},
+		"zip nested traversal": {
+			archive: "/archive.zip",
+			entries: []string{"dir/../../evil.txt"},
+			wantErr: errors.ErrUnsafeArchivePath,
+		},
+		"zip absolute path": {
+			archive: "/archive.zip",
+			entries: []string{"/evil.txt"},
+			wantErr: errors.ErrUnsafeArchivePath,
+		},
+		"zip windows traversal": {
+			archive: "/archive.zip",
+			entries: []string{"..\\evil.txt"},
+			wantErr: errors.ErrUnsafeArchivePath,
+		},
+		"tar parent traversal": {
+			archive: "/archive.tar",
+			entries: []string{"../evil.txt"},
+			wantErr: errors.ErrUnsafeArchivePath,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			writeTestArchive(t, fs, tt.archive, tt.entries)
+
+			got, err := Extract(fs, tt.archive, "/dst", ExtractOptions{})
+			if tt.wantErr != nil {
+				require.Equal(t, tt.wantErr, err)
+
+				exists, err := afero.Exists(fs, "/evil.txt")
+				require.NoError(t, err)
+				require.False(t, exists)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got.Extracted)
+			require.Empty(t, got.Skipped)
+			for _, p := range tt.want {
+				content, err := afero.ReadFile(fs, p)
+				require.NoError(t, err)
+				require.Equal(t, "content", string(content))
+			}
+		})
+	}
+}
+
+// denyPrefix is a rules.Checker denying the paths under a directory.
+type denyPrefix string
+
+func (p denyPrefix) Check(path string) bool {
+	return path != string(p) && !strings.HasPrefix(path, string(p)+"/")
+}
+
+func TestExtractSkips(t *testing.T) {
+	for _, archive := range []string{"/archive.zip", "/archive.tar"} {
+		t.Run(archive, func(t *testing.T) {
+			fs := afero.NewMemMapFs()
+			writeTestArchive(t, fs, archive, []string{"a.txt", "b.txt", "private/c.txt"})
+			require.NoError(t, afero.WriteFile(fs, "/dst/b.txt", []byte("mine"), 0644))
+
+			opts := ExtractOptions{Checker: denyPrefix("/dst/private")}
+			got, err := Extract(fs, archive, "/dst", opts)
+			require.NoError(t, err)
+			require.Equal(t, []string{"/dst/a.txt"}, got.Extracted)
+			require.Equal(t, []string{"/dst/b.txt", "/dst/private/c.txt"}, got.Skipped)
+
+			content, err := afero.ReadFile(fs, "/dst/b.txt")
+			require.NoError(t, err)
+			require.Equal(t, "mine", string(content), "existing files are kept")
+			exists, err := afero.Exists(fs, "/dst/private")
+			require.NoError(t, err)
+			require.False(t, exists, "denied directories aren't created")
+
+			opts.Override = true
+			got, err = Extract(fs, archive, "/dst", opts)
+			require.NoError(t, err)
+			require.Equal(t, []string{"/dst/a.txt", "/dst/b.txt"}, got.Extracted)
+			require.Equal(t, []string{"/dst/private/c.txt"}, got.Skipped)
+			content, err = afero.ReadFile(fs, "/dst/b.txt")
+			require.NoError(t, err)
+			require.Equal(t, "content", string(content))
+		})
+	}
+}
+
+func writeTestArchive(t *testing.T, fs afero.Fs, name string, entries []string) {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	if name == "/archive.zip" {
+		zw := zip.NewWriter(buf)
+		for _, entry := range entries {
+			w, err := zw.Create(entry)
+			require.NoError(t, err)
+			_, err = w.Write([]byte("content"))
+			require.NoError(t, err)
+		}
+		require.NoError(t, zw.Close())
+	} else {
+		tw := tar.NewWriter(buf)
+		for _, entry := range entries {
+			err := tw.WriteHeader(&tar.Header{Name: entry, Mode: 0644, Size: 7, Typeflag: tar.TypeReg})
+			require.NoError(t, err)
+			_, err = tw.Write([]byte("content"))
+			require.NoError(t, err)
+		}
+		require.NoError(t, tw.Close())
+	}
+
+	require.NoError(t, afero.WriteFile(fs, name, buf.Bytes(), 0644))
+}
@@ -1,6 +1,7 @@
 package fileutils
 
 import (
+	"context"
 	"io"
 	"os"
 	"path"
@@ -13,11 +14,17 @@ import (
 // By default the rename filesystem system call is used. If src and dst point to different volumes
 // the file copy is used as a fallback
 func MoveFile(fs afero.Fs, src, dst string) error {
+	return MoveFileContext(context.Background(), fs, src, dst, nil)
+}
+
+// MoveFileContext is MoveFile, whose fallback copy is stopped when the
+// context is done and gives the bytes copied to the progress, if not nil.
+func MoveFileContext(ctx context.Context, fs afero.Fs, src, dst string, progress Progress) error {
 	if fs.Rename(src, dst) == nil {
 		return nil
 	}
 	// fallback
-	err := CopyFile(fs, src, dst)
+	err := copyFile(ctx, fs, src, dst, progress)
 	if err != nil {
 		_ = fs.Remove(dst)
 		return err
@@ -31,6 +38,10 @@ func MoveFile(fs afero.Fs, src, dst string) error {
 // CopyFile copies a file from source to dest and returns
 // an error if any.
 func CopyFile(fs afero.Fs, source, dest string) error {
+	return copyFile(context.Background(), fs, source, dest, nil)
+}
+
+func copyFile(ctx context.Context, fs afero.Fs, source, dest string, progress Progress) error {
 	// Open the source file.
 	src, err := fs.Open(source)
 	if err != nil {
@@ -53,7 +64,7 @@ func CopyFile(fs afero.Fs, source, dest string) error {
 	defer dst.Close()
 
 	// Copy the contents of the file.
-	_, err = io.Copy(dst, src)
+	_, err = io.Copy(&progressWriter{ctx: ctx, w: dst, progress: progress}, src)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,82 @@
+package fileutils
+
+import (
+	"sort"
+	"sync"
+)
+
+// Locker provides advisory locks on paths so operations changing the
+// same files are serialized instead of being interleaved.
+type Locker struct {
+	mu    sync.Mutex
+	locks map[string]*pathLock
+}
+
+type pathLock struct {
+	sync.Mutex
+	refs int
+}
+
+// NewLocker creates a new Locker.
+func NewLocker() *Locker {
+	return &Locker{locks: map[string]*pathLock{}}
+}
+
+// Lock locks the given paths, waiting for them to be unlocked if needed,
+// and returns the function unlocking them. Paths are always locked in the
+// same order so two operations on the same paths can't deadlock.
+func (l *Locker) Lock(paths ...string) (unlock func()) {
+	paths = uniquePaths(paths)
+
+	locks := make([]*pathLock, 0, len(paths))
+	for _, p := range paths {
+		lock := l.acquire(p)
+		lock.Lock()
+		locks = append(locks, lock)
+	}
+
+	return func() {
+		for i := len(locks) - 1; i >= 0; i-- {
+			locks[i].Unlock()
+			l.release(paths[i])
+		}
+	}
+}
+
+func (l *Locker) acquire(p string) *pathLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock, ok := l.locks[p]
+	if !ok {
+		lock = &pathLock{}
+		l.locks[p] = lock
+	}
+	lock.refs++
+	return lock
+}
+
+func (l *Locker) release(p string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lock := l.locks[p]
+	lock.refs--
+	if lock.refs == 0 {
+		delete(l.locks, p)
+	}
+}
+
+func uniquePaths(paths []string) []string {
+	unique := make([]string, 0, len(paths))
+	seen := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		if !seen[p] {
+			seen[p] = true
+			unique = append(unique, p)
+		}
+	}
+
+	sort.Strings(unique)
+	return unique
+}
@@ -0,0 +1,78 @@
+package fileutils
+
+import (
+	"bytes"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockerConcurrentWriters(t *testing.T) {
+	const (
+		writers = 8
+		chunks  = 50
+	)
+
+	fs := afero.NewMemMapFs()
+	locker := NewLocker()
+
+	write := func(content byte) {
+		defer locker.Lock("/file.txt")()
+
+		file, err := fs.OpenFile("/file.txt", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer file.Close()
+
+		// Writing in chunks gives the other writers the chance to
+		// interleave their writes if the lock doesn't serialize them.
+		for i := 0; i < chunks; i++ {
+			_, err := file.Write(bytes.Repeat([]byte{content}, 16))
+			assert.NoError(t, err)
+			runtime.Gosched()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(content byte) {
+			defer wg.Done()
+			write(content)
+		}(byte('a' + i))
+	}
+	wg.Wait()
+
+	content, err := afero.ReadFile(fs, "/file.txt")
+	require.NoError(t, err)
+	require.Len(t, content, chunks*16)
+	require.Equal(t, strings.Repeat(string(content[0]), chunks*16), string(content))
+}
+
+func TestLockerMultiplePaths(t *testing.T) {
+	locker := NewLocker()
+
+	// Locking the same paths in different orders must not deadlock.
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			defer locker.Lock("/a", "/b")()
+		}()
+		go func() {
+			defer wg.Done()
+			defer locker.Lock("/b", "/a", "/b")()
+		}()
+	}
+	wg.Wait()
+
+	require.Empty(t, locker.locks)
+}
@@ -0,0 +1,48 @@
+package fileutils
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/spf13/afero"
+)
+
+// Progress is given the number of bytes copied each time a copy writes.
+type Progress func(n int64)
+
+// progressWriter reports the bytes written to the progress, and stops
+// writing once the context is done.
+type progressWriter struct {
+	ctx      context.Context
+	w        io.Writer
+	progress Progress
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	n, err := w.w.Write(p)
+	if w.progress != nil && n > 0 {
+		w.progress(int64(n))
+	}
+	return n, err
+}
+
+// Size returns the size of the file, or the total size of the files
+// inside the directory.
+func Size(fs afero.Fs, name string) (int64, error) {
+	var size int64
+	err := afero.Walk(fs, name, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
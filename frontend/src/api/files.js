@@ -1,11 +1,11 @@
-import { fetchURL, removePrefix } from './utils'
+import { fetchURL, fetchJSON, removePrefix } from './utils'
 import { baseURL } from '@/utils/constants'
 import store from '@/store'
 
-export async function fetch (url) {
+export async function fetch (url, query = '') {
   url = removePrefix(url)
 
-  const res = await fetchURL(`/api/resources${url}`, {})
+  const res = await fetchURL(`/api/resources${url}${query}`, {})
 
   if (res.status === 200) {
     let data = await res.json()
@@ -15,7 +15,8 @@ export async function fetch (url) {
       if (!data.url.endsWith('/')) data.url += '/'
       data.items = data.items.map((item, index) => {
         item.index = index
-        item.url = `${data.url}${encodeURIComponent(item.name)}`
+        // The names of flattened listings are relative paths.
+        item.url = `${data.url}${item.name.split('/').map(encodeURIComponent).join('/')}`
 
         if (item.isDir) {
           item.url += '/'
@@ -31,6 +32,10 @@ export async function fetch (url) {
   }
 }
 
+export async function tree (url, depth = 0) {
+  return fetchJSON(`/api/resources${removePrefix(url)}?tree=true&depth=${depth}`, {})
+}
+
 async function resourceAction (url, method, content) {
   url = removePrefix(url)
 
@@ -82,6 +87,41 @@ export function download (format, ...files) {
   window.open(url)
 }
 
+export async function manifest (...files) {
+  let url = '/api/manifest'
+
+  if (files.length === 1) {
+    url += removePrefix(files[0])
+  } else {
+    const arg = files.map(file => removePrefix(file)).join(',')
+    url += `/?files=${encodeURIComponent(arg)}`
+  }
+
+  return fetchJSON(url)
+}
+
+export async function versions (url) {
+  return fetchJSON(`/api/versions${removePrefix(url)}`)
+}
+
+export function versionUrl (url, version) {
+  return `${baseURL}/api/raw${removePrefix(url)}?version=${encodeURIComponent(version)}&auth=${store.state.jwt}`
+}
+
+export async function select (url, glob = '') {
+  return fetchJSON(`/api/select${removePrefix(url)}?glob=${encodeURIComponent(glob)}`)
+}
+
+export async function fetchRemote (url, remote, name = '', overwrite = false) {
+  let query = `?url=${encodeURIComponent(remote)}&override=${overwrite}`
+  if (name) query += `&name=${encodeURIComponent(name)}`
+  return fetchJSON(`/api/fetch${removePrefix(url)}${query}`, { method: 'POST' })
+}
+
+export async function createSymlink (url, target) {
+  return fetchJSON(`/api/symlink${removePrefix(url)}?target=${encodeURIComponent(target)}`, { method: 'POST' })
+}
+
 export async function post (url, content = '', overwrite = false, onupload) {
   url = removePrefix(url)
 
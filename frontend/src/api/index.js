@@ -2,6 +2,7 @@ import * as files from './files'
 import * as share from './share'
 import * as users from './users'
 import * as settings from './settings'
+import * as transfers from './transfers'
 import search from './search'
 import commands from './commands'
 
@@ -10,6 +11,7 @@ export {
   share,
   users,
   settings,
+  transfers,
   commands,
   search
 }
@@ -0,0 +1,29 @@
+import { fetchURL, fetchJSON, removePrefix } from './utils'
+
+export async function start (item, copy = false, overwrite = false, rename = false) {
+  const from = removePrefix(item.from)
+  const to = encodeURIComponent(removePrefix(item.to))
+  const url = `/api/resources${from}?action=${copy ? 'copy' : 'rename'}&destination=${to}&override=${overwrite}&rename=${rename}&async=true`
+
+  const res = await fetchURL(url, { method: 'PATCH' })
+  if (res.status !== 202) {
+    throw new Error(await res.text())
+  }
+
+  return res.json()
+}
+
+export async function list () {
+  return fetchJSON('/api/transfers')
+}
+
+export async function get (id) {
+  return fetchJSON(`/api/transfers/${id}`)
+}
+
+export async function cancel (id) {
+  const res = await fetchURL(`/api/transfers/${id}`, { method: 'DELETE' })
+  if (res.status !== 200) {
+    throw new Error(res.status)
+  }
+}
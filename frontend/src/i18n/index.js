@@ -1,5 +1,6 @@
 import Vue from 'vue'
 import VueI18n from 'vue-i18n'
+import { locale as requestLocale } from '@/utils/constants'
 
 import ar from './ar.json'
 import de from './de.json'
@@ -23,6 +24,10 @@ import zhTW from './zh-tw.json'
 Vue.use(VueI18n)
 
 export function detectLocale () {
+  // The server picks the locale from the lang parameter or the
+  // Accept-Language header, among the offered ones.
+  if (requestLocale) return requestLocale
+
   let locale = (navigator.language || navigator.browserLangugae).toLowerCase()
   switch (true) {
     case /^ar.*/i.test(locale):
@@ -1,5 +1,6 @@
 import * as i18n from '@/i18n'
 import moment from 'moment'
+import { localeExplicit, locales } from '@/utils/constants'
 
 const mutations = {
   closeHovers: state => {
@@ -37,7 +38,9 @@ const mutations = {
 
     let locale = value.locale
 
-    if (locale === '') {
+    // A lang parameter, or a locale no longer offered, overrides the one
+    // of the user.
+    if (locale === '' || localeExplicit || (locales && !locales.includes(locale))) {
       locale = i18n.detectLocale()
     }
 
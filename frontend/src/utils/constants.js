@@ -14,6 +14,12 @@ const theme = window.FileBrowser.Theme
 const enableThumbs = window.FileBrowser.EnableThumbs
 const resizePreview = window.FileBrowser.ResizePreview
 const enableExec = window.FileBrowser.EnableExec
+const officePreview = window.FileBrowser.OfficePreview
+const pdfThumbs = window.FileBrowser.PDFThumbs
+const listingColumns = window.FileBrowser.ListingColumns || ['icon', 'name', 'size', 'modified']
+const locale = window.FileBrowser.Locale
+const localeExplicit = window.FileBrowser.LocaleExplicit
+const locales = window.FileBrowser.Locales
 
 export {
   name,
@@ -30,5 +36,11 @@ export {
   theme,
   enableThumbs,
   resizePreview,
-  enableExec
+  enableExec,
+  officePreview,
+  pdfThumbs,
+  listingColumns,
+  locale,
+  localeExplicit,
+  locales
 }
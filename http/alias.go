@@ -0,0 +1,30 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+// withAlias resolves the aliases of the request path, see Server.Aliases,
+// so the handler serves the files of the path it stands for. The aliased
+// path is kept in data.aliased for the listings to link under it.
+func withAlias(fn handleFunc) handleFunc {
+	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if len(d.server.Aliases) == 0 {
+			return fn(w, r, d)
+		}
+
+		resolved, err := files.Aliases(d.server.Aliases).Resolve(r.URL.Path)
+		if err != nil {
+			return errToStatus(err), err
+		}
+		if resolved != r.URL.Path {
+			d.aliased = r.URL.Path
+			r.URL.Path = resolved
+			r.URL.RawPath = ""
+		}
+
+		return fn(w, r, d)
+	}
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/archivefs"
+)
+
+const archivePasswordHeader = "X-Archive-Password"
+
+// tarIndexes keeps the indexes of the tar archives browsed recently.
+var tarIndexes = archivefs.NewIndexCache(tarIndexCacheSize)
+
+const tarIndexCacheSize = 32
+
+// mountArchive checks if the request path goes through a zip or tar
+// archive, such as "/docs/archive.zip/dir/file.txt". If it does, the
+// user of d is replaced by a copy whose filesystem exposes the contents
+// of the archive, read-only. The returned function must be called to
+// close the archive.
+func mountArchive(r *http.Request, d *data) (func(), error) {
+	archive := findArchive(d, r.URL.Path)
+	if archive == "" {
+		return func() {}, nil
+	}
+
+	var (
+		fs  *archivefs.Fs
+		err error
+	)
+	if archivefs.IsTar(archive) {
+		fs, err = archivefs.NewTar(d.user.Fs, archive, tarIndexes, archiveKey(d, archive))
+	} else {
+		fs, err = archivefs.NewZip(d.user.Fs, archive, r.Header.Get(archivePasswordHeader))
+	}
+	if err != nil {
+		return func() {}, err
+	}
+
+	user := *d.user
+	user.Fs = fs
+	user.Perm.Create = false
+	user.Perm.Rename = false
+	user.Perm.Modify = false
+	user.Perm.Delete = false
+	d.user = &user
+
+	return func() { _ = fs.Close() }, nil
+}
+
+// archiveKey identifies the archive among the ones of all the users.
+func archiveKey(d *data, archive string) string {
+	if _, ok := d.user.Fs.(*afero.BasePathFs); ok {
+		return d.user.FullPath(archive)
+	}
+	return ""
+}
+
+// inArchive tells if the path is inside of an archive. Archives are
+// read-only, so these paths can't be written to.
+func inArchive(d *data, fPath string) bool {
+	return findArchive(d, fPath) != ""
+}
+
+// findArchive returns the path of the zip or tar archive the given path
+// goes through, if any. A path pointing at the archive itself only refers to
+// its contents if it ends with a slash.
+func findArchive(d *data, fPath string) string {
+	elems := strings.Split(strings.Trim(fPath, "/"), "/")
+	current := "/"
+
+	for i, elem := range elems {
+		current = path.Join(current, elem)
+		if !strings.HasSuffix(strings.ToLower(elem), ".zip") && !archivefs.IsTar(elem) {
+			continue
+		}
+
+		if i == len(elems)-1 && !strings.HasSuffix(fPath, "/") {
+			return ""
+		}
+
+		info, err := d.user.Fs.Stat(current)
+		if err != nil {
+			return ""
+		}
+
+		if !info.IsDir() {
+			return current
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+func TestTarArchive(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "dir/a.txt", Typeflag: tar.TypeReg, Mode: 0644, Size: 5}))
+	_, err := tw.Write([]byte("alpha"))
+	require.NoError(t, err)
+	require.NoError(t, tw.Close())
+
+	d := newTestData(t, map[string]string{"/data.tar": buf.String()})
+	user := d.user
+
+	w := httptest.NewRecorder()
+	status, err := resourceGet(w, httptest.NewRequest(http.MethodGet, "/data.tar/dir/", nil), d, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, status)
+
+	var listing files.FileInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listing))
+	require.Len(t, listing.Items, 1)
+	require.Equal(t, "a.txt", listing.Items[0].Name)
+
+	d.user = user
+	w = httptest.NewRecorder()
+	_, err = resourceGet(w, httptest.NewRequest(http.MethodGet, "/data.tar/dir/a.txt", nil), d, nil)
+	require.NoError(t, err)
+	var file files.FileInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &file))
+	require.Equal(t, "alpha", file.Content)
+
+	// Archives are read-only.
+	d.user = user
+	r := httptest.NewRequest(http.MethodPut, "/data.tar/dir/a.txt", strings.NewReader("changed"))
+	status, _ = resourcePostPut(httptest.NewRecorder(), r, d)
+	require.Equal(t, http.StatusMethodNotAllowed, status)
+
+	status, _ = deleteResource(context.Background(), d, nil, "/data.tar/dir/a.txt")
+	require.Equal(t, http.StatusMethodNotAllowed, status)
+
+	status, _ = patchResource(d, "rename", "/data.tar/dir/a.txt", "/a.txt", false, false)
+	require.Equal(t, http.StatusMethodNotAllowed, status)
+
+	require.Equal(t, map[string]string{"/data.tar": buf.String()}, fileTree(t, d))
+}
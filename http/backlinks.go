@@ -0,0 +1,41 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+// backlinksHandler reports the symbolic links of the scope of the user
+// pointing to the request path, such as before deleting it. The "depth"
+// parameter limits how many directory levels are walked, at most the
+// configured maximum.
+var backlinksHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if !d.Check(r.URL.Path) {
+		return http.StatusForbidden, nil
+	}
+
+	depth := -1
+	if d.server.BacklinksMaxDepth > 0 {
+		depth = d.server.BacklinksMaxDepth
+	}
+	if value := r.URL.Query().Get("depth"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return http.StatusBadRequest, fmt.Errorf("invalid depth %q: %w", value, errors.ErrInvalidRequestParams)
+		}
+		if depth < 0 || n < depth {
+			depth = n
+		}
+	}
+
+	links, err := files.Backlinks(r.Context(), d.user.Fs, "/", r.URL.Path, depth, d)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return renderJSON(w, r, links)
+})
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+type batchOperation struct {
+	Op     string      `json:"op"`
+	Params batchParams `json:"params"`
+}
+
+type batchParams struct {
+	Path        string `json:"path"`
+	Destination string `json:"destination"`
+	Override    bool   `json:"override"`
+	Rename      bool   `json:"rename"`
+}
+
+type batchResult struct {
+	Status int         `json:"status"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+func batchHandler(fileCache FileCache) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		return batch(w, r, d, fileCache)
+	})
+}
+
+func batch(w http.ResponseWriter, r *http.Request, d *data, fileCache FileCache) (int, error) {
+	ops, err := decodeBatch(r.Body, d.server.MaxBatchSize)
+	if err == errors.ErrBatchTooLarge {
+		return http.StatusRequestEntityTooLarge, nil
+	}
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	results := make([]batchResult, 0, len(ops))
+	for _, op := range ops {
+		data, status, err := runBatchOperation(r, d, fileCache, op)
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		result := batchResult{Status: status, Data: data}
+		if err != nil {
+			result.Error = err.Error()
+		} else if status >= 400 {
+			result.Error = http.StatusText(status)
+		}
+
+		results = append(results, result)
+	}
+
+	return renderJSON(w, r, results)
+}
+
+// decodeBatch decodes the array of operations of body one at a time, so
+// the batches over max operations are refused without reading the rest
+// of them. Zero doesn't limit the number of operations.
+func decodeBatch(body io.Reader, max int) ([]batchOperation, error) {
+	dec := json.NewDecoder(body)
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('[') {
+		return nil, fmt.Errorf("the batch isn't an array: %w", errors.ErrInvalidRequestParams)
+	}
+
+	ops := []batchOperation{}
+	for dec.More() {
+		if max > 0 && len(ops) == max {
+			return nil, errors.ErrBatchTooLarge
+		}
+
+		var op batchOperation
+		if err := dec.Decode(&op); err != nil {
+			return nil, err
+		}
+		ops = append(ops, op)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// runBatchOperation runs a single operation of a batch. Operations are
+// run against the requesting user the same way a standalone request is.
+func runBatchOperation(r *http.Request, d *data, fileCache FileCache, op batchOperation) (interface{}, int, error) {
+	p := op.Params
+	if p.Path == "" {
+		return nil, http.StatusBadRequest, errors.ErrInvalidRequestParams
+	}
+
+	// TODO: use enum
+	switch op.Op {
+	case "stat", "list":
+		file, err := files.NewFileInfo(files.FileOptions{
+			Fs:             d.user.Fs,
+			Path:           p.Path,
+			Modify:         d.user.Perm.Modify,
+			Expand:         op.Op == "list",
+			ReadHeader:     d.server.TypeDetectionByHeader,
+			Checker:        d,
+			FollowSymlinks: d.server.FollowSymlinks,
+			Locale:         d.server.Locale,
+		})
+		if err != nil {
+			return nil, errToStatus(err), err
+		}
+
+		if file.IsDir && file.Listing != nil {
+			file.Listing.Sorting = d.user.Sorting
+			file.Listing.ApplySort()
+		}
+
+		return file, http.StatusOK, nil
+	case "delete":
+		status, err := deleteResource(r.Context(), d, fileCache, p.Path)
+		return nil, status, err
+	case "rename", "copy":
+		status, err := patchResource(d, op.Op, p.Path, p.Destination, p.Override, p.Rename)
+		return nil, status, err
+	default:
+		return nil, http.StatusBadRequest, fmt.Errorf("unsupported operation %s: %w", op.Op, errors.ErrInvalidRequestParams)
+	}
+}
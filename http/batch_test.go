@@ -0,0 +1,99 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/diskcache"
+)
+
+func runBatch(t *testing.T, d *data, body string) ([]batchResult, int) {
+	t.Helper()
+
+	r := httptest.NewRequest(http.MethodPost, "/api/batch", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	status, _ := batch(w, r, d, diskcache.NewNoOp())
+	if status != 0 {
+		return nil, status
+	}
+
+	var results []batchResult
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &results))
+	return results, status
+}
+
+func TestBatch(t *testing.T) {
+	d := newTestData(t, map[string]string{
+		"/a.txt":     "a",
+		"/dir/b.txt": "b",
+	})
+	d.user.Perm.Delete = true
+
+	results, status := runBatch(t, d, `[
+		{"op": "stat", "params": {"path": "/a.txt"}},
+		{"op": "copy", "params": {"path": "/a.txt", "destination": "/dir/a.txt"}},
+		{"op": "copy", "params": {"path": "/a.txt", "destination": "/dir/b.txt"}},
+		{"op": "delete", "params": {"path": "/missing.txt"}},
+		{"op": "delete", "params": {"path": "/a.txt"}},
+		{"op": "list", "params": {"path": "/dir"}},
+		{"op": "chmod", "params": {"path": "/dir"}},
+		{"op": "stat", "params": {}}
+	]`)
+	require.Equal(t, 0, status)
+
+	statuses := make([]int, 0, len(results))
+	for _, result := range results {
+		statuses = append(statuses, result.Status)
+		if result.Status >= 400 {
+			require.NotEmpty(t, result.Error)
+		}
+	}
+	require.Equal(t, []int{
+		http.StatusOK,
+		http.StatusOK,
+		http.StatusConflict,
+		http.StatusNotFound,
+		http.StatusOK,
+		http.StatusOK,
+		http.StatusBadRequest,
+		http.StatusBadRequest,
+	}, statuses)
+
+	// The operations run in order, so the list sees the copy.
+	listing := results[5].Data.(map[string]interface{})["items"].([]interface{})
+	require.Len(t, listing, 2)
+
+	require.Equal(t, map[string]string{
+		"/dir/":      "",
+		"/dir/a.txt": "a",
+		"/dir/b.txt": "b",
+	}, fileTree(t, d))
+}
+
+func TestBatchSize(t *testing.T) {
+	d := newTestData(t, map[string]string{"/a.txt": "a"})
+	d.server.MaxBatchSize = 2
+
+	stat := `{"op": "stat", "params": {"path": "/a.txt"}}`
+
+	results, status := runBatch(t, d, "["+stat+","+stat+"]")
+	require.Equal(t, 0, status)
+	require.Len(t, results, 2)
+
+	// The operations over the limit aren't decoded.
+	_, status = runBatch(t, d, "["+stat+","+stat+","+stat+", not json")
+	require.Equal(t, http.StatusRequestEntityTooLarge, status)
+
+	_, status = runBatch(t, d, `{"op": "stat"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+
+	d.server.MaxBatchSize = 0
+	results, status = runBatch(t, d, "["+stat+","+stat+","+stat+"]")
+	require.Equal(t, 0, status)
+	require.Len(t, results, 3)
+}
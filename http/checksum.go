@@ -0,0 +1,93 @@
+package http
+
+import (
+	"bytes"
+	"crypto/md5"  //nolint:gosec
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/filebrowser/filebrowser/v2/checksums"
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+// checksumHeaders are the headers uploads can carry the expected checksum
+// of their content with, in hex, by order of preference.
+var checksumHeaders = []struct {
+	name string
+	new  func() hash.Hash
+}{
+	{"X-Content-SHA256", sha256.New},
+	{"X-Content-SHA1", sha1.New},
+	{"X-Content-MD5", md5.New},
+}
+
+// uploadChecksum is the checksum an upload is expected to have.
+type uploadChecksum struct {
+	hash.Hash
+	expected []byte
+}
+
+// parseUploadChecksum returns the checksum expected by the request, or nil
+// if it doesn't expect any.
+func parseUploadChecksum(r *http.Request) (*uploadChecksum, error) {
+	for _, header := range checksumHeaders {
+		value := strings.TrimSpace(r.Header.Get(header.name))
+		if value == "" {
+			continue
+		}
+
+		expected, err := hex.DecodeString(value)
+		h := header.new()
+		if err != nil || len(expected) != h.Size() {
+			return nil, fmt.Errorf("invalid %s header: %w", header.name, errors.ErrInvalidRequestParams)
+		}
+
+		return &uploadChecksum{Hash: h, expected: expected}, nil
+	}
+
+	return nil, nil
+}
+
+// verify returns errors.ErrChecksumMismatch if the content written so far
+// doesn't have the expected checksum.
+func (c *uploadChecksum) verify() error {
+	if !bytes.Equal(c.Sum(nil), c.expected) {
+		return errors.ErrChecksumMismatch
+	}
+	return nil
+}
+
+// checksumsFlushInterval is how often the checksum store is saved.
+const checksumsFlushInterval = time.Minute
+
+// scopedChecksums keys the checksums of the files of a user by their path
+// from the root, so users whose scopes overlap share them.
+type scopedChecksums struct {
+	store *checksums.Store
+	scope string
+}
+
+func (c scopedChecksums) Load(fPath string, modTime time.Time) map[string]string {
+	return c.store.Load(path.Join("/", c.scope, fPath), modTime)
+}
+
+func (c scopedChecksums) Store(fPath, algo, checksum string, modTime time.Time) {
+	c.store.Store(path.Join("/", c.scope, fPath), algo, checksum, modTime)
+}
+
+// checksumCache returns the cache of the checksums of the files of the
+// user, or nil if there's no store.
+func (d *data) checksumCache(store *checksums.Store) files.ChecksumCache {
+	if store == nil {
+		return nil
+	}
+	return scopedChecksums{store: store, scope: d.user.Scope}
+}
@@ -0,0 +1,164 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/filebrowser/filebrowser/v2/clipboard"
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+// clipboardTTL is how long the paths cut or copied can be pasted.
+const clipboardTTL = time.Hour
+
+type clipboardRequest struct {
+	Mode  clipboard.Mode `json:"mode"`
+	Paths []string       `json:"paths"`
+}
+
+func clipboardGetHandler(clip *clipboard.Store) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		content, ok := clip.Get(d.user.ID)
+		if !ok {
+			return http.StatusNoContent, nil
+		}
+		return renderJSON(w, r, content)
+	})
+}
+
+// clipboardPostHandler records the paths the user cut or copied.
+func clipboardPostHandler(clip *clipboard.Store) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		var req clipboardRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			return http.StatusBadRequest, err
+		}
+
+		if (req.Mode == clipboard.Cut && !d.user.Perm.Rename) || (req.Mode == clipboard.Copy && !d.user.Perm.Create) {
+			return http.StatusForbidden, nil
+		}
+
+		for _, p := range req.Paths {
+			if !d.Check(path.Clean("/" + p)) {
+				return http.StatusForbidden, nil
+			}
+		}
+
+		content, err := clip.Set(d.user.ID, req.Mode, req.Paths)
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		return renderJSON(w, r, content)
+	})
+}
+
+func clipboardDeleteHandler(clip *clipboard.Store) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		clip.Clear(d.user.ID)
+		return http.StatusOK, nil
+	})
+}
+
+// pasteHandler copies or moves the paths of the clipboard of the user into
+// the directory of the request path and returns its listing.
+func pasteHandler(clip *clipboard.Store) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		override := r.URL.Query().Get("override") == "true"
+		rename := r.URL.Query().Get("rename") == "true"
+
+		status, err := paste(d, clip, r.URL.Path, override, rename)
+		if err != nil || status >= 400 {
+			return status, err
+		}
+
+		listing, err := files.NewFileInfo(files.FileOptions{
+			Fs:                    d.user.Fs,
+			Path:                  path.Clean("/" + r.URL.Path),
+			Modify:                d.user.Perm.Modify,
+			Expand:                true,
+			ReadHeader:            d.server.TypeDetectionByHeader,
+			Checker:               d,
+			FollowSymlinks:        d.server.FollowSymlinks,
+			Columns:               d.server.ListingColumns,
+			LargeFileThreshold:    d.server.LargeFileThreshold,
+			DirMTimeFromContents:  d.server.DirMTimeFromContents,
+			ImageDimensions:       d.server.ImageDimensions,
+			ShowChildCounts:       d.server.ShowChildCounts,
+			DisplayName:           d.displayName(),
+			PreviewLimits:         d.previewLimits(),
+			CaseInsensitive:       d.server.CaseInsensitive,
+			GracefulPreviewErrors: d.server.GracefulPreviewErrors,
+			BinaryThreshold:       d.server.BinaryThreshold,
+			TextExtensions:        d.server.TextExtensions,
+			Locale:                d.server.Locale,
+			SymlinkDepth:          d.server.SymlinkResolveDepth,
+		})
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		listing.Listing.Sorting = d.user.Sorting
+		listing.Listing.ApplySort()
+		return renderJSON(w, r, listing)
+	})
+}
+
+// paste pastes the clipboard of the user into dir. The paths are checked
+// again since they may have moved, or the rules changed, since they were
+// cut or copied. After a cut, the moved paths are removed from the
+// clipboard, even if pasting some other path failed.
+func paste(d *data, clip *clipboard.Store, dir string, override, rename bool) (int, error) {
+	content, ok := clip.Get(d.user.ID)
+	if !ok {
+		return http.StatusConflict, nil
+	}
+
+	dir = path.Clean("/" + dir)
+	if !d.Check(dir) {
+		return http.StatusForbidden, nil
+	}
+
+	info, err := d.user.Fs.Stat(dir)
+	if err != nil {
+		return errToStatus(err), err
+	}
+	if !info.IsDir() {
+		return http.StatusBadRequest, nil
+	}
+
+	action := "copy"
+	if content.Mode == clipboard.Cut {
+		action = "rename"
+	}
+
+	for _, src := range content.Paths {
+		dst := path.Join(dir, path.Base(src))
+		if !d.Check(src) || !d.Check(dst) {
+			return http.StatusForbidden, nil
+		}
+
+		if !d.server.FollowSymlinks {
+			if err := files.CheckSymlinks(d.user.Fs, src); err != nil { //nolint:govet
+				return errToStatus(err), err
+			}
+		}
+
+		if _, err := d.user.Fs.Stat(src); err != nil { //nolint:govet
+			return errToStatus(err), err
+		}
+
+		status, err := patchResource(d, action, src, dst, override, rename) //nolint:govet
+		if err != nil || status >= 400 {
+			return status, err
+		}
+
+		if content.Mode == clipboard.Cut {
+			clip.Remove(d.user.ID, src)
+		}
+	}
+
+	return http.StatusOK, nil
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/clipboard"
+)
+
+func TestPaste(t *testing.T) {
+	entries := map[string]string{
+		"/a.txt":     "a",
+		"/dir/b.txt": "b",
+		"/dst/":      "",
+	}
+
+	t.Run("cut", func(t *testing.T) {
+		d := newTestData(t, entries)
+		clip := clipboard.NewStore(time.Hour)
+		_, err := clip.Set(d.user.ID, clipboard.Cut, []string{"/a.txt", "/dir/b.txt"})
+		require.NoError(t, err)
+
+		status, err := paste(d, clip, "/dst", false, false)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, map[string]string{
+			"/dir/": "", "/dst/": "", "/dst/a.txt": "a", "/dst/b.txt": "b",
+		}, fileTree(t, d))
+
+		_, ok := clip.Get(d.user.ID)
+		require.False(t, ok, "the clipboard is cleared after a cut")
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		d := newTestData(t, entries)
+		clip := clipboard.NewStore(time.Hour)
+		_, err := clip.Set(d.user.ID, clipboard.Copy, []string{"/a.txt"})
+		require.NoError(t, err)
+
+		status, _ := paste(d, clip, "/dst", false, false)
+		require.Equal(t, http.StatusOK, status)
+		status, _ = paste(d, clip, "/dir", false, false)
+		require.Equal(t, http.StatusOK, status)
+		require.Equal(t, map[string]string{
+			"/a.txt": "a", "/dir/": "", "/dir/a.txt": "a", "/dir/b.txt": "b", "/dst/": "", "/dst/a.txt": "a",
+		}, fileTree(t, d))
+	})
+
+	t.Run("moved source", func(t *testing.T) {
+		d := newTestData(t, entries)
+		clip := clipboard.NewStore(time.Hour)
+		_, err := clip.Set(d.user.ID, clipboard.Cut, []string{"/gone.txt"})
+		require.NoError(t, err)
+
+		status, _ := paste(d, clip, "/dst", false, false)
+		require.Equal(t, http.StatusNotFound, status)
+	})
+
+	t.Run("empty clipboard", func(t *testing.T) {
+		d := newTestData(t, entries)
+		status, _ := paste(d, clipboard.NewStore(time.Hour), "/dst", false, false)
+		require.Equal(t, http.StatusConflict, status)
+	})
+}
@@ -0,0 +1,136 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/mholt/archiver"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/fileutils"
+)
+
+type compressRequest struct {
+	Sources     []string `json:"sources"`
+	ArchiveName string   `json:"archiveName"`
+}
+
+var compressHandler = withUser(compress)
+
+func compress(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if !d.user.Perm.Create {
+		return http.StatusForbidden, nil
+	}
+
+	req := &compressRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	name := req.ArchiveName
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." || len(req.Sources) == 0 {
+		return http.StatusBadRequest, nil
+	}
+	if !strings.HasSuffix(strings.ToLower(name), ".zip") {
+		name += ".zip"
+	}
+
+	dir := path.Clean("/" + r.URL.Path)
+	dst := path.Join(dir, name)
+
+	sources := make([]string, 0, len(req.Sources))
+	for _, src := range req.Sources {
+		src = path.Clean("/" + src)
+		// Refuse to put the archive inside of what is being compressed.
+		if src == "/" || src == dst || strings.HasPrefix(dst, src+"/") {
+			return http.StatusBadRequest, nil
+		}
+		if !d.Check(src) {
+			return http.StatusForbidden, nil
+		}
+		if _, err := d.user.Fs.Stat(src); err != nil {
+			return errToStatus(err), err
+		}
+		sources = append(sources, src)
+	}
+
+	if !d.Check(dst) {
+		return http.StatusForbidden, nil
+	}
+
+	// "override" is accepted too, like for the resources.
+	query := r.URL.Query()
+	if query.Get("overwrite") != "true" && query.Get("override") != "true" {
+		if _, err := d.user.Fs.Stat(dst); err == nil {
+			return http.StatusConflict, nil
+		}
+	}
+
+	nameMax := files.NameMax(d.user.Fs, dir)
+	err := d.RunHook(func() error {
+		// The archive is built aside so an existing one is only replaced
+		// once the new one is complete.
+		tmpPath, err := files.UploadTempPath(dst, nameMax)
+		if err != nil {
+			return err
+		}
+
+		err = compressFiles(d, sources, tmpPath)
+		if err == nil {
+			err = d.user.Fs.Rename(tmpPath, dst)
+		}
+		if err != nil {
+			_ = d.user.Fs.Remove(tmpPath)
+		}
+		return err
+	}, "compress", dir, dst, d.user)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	file, err := files.NewFileInfo(files.FileOptions{
+		Fs:             d.user.Fs,
+		Path:           dst,
+		Modify:         d.user.Perm.Modify,
+		Expand:         false,
+		ReadHeader:     d.server.TypeDetectionByHeader,
+		Checker:        d,
+		FollowSymlinks: d.server.FollowSymlinks,
+	})
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return renderJSON(w, r, file)
+}
+
+func compressFiles(d *data, sources []string, dst string) error {
+	out, err := d.user.Fs.OpenFile(dst, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0775)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	ar := archiver.NewZip()
+	if err := ar.Create(out); err != nil {
+		return err
+	}
+
+	// Keep the parent directory so single files keep their own name.
+	commonDir := fileutils.CommonPrefix('/', sources...)
+	if len(sources) == 1 {
+		commonDir = path.Dir(sources[0])
+	}
+
+	for _, src := range sources {
+		if err := addFile(ar, d, src, commonDir); err != nil {
+			_ = ar.Close()
+			return err
+		}
+	}
+
+	return ar.Close()
+}
@@ -0,0 +1,56 @@
+package http
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompressOverwrite(t *testing.T) {
+	d := newTestData(t, map[string]string{
+		"/docs/a.txt": "a",
+		"/docs.zip":   "old archive",
+	})
+
+	run := func(query string) int {
+		body := strings.NewReader(`{"sources": ["/docs/a.txt"], "archiveName": "docs.zip"}`)
+		status, _ := compress(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/"+query, body), d)
+		return status
+	}
+	archive := func() string {
+		content, err := afero.ReadFile(d.user.Fs, "/docs.zip")
+		require.NoError(t, err)
+		return string(content)
+	}
+
+	require.Equal(t, http.StatusConflict, run(""))
+
+	// A failing hook leaves the existing archive alone.
+	d.Runner.Enabled = true
+	d.settings.Commands = map[string][]string{"before_compress": {"false"}}
+	require.Equal(t, http.StatusInternalServerError, run("?overwrite=true"))
+	require.Equal(t, "old archive", archive())
+	d.Runner.Enabled = false
+
+	for _, query := range []string{"?overwrite=true", "?override=true"} {
+		require.Equal(t, 0, run(query), query)
+
+		content := archive()
+		r, err := zip.NewReader(bytes.NewReader([]byte(content)), int64(len(content)))
+		require.NoError(t, err, query)
+		require.Len(t, r.File, 1)
+		require.Equal(t, "a.txt", r.File[0].Name)
+	}
+
+	require.Equal(t, map[string]string{
+		"/docs/":      "",
+		"/docs/a.txt": "a",
+		"/docs.zip":   archive(),
+	}, fileTree(t, d), "no temporary file is left")
+}
@@ -0,0 +1,109 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+// corsMethods are the methods OPTIONS requests tell whether a path
+// handles, in the order they are listed.
+var corsMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+}
+
+// corsExposedHeaders are the response headers clients on other origins
+// can read.
+const corsExposedHeaders = "Content-Disposition, ETag, X-Renew-Token"
+
+// corsAllowedHeaders are the request headers preflight requests are told
+// the API accepts when they don't ask for any.
+const corsAllowedHeaders = "Content-Type, X-Auth, " + archivePasswordHeader
+
+// corsHandler answers the OPTIONS requests with the methods the router
+// handles for the path and, if the request comes from one of the allowed
+// origins, lets browsers on that origin call the API. An origin of "*"
+// allows any, but only the origins listed can send credentials.
+func corsHandler(router *mux.Router, origins []string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		allowed, listed := corsAllowed(origins, origin)
+		switch {
+		case listed:
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		case allowed:
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		}
+		if allowed {
+			w.Header().Set("Access-Control-Expose-Headers", corsExposedHeaders)
+		}
+		if len(origins) > 0 {
+			w.Header().Add("Vary", "Origin")
+		}
+
+		if r.Method != http.MethodOptions {
+			router.ServeHTTP(w, r)
+			return
+		}
+
+		methods := strings.Join(allowedMethods(router, r), ", ")
+		w.Header().Set("Allow", methods)
+
+		if allowed && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			headers := r.Header.Get("Access-Control-Request-Headers")
+			if headers == "" {
+				headers = corsAllowedHeaders
+			}
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+			w.Header().Set("Access-Control-Max-Age", "600")
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// corsAllowed tells whether the origin is allowed and whether it is one of
+// the listed ones, rather than allowed by a wildcard.
+func corsAllowed(origins []string, origin string) (allowed, listed bool) {
+	if origin == "" {
+		return false, false
+	}
+
+	for _, o := range origins {
+		if strings.EqualFold(o, origin) {
+			return true, true
+		}
+		if o == "*" {
+			allowed = true
+		}
+	}
+	return allowed, false
+}
+
+// allowedMethods returns the methods of the routes matching the path of
+// the request. The paths no route matches are the pages of the frontend.
+func allowedMethods(router *mux.Router, r *http.Request) []string {
+	var methods []string
+	for _, method := range corsMethods {
+		req := r.Clone(r.Context())
+		req.Method = method
+
+		var match mux.RouteMatch
+		if router.Match(req, &match) && match.MatchErr == nil {
+			methods = append(methods, method)
+		}
+	}
+
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet}
+	}
+	return append(methods, http.MethodOptions)
+}
@@ -0,0 +1,98 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCORSHandler(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Method))
+	})
+
+	router := mux.NewRouter()
+	router.NotFoundHandler = ok
+	api := router.PathPrefix("/api").Subrouter()
+	api.PathPrefix("/resources").Handler(ok).Methods("GET")
+	api.PathPrefix("/resources").Handler(ok).Methods("DELETE")
+	api.PathPrefix("/resources").Handler(ok).Methods("PATCH")
+	api.PathPrefix("/raw").Handler(ok).Methods("GET")
+
+	handler := corsHandler(router, []string{"https://app.example.com"})
+
+	serve := func(method, target string, headers map[string]string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, target, nil)
+		for name, value := range headers {
+			r.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve(http.MethodOptions, "/api/resources/a.txt", nil)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, "GET, PATCH, DELETE, OPTIONS", w.Header().Get("Allow"))
+	require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = serve(http.MethodOptions, "/api/raw/a.txt", nil)
+	require.Equal(t, "GET, OPTIONS", w.Header().Get("Allow"))
+
+	w = serve(http.MethodOptions, "/files/a.txt", nil)
+	require.Equal(t, "GET, OPTIONS", w.Header().Get("Allow"))
+
+	w = serve(http.MethodOptions, "/api/resources/a.txt", map[string]string{
+		"Origin":                         "https://app.example.com",
+		"Access-Control-Request-Method":  "DELETE",
+		"Access-Control-Request-Headers": "X-Auth",
+	})
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "GET, PATCH, DELETE, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+	require.Equal(t, "X-Auth", w.Header().Get("Access-Control-Allow-Headers"))
+	require.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+
+	w = serve(http.MethodOptions, "/api/resources/a.txt", map[string]string{
+		"Origin":                        "https://evil.example.com",
+		"Access-Control-Request-Method": "DELETE",
+	})
+	require.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	require.Empty(t, w.Header().Get("Access-Control-Allow-Methods"))
+
+	w = serve(http.MethodGet, "/api/resources/a.txt", map[string]string{"Origin": "https://app.example.com"})
+	require.Equal(t, "GET", w.Body.String())
+	require.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	require.Equal(t, "Origin", w.Header().Get("Vary"))
+}
+
+func TestCORSHandlerWildcard(t *testing.T) {
+	router := mux.NewRouter()
+	router.PathPrefix("/api/resources").Handler(http.NotFoundHandler()).Methods("GET")
+
+	serve := func(origins []string, method string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, "/api/resources/", nil)
+		r.Header.Set("Origin", "https://app.example.com")
+		r.Header.Set("Access-Control-Request-Method", "GET")
+		w := httptest.NewRecorder()
+		corsHandler(router, origins).ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve([]string{"*"}, http.MethodOptions)
+	require.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	require.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	require.Equal(t, corsAllowedHeaders, w.Header().Get("Access-Control-Allow-Headers"))
+
+	// Without configuration, no CORS headers are sent.
+	for _, method := range []string{http.MethodOptions, http.MethodGet} {
+		w = serve(nil, method)
+		for name := range w.Header() {
+			require.NotContains(t, name, "Access-Control")
+		}
+		require.Empty(t, w.Header().Get("Vary"))
+	}
+}
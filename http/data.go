@@ -3,10 +3,13 @@ package http
 import (
 	"log"
 	"net/http"
-	"strconv"
+	"path"
+	"sync"
+	"time"
 
 	"github.com/tomasen/realip"
 
+	"github.com/filebrowser/filebrowser/v2/files"
 	"github.com/filebrowser/filebrowser/v2/rules"
 	"github.com/filebrowser/filebrowser/v2/runner"
 	"github.com/filebrowser/filebrowser/v2/settings"
@@ -23,6 +26,12 @@ type data struct {
 	store    *storage.Storage
 	user     *users.User
 	raw      interface{}
+	// aliased is the path the request was made under when it is an
+	// alias, see withAlias.
+	aliased string
+	// heavySlot is whether the request holds a slot of the heavy
+	// operations limiter, see heavyOpLimiter.
+	heavySlot bool
 }
 
 // Check implements rules.Checker.
@@ -31,6 +40,10 @@ func (d *data) Check(path string) bool {
 		return false
 	}
 
+	if d.versionsLayout().Hides(path) {
+		return false
+	}
+
 	allow := true
 	for _, rule := range d.settings.Rules {
 		if rule.Matches(path) {
@@ -47,8 +60,67 @@ func (d *data) Check(path string) bool {
 	return allow
 }
 
+// checkWriteSymlinks makes sure that writing to fPath doesn't lead out
+// of the scope through a symbolic link, unless following them is
+// allowed. The files yet to be created are checked through the closest
+// of their parents which exists.
+func (d *data) checkWriteSymlinks(fPath string) error {
+	if d.server.FollowSymlinks {
+		return nil
+	}
+
+	for p := path.Clean("/" + fPath); ; p = path.Dir(p) {
+		if err := files.CheckSymlinks(d.user.Fs, p); err != nil {
+			return err
+		}
+		if _, err := d.user.Fs.Stat(p); err == nil || p == "/" {
+			return nil
+		}
+	}
+}
+
+// displayName returns the transform of the names the files are shown with.
+func (d *data) displayName() files.NameTransform {
+	return files.NameTransform{Pattern: d.server.DisplayNamePattern, Replacement: d.server.DisplayNameReplacement}
+}
+
+// previewLimits returns the maximum sizes of the text files previewed.
+func (d *data) previewLimits() files.PreviewLimits {
+	return files.PreviewLimits{Default: d.server.PreviewMaxSize, ByCategory: d.server.PreviewMaxSizes}
+}
+
+// locations caches the timezones by name, as loading one reads the
+// timezone database.
+var locations sync.Map // name -> *time.Location
+
+// timezone returns the location the dates are shown in, the local one
+// unless configured.
+func (d *data) timezone() *time.Location {
+	if d.server.Timezone == "" {
+		return time.Local
+	}
+
+	if loc, ok := locations.Load(d.server.Timezone); ok {
+		return loc.(*time.Location)
+	}
+
+	loc, err := time.LoadLocation(d.server.Timezone)
+	if err != nil {
+		log.Printf("invalid timezone %q: %v", d.server.Timezone, err)
+		return time.Local
+	}
+	locations.Store(d.server.Timezone, loc)
+	return loc
+}
+
 func handle(fn handleFunc, prefix string, store *storage.Storage, server *settings.Server) http.Handler {
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The routes with a prefix are the ones taking a path.
+		if prefix != "" && server.MaxPathDepth > 0 && pathDepth(r.URL.Path) > server.MaxPathDepth {
+			writeError(w, r, http.StatusBadRequest, "")
+			return
+		}
+
 		settings, err := store.Settings.Get()
 		if err != nil {
 			log.Fatalln("ERROR: couldn't get settings")
@@ -63,8 +135,7 @@ func handle(fn handleFunc, prefix string, store *storage.Storage, server *settin
 		})
 
 		if status != 0 {
-			txt := http.StatusText(status)
-			http.Error(w, strconv.Itoa(status)+" "+txt, status)
+			writeError(w, r, status, "")
 		}
 
 		if status >= 400 || err != nil {
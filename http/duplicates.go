@@ -0,0 +1,35 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/search"
+)
+
+// duplicatesHandler reports the groups of files with identical content
+// found under the request path. The "depth" parameter limits how many
+// directory levels are walked, which is unlimited by default.
+var duplicatesHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if !d.Check(r.URL.Path) {
+		return http.StatusForbidden, nil
+	}
+
+	depth := -1
+	if value := r.URL.Query().Get("depth"); value != "" {
+		var err error
+		depth, err = strconv.Atoi(value)
+		if err != nil || depth < 0 {
+			return http.StatusBadRequest, fmt.Errorf("invalid depth %q: %w", value, errors.ErrInvalidRequestParams)
+		}
+	}
+
+	groups, err := search.Duplicates(r.Context(), d.user.Fs, r.URL.Path, depth, d)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return renderJSON(w, r, groups)
+})
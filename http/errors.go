@@ -0,0 +1,54 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// errorEnvelope is the body of the failed requests of JSON clients.
+type errorEnvelope struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// wantsJSON tells if the request prefers JSON over HTML, as API clients
+// asking for it do, rather than browsers.
+func wantsJSON(r *http.Request) bool {
+	for _, mediaType := range acceptedMediaTypes(r) {
+		switch mediaType {
+		case "application/json":
+			return true
+		case "text/html":
+			return false
+		}
+	}
+	return false
+}
+
+// writeError replies to the request with the status. JSON clients get an
+// errorEnvelope and the others a plain text body, as http.Error writes.
+// The message is the text of the status unless given, so the errors
+// themselves, which may tell about the server, never reach the client.
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	if !wantsJSON(r) {
+		if message == "" {
+			message = strconv.Itoa(status) + " " + http.StatusText(status)
+		}
+		http.Error(w, message, status)
+		return
+	}
+
+	if message == "" {
+		message = http.StatusText(status)
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(errorEnvelope{errorBody{Code: status, Message: message}})
+}
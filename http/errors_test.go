@@ -0,0 +1,46 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteError(t *testing.T) {
+	testCases := map[string]struct {
+		accept string
+		json   bool
+	}{
+		"no accept": {"", false},
+		"any":       {"*/*", false},
+		"json":      {"application/json", true},
+		"api":       {"application/json, text/plain, */*", true},
+		"browser":   {"text/html,application/xhtml+xml,application/json;q=0.9", false},
+		"quality":   {"text/html;q=0.5, application/json", true},
+		"refused":   {"application/json;q=0", false},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept", tc.accept)
+			w := httptest.NewRecorder()
+			writeError(w, r, http.StatusNotFound, "")
+
+			require.Equal(t, http.StatusNotFound, w.Code)
+			if !tc.json {
+				assert.Equal(t, "404 Not Found\n", w.Body.String())
+				return
+			}
+
+			assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+			var envelope errorEnvelope
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &envelope))
+			assert.Equal(t, errorBody{Code: http.StatusNotFound, Message: "Not Found"}, envelope.Error)
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"path"
+	"strings"
+)
+
+// uploadExtensionAllowed tells if a file with the given name can be
+// uploaded according to the allowed and blocked extensions, which are
+// lowercase and start with a dot. Blocked extensions take precedence and
+// are looked for in every extension of the name, so "shell.php.jpg" is
+// blocked along with "shell.php". When some extensions are allowed, the
+// final one must be among them.
+func uploadExtensionAllowed(name string, allow, block []string) bool {
+	// Trailing dots and spaces are dropped by some systems, which would
+	// turn "shell.php." into "shell.php".
+	name = strings.ToLower(strings.TrimRight(path.Base(name), ". "))
+
+	exts := strings.Split(name, ".")[1:]
+	for _, ext := range exts {
+		if containsString(block, "."+strings.TrimSpace(ext)) {
+			return false
+		}
+	}
+
+	if len(allow) == 0 {
+		return true
+	}
+
+	return len(exts) > 0 && containsString(allow, "."+exts[len(exts)-1])
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// downloadForced tells if a file with the given name must be downloaded
+// rather than shown inline according to the extensions forced to, which
+// are lowercase and start with a dot. Only the final extension counts,
+// after dropping the trailing dots and spaces as for uploads.
+func downloadForced(name string, forced []string) bool {
+	name = strings.ToLower(strings.TrimRight(path.Base(name), ". "))
+	return len(forced) > 0 && containsString(forced, path.Ext(name))
+}
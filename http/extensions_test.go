@@ -0,0 +1,43 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploadExtensionAllowed(t *testing.T) {
+	block := []string{".php", ".exe", ".sh"}
+	allow := []string{".jpg", ".png"}
+
+	testCases := map[string]struct {
+		name  string
+		allow []string
+		want  bool
+	}{
+		"no restriction":           {"/a.txt", nil, true},
+		"allowed":                  {"/photos/a.jpg", allow, true},
+		"not allowed":              {"/a.gif", allow, false},
+		"no extension":             {"/README", allow, false},
+		"no extension allowed":     {"/README", nil, true},
+		"uppercase allowed":        {"/a.JPG", allow, true},
+		"blocked":                  {"/a.php", nil, false},
+		"blocked uppercase":        {"/a.PHP", nil, false},
+		"blocked mixed case":       {"/a.pHp", allow, false},
+		"double extension":         {"/shell.php.jpg", allow, false},
+		"double extension reverse": {"/photo.jpg.php", allow, false},
+		"deep double extension":    {"/a.tar.exe.png", nil, false},
+		"trailing dot":             {"/shell.php.", nil, false},
+		"trailing space":           {"/shell.php ", nil, false},
+		"trailing dots and spaces": {"/shell.jpg.php. .", allow, false},
+		"blocked directory name":   {"/dir.php/a.jpg", allow, true},
+		"dotfile":                  {"/.sh", nil, false},
+		"similar extension":        {"/a.phps", nil, true},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.want, uploadExtensionAllowed(tc.name, tc.allow, block))
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/filebrowser/filebrowser/v2/fileutils"
+)
+
+var extractHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if !d.user.Perm.Create {
+		return http.StatusForbidden, nil
+	}
+
+	src := path.Clean("/" + r.URL.Path)
+	dst, err := url.QueryUnescape(r.URL.Query().Get("destination"))
+	if err != nil {
+		return errToStatus(err), err
+	}
+	if dst == "" {
+		dst = path.Dir(src)
+	}
+	dst = path.Clean("/" + dst)
+
+	if !d.Check(src) || !d.Check(dst) {
+		return http.StatusForbidden, nil
+	}
+
+	if !fileutils.IsExtractable(src) {
+		return http.StatusBadRequest, nil
+	}
+
+	// The entries denied by the rules, or overwriting files without the
+	// permission and the override parameter, are reported as skipped.
+	opts := fileutils.ExtractOptions{
+		Checker:  d,
+		Override: d.user.Perm.Modify && r.URL.Query().Get("override") == "true",
+	}
+
+	var extraction *fileutils.Extraction
+	err = d.RunHook(func() error {
+		extraction, err = fileutils.Extract(d.user.Fs, src, dst, opts)
+		return err
+	}, "extract", src, dst, d.user)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return renderJSON(w, r, extraction)
+})
@@ -0,0 +1,131 @@
+package http
+
+import (
+	stderrors "errors"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/remotefetch"
+)
+
+var fetchHandler = withUser(fetchResource)
+
+// fetchResource downloads the file at the "url" parameter into the
+// directory of the path, under the name of the "name" parameter or else
+// the one the remote server tells. Like uploads, the file only appears
+// once complete, and existing files are only replaced if the override
+// parameter is set.
+func fetchResource(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if d.server.DisableFetch {
+		return http.StatusNotFound, nil
+	}
+	if !d.user.Perm.Create {
+		return http.StatusForbidden, nil
+	}
+
+	u, err := remotefetch.ParseURL(r.URL.Query().Get("url"))
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	dir := path.Clean("/" + r.URL.Path)
+	if !d.Check(dir) || inArchive(d, dir) {
+		return http.StatusForbidden, nil
+	}
+
+	info, err := d.user.Fs.Stat(dir)
+	if err != nil {
+		return errToStatus(err), err
+	}
+	if !info.IsDir() {
+		return http.StatusBadRequest, nil
+	}
+
+	fetcher := remotefetch.New(time.Duration(d.server.FetchTimeout)*time.Second, d.server.FetchMaxSize)
+	resp, err := fetcher.Fetch(r.Context(), u)
+	if err != nil {
+		return fetchErrToStatus(err), err
+	}
+	defer resp.Close()
+
+	name := resp.Name
+	if n := r.URL.Query().Get("name"); n != "" {
+		name = path.Base(path.Clean("/" + n))
+	}
+	fPath := path.Join(dir, name)
+
+	if !d.Check(fPath) {
+		return http.StatusForbidden, nil
+	}
+
+	nameMax := files.NameMax(d.user.Fs, dir)
+	if err := files.CheckNameLength(fPath, nameMax); err != nil { //nolint:govet
+		return errToStatus(err), err
+	}
+
+	if !uploadExtensionAllowed(fPath, d.server.UploadAllowExtensions, d.server.UploadBlockExtensions) {
+		return http.StatusUnsupportedMediaType, nil
+	}
+
+	if r.URL.Query().Get("override") != "true" {
+		if _, err := d.user.Fs.Stat(fPath); err == nil { //nolint:govet
+			return http.StatusConflict, nil
+		}
+	}
+
+	err = d.RunHook(func() error {
+		defer lockPaths(d, fPath)()
+
+		tmpPath, err := files.UploadTempPath(fPath, nameMax)
+		if err != nil {
+			return err
+		}
+
+		_, err = writeUpload(d.user.Fs, tmpPath, resp, nil)
+		if err == nil && d.server.KeepVersions > 0 {
+			snapshotVersion(d, fPath)
+		}
+		if err == nil {
+			err = d.user.Fs.Rename(tmpPath, fPath)
+		}
+		if err != nil {
+			_ = d.user.Fs.Remove(tmpPath)
+		}
+		return err
+	}, "upload", fPath, "", d.user)
+	if err != nil {
+		return fetchErrToStatus(err), err
+	}
+
+	file, err := files.NewFileInfo(files.FileOptions{
+		Fs:             d.user.Fs,
+		Path:           fPath,
+		Modify:         d.user.Perm.Modify,
+		Expand:         false,
+		ReadHeader:     d.server.TypeDetectionByHeader,
+		Checker:        d,
+		FollowSymlinks: d.server.FollowSymlinks,
+	})
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return renderJSON(w, r, file)
+}
+
+// fetchErrToStatus tells the status of the errors of fetches, which may
+// come from the remote server as well as the filesystem.
+func fetchErrToStatus(err error) int {
+	switch {
+	case stderrors.Is(err, remotefetch.ErrBlockedAddress):
+		return http.StatusForbidden
+	case stderrors.Is(err, remotefetch.ErrTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case stderrors.Is(err, remotefetch.ErrFailed):
+		return http.StatusBadGateway
+	default:
+		return errToStatus(err)
+	}
+}
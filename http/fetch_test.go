@@ -0,0 +1,34 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchResource(t *testing.T) {
+	internal := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the internal address was reached")
+	}))
+	defer internal.Close()
+
+	d := newTestData(t, map[string]string{"/downloads/.keep": ""})
+
+	fetch := func(dir, remote string) int {
+		r := httptest.NewRequest(http.MethodPost, dir+"?url="+url.QueryEscape(remote), nil)
+		status, _ := fetchResource(httptest.NewRecorder(), r, d)
+		return status
+	}
+
+	require.Equal(t, http.StatusForbidden, fetch("/downloads", internal.URL+"/a.txt"))
+	require.Equal(t, http.StatusForbidden, fetch("/downloads", "http://169.254.169.254/latest/meta-data"))
+	require.Equal(t, http.StatusBadRequest, fetch("/downloads", "file:///etc/passwd"))
+	require.Equal(t, http.StatusNotFound, fetch("/missing", "https://example.com/a.txt"))
+	require.Equal(t, map[string]string{"/downloads/": "", "/downloads/.keep": ""}, fileTree(t, d))
+
+	d.user.Perm.Create = false
+	require.Equal(t, http.StatusForbidden, fetch("/downloads", "https://example.com/a.txt"))
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+// fileFields maps the JSON keys of a files.FileInfo to their values so
+// clients can ask for a subset of them using the "fields" parameter.
+var fileFields = map[string]func(*files.FileInfo) interface{}{
+	"path":           func(f *files.FileInfo) interface{} { return f.Path },
+	"name":           func(f *files.FileInfo) interface{} { return f.Name },
+	"size":           func(f *files.FileInfo) interface{} { return f.Size },
+	"extension":      func(f *files.FileInfo) interface{} { return f.Extension },
+	"modified":       func(f *files.FileInfo) interface{} { return f.ModTime },
+	"mode":           func(f *files.FileInfo) interface{} { return f.Mode },
+	"isDir":          func(f *files.FileInfo) interface{} { return f.IsDir },
+	"isSpecial":      func(f *files.FileInfo) interface{} { return f.IsSpecial },
+	"decompressed":   func(f *files.FileInfo) interface{} { return f.Decompressed },
+	"type":           func(f *files.FileInfo) interface{} { return f.Type },
+	"subtitles":      func(f *files.FileInfo) interface{} { return f.Subtitles },
+	"content":        func(f *files.FileInfo) interface{} { return f.Content },
+	"checksums":      func(f *files.FileInfo) interface{} { return f.Checksums },
+	"xattrs":         func(f *files.FileInfo) interface{} { return f.Xattrs },
+	"owner":          func(f *files.FileInfo) interface{} { return f.Owner },
+	"icon":           func(f *files.FileInfo) interface{} { return f.Icon },
+	"hex":            func(f *files.FileInfo) interface{} { return f.Hex },
+	"largeFile":      func(f *files.FileInfo) interface{} { return f.LargeFile },
+	"error":          func(f *files.FileInfo) interface{} { return f.Error },
+	"line":           func(f *files.FileInfo) interface{} { return f.Line },
+	"firstLine":      func(f *files.FileInfo) interface{} { return f.FirstLine },
+	"width":          func(f *files.FileInfo) interface{} { return f.Width },
+	"height":         func(f *files.FileInfo) interface{} { return f.Height },
+	"childCount":     func(f *files.FileInfo) interface{} { return f.ChildCount },
+	"displayName":    func(f *files.FileInfo) interface{} { return f.DisplayName },
+	"previewLimit":   func(f *files.FileInfo) interface{} { return f.PreviewLimit },
+	"truncated":      func(f *files.FileInfo) interface{} { return f.Truncated },
+	"symlink":        func(f *files.FileInfo) interface{} { return f.Symlink },
+	"offset":         func(f *files.FileInfo) interface{} { return f.Offset },
+	"pastEnd":        func(f *files.FileInfo) interface{} { return f.PastEnd },
+	"binaryDetected": func(f *files.FileInfo) interface{} { return f.BinaryDetected },
+	"previewFailed":  func(f *files.FileInfo) interface{} { return f.PreviewFailed },
+	"modifiedNs":     func(f *files.FileInfo) interface{} { return f.ModTimeNano },
+	"statHash":       func(f *files.FileInfo) interface{} { return f.StatHash },
+}
+
+// parseFields parses the comma separated "fields" query parameter. It
+// returns nil if the parameter is not set.
+func parseFields(r *http.Request) ([]string, error) {
+	value := r.URL.Query().Get("fields")
+	if value == "" {
+		return nil, nil
+	}
+
+	fields := splitFields(value)
+	for _, field := range fields {
+		if _, ok := fileFields[field]; !ok {
+			return nil, fmt.Errorf("unknown field %q: %w", field, errors.ErrInvalidRequestParams)
+		}
+	}
+
+	return fields, nil
+}
+
+func splitFields(value string) []string {
+	var fields []string
+	for _, field := range strings.Split(value, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// selectFields returns a map with the requested fields of the file. For
+// directories, the listing is kept and each item is reduced the same way.
+func selectFields(file *files.FileInfo, fields []string) map[string]interface{} {
+	selected := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		selected[field] = fileFields[field](file)
+	}
+
+	if file.Listing != nil {
+		items := make([]map[string]interface{}, 0, len(file.Items))
+		for _, item := range file.Items {
+			items = append(items, selectFields(item, fields))
+		}
+
+		selected["items"] = items
+		selected["numDirs"] = file.NumDirs
+		selected["numFiles"] = file.NumFiles
+		selected["sorting"] = file.Sorting
+		selected["totalSize"] = file.TotalSize
+		selected["categories"] = file.Categories
+		if file.Largest != "" {
+			selected["largest"] = file.Largest
+		}
+		if file.View != "" {
+			selected["view"] = file.View
+		}
+		if file.RenderedReadme != "" {
+			selected["renderedReadme"] = file.RenderedReadme
+		}
+		if file.Groups != nil {
+			selected["groups"] = file.Groups
+		}
+		if file.Listing.Truncated {
+			selected["listingTruncated"] = true
+		}
+		if file.Omitted != nil {
+			selected["omitted"] = file.Omitted
+		}
+	}
+
+	return selected
+}
+
+// renderFileJSON renders the file, restricted to the given fields if any.
+func renderFileJSON(w http.ResponseWriter, r *http.Request, file *files.FileInfo, fields []string) (int, error) {
+	if fields == nil {
+		return renderJSON(w, r, file)
+	}
+	return renderJSON(w, r, selectFields(file, fields))
+}
@@ -0,0 +1,79 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func getFields(t *testing.T, d *data, url string) map[string]interface{} {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	status, err := resourceGet(w, httptest.NewRequest(http.MethodGet, url, nil), d, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, status)
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	return got
+}
+
+func TestFieldsFlatten(t *testing.T) {
+	d := newTestData(t, map[string]string{
+		"/root/a.txt":   "a",
+		"/root/b/c.txt": "c",
+		"/root/b/d.txt": "d",
+	})
+
+	got := getFields(t, d, "/root/?flatten=true&depth=2&maxfiles=2&fields=name")
+	require.Equal(t, true, got["listingTruncated"])
+	require.EqualValues(t, 1, got["omitted"])
+	require.Len(t, got["items"], 2)
+}
+
+func TestFields(t *testing.T) {
+	d := newTestData(t, map[string]string{
+		"/small.txt": "a",
+		"/large.txt": "large file",
+	})
+	d.server.LargeFileThreshold = 5
+
+	testCases := map[string]struct {
+		url  string
+		want map[string]interface{}
+	}{
+		"largeFile": {
+			url:  "/large.txt?fields=name,largeFile",
+			want: map[string]interface{}{"name": "large.txt", "largeFile": true},
+		},
+		"not largeFile": {
+			url:  "/small.txt?fields=largeFile",
+			want: map[string]interface{}{"largeFile": false},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.want, getFields(t, d, tc.url))
+		})
+	}
+
+	d.server.HashOnStat = true
+	got := getFields(t, d, "/small.txt?fields=modifiedNs,statHash")
+	require.Len(t, got, 2)
+	require.NotZero(t, got["modifiedNs"])
+	require.NotEmpty(t, got["statHash"])
+
+	got = getFields(t, d, "/?fields=name,binaryDetected,previewFailed,pastEnd,error")
+	require.EqualValues(t, 11, got["totalSize"])
+	require.Equal(t, "large.txt", got["largest"])
+	require.Contains(t, got, "categories")
+
+	w := httptest.NewRecorder()
+	status, _ := resourceGet(w, httptest.NewRequest(http.MethodGet, "/small.txt?fields=bogus", nil), d, nil)
+	require.Equal(t, http.StatusBadRequest, status)
+}
@@ -0,0 +1,45 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/version"
+)
+
+type healthStatus struct {
+	Status  string  `json:"status"`
+	Error   string  `json:"error,omitempty"`
+	Uptime  float64 `json:"uptime"`
+	Version string  `json:"version"`
+	Commit  string  `json:"commit"`
+}
+
+// healthHandler tells whether the root of the files can be reached, with
+// 503 if it can't, so load balancers know when the storage went away. It
+// doesn't need authentication and only stats the root.
+func healthHandler(fs afero.Fs, root string, started time.Time) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		health := healthStatus{
+			Status:  "ok",
+			Uptime:  time.Since(started).Seconds(),
+			Version: version.Version,
+			Commit:  version.CommitSHA,
+		}
+
+		status := http.StatusOK
+		if _, err := fs.Stat(root); err != nil {
+			status = http.StatusServiceUnavailable
+			health.Status = "unavailable"
+			health.Error = err.Error()
+		}
+
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(health)
+	})
+}
@@ -0,0 +1,32 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	require.NoError(t, fs.MkdirAll("/srv", 0755))
+	handler := healthHandler(fs, "/srv", time.Now().Add(-time.Minute))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var health healthStatus
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&health))
+	require.Equal(t, "ok", health.Status)
+	require.GreaterOrEqual(t, health.Uptime, 60.0)
+
+	require.NoError(t, fs.RemoveAll("/srv"))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+}
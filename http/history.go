@@ -0,0 +1,78 @@
+package http
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+type historyEntry struct {
+	Time time.Time `json:"time"`
+	User string    `json:"user"`
+	From string    `json:"from"`
+	To   string    `json:"to"`
+}
+
+// historyMu serializes the writes to the central history log.
+var historyMu sync.Mutex
+
+// recordMove appends a move, or rename, from src to dst to the history
+// log. That is the server's central log if one is set, or the hidden
+// history file of the source and destination directories otherwise.
+// Failing to write the history is only logged so it never makes the
+// move itself fail.
+func recordMove(d *data, src, dst string) {
+	entry, err := json.Marshal(historyEntry{
+		Time: time.Now(),
+		User: d.user.Username,
+		From: src,
+		To:   dst,
+	})
+	if err != nil {
+		log.Printf("couldn't record move of %s: %v", src, err)
+		return
+	}
+	entry = append(entry, '\n')
+
+	if d.server.HistoryLog != "" {
+		historyMu.Lock()
+		defer historyMu.Unlock()
+
+		if err := appendHistory(afero.NewOsFs(), d.server.HistoryLog, entry); err != nil {
+			log.Printf("couldn't record move of %s: %v", src, err)
+		}
+		return
+	}
+
+	dirs := []string{path.Dir(src)}
+	if path.Dir(dst) != path.Dir(src) {
+		dirs = append(dirs, path.Dir(dst))
+	}
+
+	for _, dir := range dirs {
+		if err := appendHistory(d.user.Fs, path.Join(dir, files.HistoryFile), entry); err != nil {
+			log.Printf("couldn't record move of %s: %v", src, err)
+		}
+	}
+}
+
+func appendHistory(fs afero.Fs, name string, entry []byte) error {
+	file, err := fs.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+
+	if _, err := file.Write(entry); err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
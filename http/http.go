@@ -1,12 +1,22 @@
 package http
 
 import (
+	"context"
+	"log"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/spf13/afero"
 
+	"github.com/filebrowser/filebrowser/v2/checksums"
+	"github.com/filebrowser/filebrowser/v2/clipboard"
+	"github.com/filebrowser/filebrowser/v2/pins"
+	"github.com/filebrowser/filebrowser/v2/search"
 	"github.com/filebrowser/filebrowser/v2/settings"
 	"github.com/filebrowser/filebrowser/v2/storage"
+	"github.com/filebrowser/filebrowser/v2/transfers"
+	"github.com/filebrowser/filebrowser/v2/users"
 )
 
 type modifyRequest struct {
@@ -17,8 +27,14 @@ type modifyRequest struct {
 func NewHandler(imgSvc ImgService, fileCache FileCache, store *storage.Storage, server *settings.Server) (http.Handler, error) {
 	server.Clean()
 
-	r := mux.NewRouter()
 	index, static := getStaticHandlers(store, server)
+	return newRouter(index, static, imgSvc, fileCache, store, server)
+}
+
+// newRouter routes the requests to the API, falling back to the index and
+// static handlers of the frontend.
+func newRouter(index, static http.Handler, imgSvc ImgService, fileCache FileCache, store *storage.Storage, server *settings.Server) (http.Handler, error) {
+	r := mux.NewRouter()
 
 	// NOTE: This fixes the issue where it would redirect if people did not put a
 	// trailing slash in the end. I hate this decision since this allows some awful
@@ -29,7 +45,67 @@ func NewHandler(imgSvc ImgService, fileCache FileCache, store *storage.Storage,
 		return handle(fn, prefix, store, server)
 	}
 
+	// The routes touching files only serve signed URLs when they are
+	// required, whatever their prefix.
+	fileRoute := func(fn handleFunc, prefix string) http.Handler {
+		if server.RequireSignedURLs {
+			return requireSignature(monkey(fn, prefix), server.URLSigningSecret)
+		}
+		return monkey(fn, prefix)
+	}
+
+	// The routes taking their paths from the body are refused instead, a
+	// signature only covering the path of the URL.
+	bodyRoute := func(fn handleFunc, prefix string) http.Handler {
+		if server.RequireSignedURLs {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				writeError(w, r, http.StatusForbidden, "")
+			})
+		}
+		return monkey(fn, prefix)
+	}
+
+	var pinStore *pins.Storage
+	if server.PinsPath != "" {
+		pinStore = pins.NewStorage(server.PinsPath)
+	}
+
+	var checksumStore *checksums.Store
+	if server.ChecksumStorePath != "" {
+		var err error
+		checksumStore, err = checksums.NewStore(server.ChecksumStorePath, checksumsFlushInterval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	clip := clipboard.NewStore(clipboardTTL)
+	transfersReg := transfers.NewRegistry(transfersTTL)
+
+	var searchIndex *search.Index
+	if server.BuildSearchIndex {
+		if _, ok := users.RootFs.(*afero.OsFs); ok {
+			searchIndex = search.NewIndex(server.Root)
+			go func() {
+				if err := searchIndex.Run(context.Background()); err != nil {
+					log.Printf("the search index of %s stopped, searches walk the files: %v", server.Root, err)
+				}
+			}()
+		} else {
+			log.Println("the search index is only built on the local disk, searches walk the files")
+		}
+	}
+
+	heavy := newHeavyOpLimiter(server.MaxConcurrentHeavyOps, time.Duration(server.HeavyOpsTimeout)*time.Second)
+
+	if server.HealthPath != "" {
+		r.Path(server.HealthPath).Handler(healthHandler(users.RootFs, server.Root, time.Now()))
+	}
+
 	r.PathPrefix("/static").Handler(static)
+	if server.EnableWebDAV {
+		r.PathPrefix("/dav").Handler(fileRoute(davHandler, ""))
+	}
 	r.NotFoundHandler = index
 
 	api := r.PathPrefix("/api").Subrouter()
@@ -45,29 +121,65 @@ func NewHandler(imgSvc ImgService, fileCache FileCache, store *storage.Storage,
 	users.Handle("/{id:[0-9]+}", monkey(userGetHandler, "")).Methods("GET")
 	users.Handle("/{id:[0-9]+}", monkey(userDeleteHandler, "")).Methods("DELETE")
 
-	api.PathPrefix("/resources").Handler(monkey(resourceGetHandler, "/api/resources")).Methods("GET")
-	api.PathPrefix("/resources").Handler(monkey(resourceDeleteHandler(fileCache), "/api/resources")).Methods("DELETE")
-	api.PathPrefix("/resources").Handler(monkey(resourcePostPutHandler, "/api/resources")).Methods("POST")
-	api.PathPrefix("/resources").Handler(monkey(resourcePostPutHandler, "/api/resources")).Methods("PUT")
-	api.PathPrefix("/resources").Handler(monkey(resourcePatchHandler, "/api/resources")).Methods("PATCH")
+	api.PathPrefix("/resources").Queries("qr", "true").
+		Handler(fileRoute(withAlias(qrResourceHandler(fileCache, server.QRSize)), "/api/resources")).Methods("GET")
+	api.PathPrefix("/resources").Handler(fileRoute(withAlias(resourceGetHandler(checksumStore)), "/api/resources")).Methods("GET")
+	api.PathPrefix("/resources").Handler(fileRoute(withAlias(resourceHeadHandler), "/api/resources")).Methods("HEAD")
+	api.PathPrefix("/resources").Handler(fileRoute(withAlias(resourceDeleteHandler(fileCache)), "/api/resources")).Methods("DELETE")
+	api.PathPrefix("/resources").Handler(fileRoute(withAlias(resourcePostPutHandler), "/api/resources")).Methods("POST")
+	api.PathPrefix("/resources").Handler(fileRoute(withAlias(resourcePostPutHandler), "/api/resources")).Methods("PUT")
+	api.PathPrefix("/resources").Handler(fileRoute(withAlias(resourcePatchHandler(transfersReg)), "/api/resources")).Methods("PATCH")
+
+	api.PathPrefix("/transfers").Handler(fileRoute(transfersGetHandler(transfersReg), "/api/transfers")).Methods("GET")
+	api.PathPrefix("/transfers").Handler(fileRoute(transferDeleteHandler(transfersReg), "/api/transfers")).Methods("DELETE")
+
+	api.Handle("/batch", bodyRoute(batchHandler(fileCache), "")).Methods("POST")
+
+	api.Path("/pins").Handler(fileRoute(pinsGetHandler(pinStore), "")).Methods("GET")
+	api.PathPrefix("/pins").Handler(fileRoute(pinPostHandler(pinStore), "/api/pins")).Methods("POST")
+	api.PathPrefix("/pins").Handler(fileRoute(pinDeleteHandler(pinStore), "/api/pins")).Methods("DELETE")
+
+	api.Path("/clipboard").Handler(bodyRoute(clipboardGetHandler(clip), "")).Methods("GET")
+	api.Path("/clipboard").Handler(bodyRoute(clipboardPostHandler(clip), "")).Methods("POST")
+	api.Path("/clipboard").Handler(bodyRoute(clipboardDeleteHandler(clip), "")).Methods("DELETE")
+	api.PathPrefix("/paste").Handler(bodyRoute(pasteHandler(clip), "/api/paste")).Methods("POST")
+
+	api.Path("/shares").Handler(fileRoute(shareListHandler, "/api/shares")).Methods("GET")
+	api.PathPrefix("/share").Handler(fileRoute(shareGetsHandler, "/api/share")).Methods("GET")
+	api.PathPrefix("/share").Handler(fileRoute(sharePostHandler, "/api/share")).Methods("POST")
+	api.PathPrefix("/share").Handler(fileRoute(shareDeleteHandler, "/api/share")).Methods("DELETE")
 
-	api.Path("/shares").Handler(monkey(shareListHandler, "/api/shares")).Methods("GET")
-	api.PathPrefix("/share").Handler(monkey(shareGetsHandler, "/api/share")).Methods("GET")
-	api.PathPrefix("/share").Handler(monkey(sharePostHandler, "/api/share")).Methods("POST")
-	api.PathPrefix("/share").Handler(monkey(shareDeleteHandler, "/api/share")).Methods("DELETE")
+	api.PathPrefix("/token").Handler(fileRoute(shareTokenPostHandler, "/api/token")).Methods("POST")
 
 	api.Handle("/settings", monkey(settingsGetHandler, "")).Methods("GET")
 	api.Handle("/settings", monkey(settingsPutHandler, "")).Methods("PUT")
 
-	api.PathPrefix("/raw").Handler(monkey(rawHandler, "/api/raw")).Methods("GET")
+	api.PathPrefix("/raw").Queries("preview", "pdf").
+		Handler(fileRoute(withAlias(heavy.limit(officePreviewHandler(fileCache, server.OfficeConverter, heavy))), "/api/raw")).Methods("GET")
+	api.PathPrefix("/raw").Queries("thumb", "true").
+		Handler(fileRoute(withAlias(heavy.limit(pdfThumbHandler(fileCache, server.PDFRenderer, server.ThumbSize, heavy))), "/api/raw")).Methods("GET")
+	api.PathPrefix("/raw").Handler(fileRoute(withAlias(rawHandler(heavy)), "/api/raw")).Methods("GET")
+	api.PathPrefix("/versions").Handler(fileRoute(withAlias(versionsGetHandler), "/api/versions")).Methods("GET")
+	api.PathPrefix("/manifest").Handler(fileRoute(withAlias(heavy.limit(manifestHandler)), "/api/manifest")).Methods("GET")
 	api.PathPrefix("/preview/{size}/{path:.*}").
-		Handler(monkey(previewHandler(imgSvc, fileCache, server.EnableThumbnails, server.ResizePreview), "/api/preview")).Methods("GET")
-	api.PathPrefix("/command").Handler(monkey(commandsHandler, "/api/command")).Methods("GET")
-	api.PathPrefix("/search").Handler(monkey(searchHandler, "/api/search")).Methods("GET")
+		Handler(fileRoute(heavy.limit(previewHandler(imgSvc, fileCache, server.EnableThumbnails, server.ResizePreview, server.ThumbSize)), "/api/preview")).Methods("GET")
+	api.PathPrefix("/command").Handler(fileRoute(commandsHandler, "/api/command")).Methods("GET")
+	api.PathPrefix("/compress").Handler(bodyRoute(heavy.limit(compressHandler), "/api/compress")).Methods("POST")
+	api.PathPrefix("/extract").Handler(fileRoute(heavy.limit(extractHandler), "/api/extract")).Methods("POST")
+	api.PathPrefix("/touch").Handler(fileRoute(withAlias(touchHandler), "/api/touch")).Methods("POST")
+	api.PathPrefix("/fetch").Handler(fileRoute(fetchHandler, "/api/fetch")).Methods("POST")
+	api.PathPrefix("/symlink").Handler(fileRoute(withAlias(symlinkHandler), "/api/symlink")).Methods("POST")
+	api.PathPrefix("/search").Handler(fileRoute(withAlias(heavy.limit(searchHandler(searchIndex))), "/api/search")).Methods("GET")
+	api.PathPrefix("/select").Handler(fileRoute(withAlias(selectHandler), "/api/select")).Methods("GET")
+	api.PathPrefix("/duplicates").Handler(fileRoute(withAlias(heavy.limit(duplicatesHandler)), "/api/duplicates")).Methods("GET")
+	api.PathPrefix("/backlinks").Handler(fileRoute(withAlias(heavy.limit(backlinksHandler)), "/api/backlinks")).Methods("GET")
 
 	public := api.PathPrefix("/public").Subrouter()
-	public.PathPrefix("/dl").Handler(monkey(publicDlHandler, "/api/public/dl/")).Methods("GET")
-	public.PathPrefix("/share").Handler(monkey(publicShareHandler, "/api/public/share/")).Methods("GET")
+	public.PathPrefix("/dl").Handler(fileRoute(publicDlHandler(heavy), "/api/public/dl/")).Methods("GET")
+	public.PathPrefix("/share").Queries("qr", "true").
+		Handler(fileRoute(qrShareHandler(fileCache, server.QRSize), "/api/public/share/")).Methods("GET")
+	public.PathPrefix("/share").Handler(fileRoute(publicShareHandler, "/api/public/share/")).Methods("GET")
+	public.PathPrefix("/token").Handler(fileRoute(publicTokenHandler(heavy), "/api/public/token/")).Methods("GET")
 
-	return stripPrefix(server.BaseURL, r), nil
+	return stripPrefix(server.BaseURL, corsHandler(r, server.CORSOrigins)), nil
 }
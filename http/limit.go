@@ -0,0 +1,60 @@
+package http
+
+import (
+	"net/http"
+	"time"
+)
+
+// heavyOpLimiter limits the number of expensive operations, such as
+// building archives, generating thumbnails or walking directories, that
+// run at the same time. Requests over the limit wait for a free slot and
+// are rejected with 503 if none is freed before the timeout. A request
+// holding a slot doesn't take another one.
+type heavyOpLimiter struct {
+	slots   chan struct{}
+	timeout time.Duration
+}
+
+// newHeavyOpLimiter returns a limiter allowing max concurrent operations.
+// It returns nil, which doesn't limit anything, if max is not positive.
+func newHeavyOpLimiter(max int, timeout time.Duration) *heavyOpLimiter {
+	if max <= 0 {
+		return nil
+	}
+
+	return &heavyOpLimiter{
+		slots:   make(chan struct{}, max),
+		timeout: timeout,
+	}
+}
+
+func (l *heavyOpLimiter) limit(fn handleFunc) handleFunc {
+	if l == nil {
+		return fn
+	}
+
+	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if d.heavySlot {
+			return fn(w, r, d)
+		}
+
+		timer := time.NewTimer(l.timeout)
+		defer timer.Stop()
+
+		select {
+		case l.slots <- struct{}{}:
+		case <-timer.C:
+			w.Header().Set("Retry-After", "1")
+			return http.StatusServiceUnavailable, nil
+		case <-r.Context().Done():
+			return http.StatusServiceUnavailable, r.Context().Err()
+		}
+		d.heavySlot = true
+		defer func() {
+			d.heavySlot = false
+			<-l.slots
+		}()
+
+		return fn(w, r, d)
+	}
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeavyOpLimiter(t *testing.T) {
+	const max = 2
+
+	limiter := newHeavyOpLimiter(max, 50*time.Millisecond)
+
+	started := make(chan struct{}, max)
+	release := make(chan struct{})
+	fn := limiter.limit(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		started <- struct{}{}
+		<-release
+		return http.StatusOK, nil
+	})
+
+	var wg sync.WaitGroup
+	statuses := make(chan int, max)
+	for i := 0; i < max; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status, _ := fn(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), &data{})
+			statuses <- status
+		}()
+	}
+	for i := 0; i < max; i++ {
+		<-started
+	}
+
+	// Every slot is taken, so the next request times out.
+	status, err := fn(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), &data{})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusServiceUnavailable, status)
+
+	close(release)
+	wg.Wait()
+	close(statuses)
+	for status := range statuses {
+		require.Equal(t, http.StatusOK, status)
+	}
+
+	// Slots are freed once the operations are done.
+	status, _ = fn(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), &data{})
+	require.Equal(t, http.StatusOK, status)
+}
+
+func TestHeavyOpLimiterQueues(t *testing.T) {
+	limiter := newHeavyOpLimiter(1, time.Second)
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	fn := limiter.limit(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if r.URL.Path == "/first" {
+			close(started)
+			<-release
+		}
+		return http.StatusOK, nil
+	})
+
+	go func() {
+		_, _ = fn(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/first", nil), &data{})
+	}()
+	<-started
+
+	time.AfterFunc(20*time.Millisecond, func() { close(release) })
+
+	// The second request waits for the first one instead of failing.
+	status, _ := fn(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/second", nil), &data{})
+	require.Equal(t, http.StatusOK, status)
+}
+
+func TestHeavyOpLimiterDisabled(t *testing.T) {
+	limiter := newHeavyOpLimiter(0, 0)
+	require.Nil(t, limiter)
+
+	fn := limiter.limit(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		return http.StatusOK, nil
+	})
+	status, _ := fn(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), &data{})
+	require.Equal(t, http.StatusOK, status)
+}
+
+func TestHeavyOpLimiterNested(t *testing.T) {
+	limiter := newHeavyOpLimiter(1, 50*time.Millisecond)
+
+	inner := limiter.limit(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		return http.StatusOK, nil
+	})
+	outer := limiter.limit(inner)
+
+	// The request already holds the only slot, so the inner operation
+	// doesn't wait for it.
+	status, _ := outer(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil), &data{})
+	require.Equal(t, http.StatusOK, status)
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang.org/x/text/language"
+)
+
+// uiLocales are the locales the frontend has a catalog of, in
+// frontend/src/i18n, English first as it's the fallback.
+var uiLocales = []string{
+	"en", "ar", "de", "es", "fr", "is", "it", "ja", "ko", "nl-be",
+	"pl", "pt", "pt-br", "ro", "ru", "sv-se", "zh-cn", "zh-tw",
+}
+
+// offeredLocales returns the locales of uiLocales the interface is
+// offered in: all of them, or the configured ones. English is always
+// offered, as the catalogs fall back to it.
+func offeredLocales(configured []string) []string {
+	if len(configured) == 0 {
+		return uiLocales
+	}
+
+	offered := []string{"en"}
+	for _, locale := range uiLocales[1:] {
+		for _, c := range configured {
+			if strings.EqualFold(c, locale) {
+				offered = append(offered, locale)
+				break
+			}
+		}
+	}
+	return offered
+}
+
+// requestLocale returns the offered locale the request is best shown in,
+// matching the "lang" parameter, or else the Accept-Language header, and
+// falling back to English. The second result tells if the locale was
+// asked for with the parameter, which takes precedence over the locale of
+// the user.
+func requestLocale(r *http.Request, offered []string) (locale string, explicit bool) {
+	tags := make([]language.Tag, len(offered))
+	for i, locale := range offered {
+		tags[i] = language.Make(locale)
+	}
+	matcher := language.NewMatcher(tags)
+
+	if lang := r.URL.Query().Get("lang"); lang != "" {
+		if _, index, confidence := matcher.Match(language.Make(lang)); confidence != language.No {
+			return offered[index], true
+		}
+	}
+
+	accepted := acceptedLanguages(r.Header.Get("Accept-Language"))
+	if _, index, confidence := matcher.Match(accepted...); len(accepted) > 0 && confidence != language.No {
+		return offered[index], false
+	}
+	return offered[0], false
+}
+
+// acceptedLanguages parses an Accept-Language header, most preferred
+// first. Unlike language.ParseAcceptLanguage, an unknown or malformed
+// language only drops that one.
+func acceptedLanguages(header string) []language.Tag {
+	type accepted struct {
+		tag language.Tag
+		q   float32
+	}
+
+	var languages []accepted
+	for _, part := range strings.Split(header, ",") {
+		tags, q, err := language.ParseAcceptLanguage(part)
+		if err != nil || len(tags) != 1 {
+			continue
+		}
+		languages = append(languages, accepted{tags[0], q[0]})
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].q > languages[j].q
+	})
+
+	tags := make([]language.Tag, len(languages))
+	for i, l := range languages {
+		tags[i] = l.tag
+	}
+	return tags
+}
@@ -0,0 +1,46 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestLocale(t *testing.T) {
+	testCases := map[string]struct {
+		query          string
+		acceptLanguage string
+		configured     []string
+		locale         string
+		explicit       bool
+	}{
+		"default":            {locale: "en"},
+		"accept language":    {acceptLanguage: "fr-CA,fr;q=0.9,en;q=0.5", locale: "fr"},
+		"region":             {acceptLanguage: "pt-BR", locale: "pt-br"},
+		"preferred":          {acceptLanguage: "xx, de;q=0.8, es;q=0.9", locale: "es"},
+		"unknown":            {acceptLanguage: "xx", locale: "en"},
+		"lang":               {query: "?lang=ja", acceptLanguage: "fr", locale: "ja", explicit: true},
+		"unknown lang":       {query: "?lang=xx", acceptLanguage: "fr", locale: "fr"},
+		"not offered":        {acceptLanguage: "fr", configured: []string{"de"}, locale: "en"},
+		"offered":            {acceptLanguage: "fr, de;q=0.5", configured: []string{"DE"}, locale: "de"},
+		"lang not offered":   {query: "?lang=fr", configured: []string{"de"}, locale: "en"},
+		"malformed header":   {acceptLanguage: ";;;", locale: "en"},
+		"chinese":            {acceptLanguage: "zh-TW", locale: "zh-tw"},
+		"simplified chinese": {acceptLanguage: "zh", locale: "zh-cn"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/"+tc.query, nil)
+			if tc.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tc.acceptLanguage)
+			}
+
+			locale, explicit := requestLocale(r, offeredLocales(tc.configured))
+			assert.Equal(t, tc.locale, locale)
+			assert.Equal(t, tc.explicit, explicit)
+		})
+	}
+}
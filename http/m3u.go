@@ -0,0 +1,47 @@
+package http
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+// renderM3U renders an M3U8 playlist of the audio and video files of the
+// listing, in its order, so a whole directory can be opened in a media
+// player. The entries are the absolute URLs of the raw files, carrying the
+// token of the request since players can't log in. The durations of the
+// files aren't known, so there are no #EXTINF lines.
+func renderM3U(w http.ResponseWriter, r *http.Request, d *data, dir *files.FileInfo) (int, error) {
+	params := url.Values{"inline": {"true"}}
+	if token, err := (extractor{}).ExtractToken(r); err == nil {
+		params.Set("auth", token)
+	}
+
+	var playlist strings.Builder
+	playlist.WriteString("#EXTM3U\n")
+	for _, item := range dir.Items {
+		if item.IsDir || (item.Type != "audio" && item.Type != "video") {
+			continue
+		}
+
+		link := pageURL(r, d, "/api/raw", item.Path, false) + "?" + params.Encode()
+		playlist.WriteString(link + "\n")
+	}
+
+	w.Header().Set("Content-Type", "audio/x-mpegurl; charset=utf-8")
+	w.Header().Set("Content-Disposition", "inline; filename*=utf-8''"+url.PathEscape(playlistName(dir)))
+	if _, err := w.Write([]byte(playlist.String())); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return 0, nil
+}
+
+func playlistName(dir *files.FileInfo) string {
+	name := dir.Name
+	if name == "" || name == "/" || name == "." {
+		name = "playlist"
+	}
+	return name + ".m3u8"
+}
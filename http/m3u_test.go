@@ -0,0 +1,32 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestM3U(t *testing.T) {
+	d := newTestData(t, map[string]string{
+		"/music/b song.mp3": "",
+		"/music/a.ogg":      "",
+		"/music/clip.mp4":   "",
+		"/music/cover.jpg":  "",
+		"/music/sub/c.mp3":  "",
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "http://example.com/api/resources/music/?format=m3u&auth=token", nil)
+	r.URL.Path = "/music/"
+	w := httptest.NewRecorder()
+
+	status, err := resourceGet(w, r, d, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, status)
+	require.Equal(t, "audio/x-mpegurl; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Equal(t, "#EXTM3U\n"+
+		"http://example.com/api/raw/music/a.ogg?auth=token&inline=true\n"+
+		"http://example.com/api/raw/music/b%20song.mp3?auth=token&inline=true\n"+
+		"http://example.com/api/raw/music/clip.mp4?auth=token&inline=true\n", w.Body.String())
+}
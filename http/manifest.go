@@ -0,0 +1,81 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/fileutils"
+)
+
+type manifestEntry struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
+}
+
+type downloadManifest struct {
+	Files   []manifestEntry `json:"files"`
+	Count   int             `json:"count"`
+	Total   int64           `json:"total"`
+	Skipped []string        `json:"skipped"`
+}
+
+// manifestHandler lists the files the archive downloaded from /api/raw
+// with the same path and "files" parameter would include, with their
+// sizes, so clients can confirm large downloads. The files which can't be
+// read are skipped and listed apart.
+var manifestHandler = withUser(manifestGet)
+
+func manifestGet(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if !d.user.Perm.Download {
+		return http.StatusForbidden, nil
+	}
+
+	file, err := files.NewFileInfo(files.FileOptions{
+		Fs:             d.user.Fs,
+		Path:           r.URL.Path,
+		Checker:        d,
+		FollowSymlinks: d.server.FollowSymlinks,
+	})
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	if !file.IsDir {
+		return renderJSON(w, r, downloadManifest{
+			Files:   []manifestEntry{{Name: file.Name, Size: file.Size}},
+			Count:   1,
+			Total:   file.Size,
+			Skipped: []string{},
+		})
+	}
+
+	filenames, err := parseQueryFiles(r, file, d.user)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	manifest := downloadManifest{Files: []manifestEntry{}, Skipped: []string{}}
+	commonDir := fileutils.CommonPrefix('/', filenames...)
+
+	for _, fname := range filenames {
+		err := walkDownload(d, fname, commonDir, func(_, name string, info os.FileInfo) error {
+			if !info.IsDir() {
+				manifest.Files = append(manifest.Files, manifestEntry{Name: name, Size: info.Size()})
+				manifest.Total += info.Size()
+			}
+			return nil
+		}, func(path string, err error) error {
+			log.Printf("download manifest: skipping %s: %v", path, err)
+			manifest.Skipped = append(manifest.Skipped, path)
+			return nil
+		})
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+
+	manifest.Count = len(manifest.Files)
+	return renderJSON(w, r, manifest)
+}
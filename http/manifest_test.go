@@ -0,0 +1,52 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/rules"
+)
+
+func TestManifest(t *testing.T) {
+	d := newTestData(t, map[string]string{
+		"/dir/a.txt":        "aaa",
+		"/dir/sub/b.txt":    "bb",
+		"/dir/hidden/c.txt": "c",
+		"/dir/other/d.txt":  "dddd",
+		"/outside/e.txt":    "e",
+	})
+	d.user.Perm.Download = true
+	d.user.Rules = []rules.Rule{{Path: "/dir/hidden", Allow: false}}
+
+	get := func(target string) downloadManifest {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		w := httptest.NewRecorder()
+		status, err := manifestGet(w, r, d)
+		require.NoError(t, err)
+		require.Equal(t, 0, status)
+
+		var manifest downloadManifest
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&manifest))
+		return manifest
+	}
+
+	manifest := get("/dir")
+	require.ElementsMatch(t, []manifestEntry{
+		{Name: "a.txt", Size: 3},
+		{Name: "sub/b.txt", Size: 2},
+		{Name: "other/d.txt", Size: 4},
+	}, manifest.Files)
+	require.Equal(t, 3, manifest.Count)
+	require.Equal(t, int64(9), manifest.Total)
+
+	manifest = get("/dir?files=a.txt,sub")
+	require.ElementsMatch(t, []manifestEntry{{Name: "a.txt", Size: 3}, {Name: "sub/b.txt", Size: 2}}, manifest.Files)
+
+	manifest = get("/dir?files=missing.txt")
+	require.Empty(t, manifest.Files)
+	require.Equal(t, []string{"/dir/missing.txt"}, manifest.Skipped)
+}
@@ -0,0 +1,51 @@
+package http
+
+import (
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// metaHeaderPrefix is the prefix of the headers whose values are stored
+// along with the uploaded files and sent back when they are downloaded.
+// The server doesn't interpret them, so they can carry client-side
+// encryption parameters such as IVs or key ids.
+const metaHeaderPrefix = "X-File-Meta-"
+
+const (
+	maxMetaHeaders   = 32
+	maxMetaValueSize = 1024
+	maxMetaSize      = 8 * 1024
+)
+
+// parseMetaHeaders returns the metadata headers of the request. It
+// returns false if they are over the size limits.
+func parseMetaHeaders(r *http.Request) (map[string]string, bool) {
+	meta := map[string]string{}
+	size := 0
+
+	for name, values := range r.Header {
+		if !strings.HasPrefix(name, metaHeaderPrefix) || len(name) == len(metaHeaderPrefix) {
+			continue
+		}
+
+		value := strings.Join(values, ",")
+		size += len(name) + len(value)
+		if len(value) > maxMetaValueSize || size > maxMetaSize || len(meta) == maxMetaHeaders {
+			return nil, false
+		}
+
+		meta[name] = value
+	}
+
+	return meta, true
+}
+
+func setMetaHeaders(w http.ResponseWriter, meta map[string]string) {
+	for name, value := range meta {
+		name = textproto.CanonicalMIMEHeaderKey(name)
+		if strings.HasPrefix(name, metaHeaderPrefix) {
+			w.Header().Set(name, value)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+// isMobile tells if the request comes from a mobile device: as the
+// "mobile" parameter says, such as mobile=1, or else if the user agent
+// has "Mobi" in it, as the mobile browsers do.
+func isMobile(r *http.Request) bool {
+	if value := r.URL.Query().Get("mobile"); value != "" {
+		if mobile, err := strconv.ParseBool(value); err == nil {
+			return mobile
+		}
+	}
+
+	return strings.Contains(r.Header.Get("User-Agent"), "Mobi")
+}
+
+// requestPreviewLimits returns the preview limits of the request, the
+// ones of the mobile devices lowering the others for them.
+func (d *data) requestPreviewLimits(w http.ResponseWriter, r *http.Request) files.PreviewLimits {
+	limits := d.previewLimits()
+
+	max := d.server.MobileLimits.PreviewMaxSize
+	if max <= 0 {
+		return limits
+	}
+	if !strings.Contains(strings.Join(w.Header().Values("Vary"), ","), "User-Agent") {
+		w.Header().Add("Vary", "User-Agent")
+	}
+	if !isMobile(r) {
+		return limits
+	}
+	return limits.Capped(max)
+}
@@ -0,0 +1,60 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+const iphoneUserAgent = "Mozilla/5.0 (iPhone; CPU iPhone OS 16_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.0 Mobile/15E148 Safari/604.1"
+
+func TestIsMobile(t *testing.T) {
+	for _, tc := range []struct {
+		target, userAgent string
+		mobile            bool
+	}{
+		{"/", iphoneUserAgent, true},
+		{"/", "Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/115.0", false},
+		{"/", "", false},
+		{"/?mobile=1", "", true},
+		{"/?mobile=false", iphoneUserAgent, false},
+		{"/?mobile=maybe", iphoneUserAgent, true},
+	} {
+		r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+		r.Header.Set("User-Agent", tc.userAgent)
+		assert.Equal(t, tc.mobile, isMobile(r), "%s %q", tc.target, tc.userAgent)
+	}
+}
+
+func TestRequestPreviewLimits(t *testing.T) {
+	d := newTestData(t, nil)
+	d.server.PreviewMaxSizes = map[string]int64{"log": 4 << 20, "json": 1024}
+
+	limits := func(userAgent string) (files.PreviewLimits, http.Header) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("User-Agent", userAgent)
+		return d.requestPreviewLimits(w, r), w.Header()
+	}
+
+	got, header := limits(iphoneUserAgent)
+	assert.Equal(t, d.previewLimits(), got, "no mobile limits")
+	assert.Empty(t, header.Values("Vary"))
+
+	d.server.MobileLimits.PreviewMaxSize = 512 << 10
+	got, header = limits(iphoneUserAgent)
+	require.Equal(t, files.PreviewLimits{
+		Default:    512 << 10,
+		ByCategory: map[string]int64{"log": 512 << 10, "json": 1024},
+	}, got)
+	assert.Equal(t, []string{"User-Agent"}, header.Values("Vary"))
+
+	got, header = limits("Mozilla/5.0 (X11; Linux x86_64)")
+	assert.Equal(t, d.previewLimits(), got)
+	assert.Equal(t, []string{"User-Agent"}, header.Values("Vary"), "desktop responses vary too")
+}
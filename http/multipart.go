@@ -0,0 +1,108 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+// isMultipartUpload tells if the request uploads files as a
+// multipart/form-data body.
+func isMultipartUpload(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// multipartFile is a file of a multipart upload, written to its temporary
+// path until all of them are.
+type multipartFile struct {
+	tmpPath string
+	path    string
+}
+
+// multipartUpload writes the file parts of a multipart upload into dir.
+// The files are only moved into place once all the parts are read, so a
+// request with more files than allowed, or failing midway, writes none of
+// them.
+func multipartUpload(r *http.Request, d *data, dir string) (int, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	var uploaded []multipartFile
+	defer func() {
+		// Only the files left out of the upload are still temporary.
+		for _, file := range uploaded {
+			_ = d.user.Fs.Remove(file.tmpPath)
+		}
+	}()
+
+	override := r.URL.Query().Get("override") == "true"
+	nameMax := files.NameMax(d.user.Fs, dir)
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return http.StatusBadRequest, err
+		}
+		if part.FileName() == "" {
+			continue
+		}
+
+		if d.server.MaxUploadFiles > 0 && len(uploaded) == d.server.MaxUploadFiles {
+			return http.StatusBadRequest, fmt.Errorf("more than %d files: %w", d.server.MaxUploadFiles, errors.ErrInvalidRequestParams)
+		}
+
+		fPath := path.Join(dir, path.Base(path.Clean("/"+part.FileName())))
+		if !d.Check(fPath) {
+			return http.StatusForbidden, nil
+		}
+		if err := files.CheckNameLength(fPath, nameMax); err != nil { //nolint:govet
+			return errToStatus(err), err
+		}
+		if !uploadExtensionAllowed(fPath, d.server.UploadAllowExtensions, d.server.UploadBlockExtensions) {
+			return http.StatusUnsupportedMediaType, nil
+		}
+		if !override {
+			if _, err := d.user.Fs.Stat(fPath); err == nil { //nolint:govet
+				return http.StatusConflict, nil
+			}
+		}
+
+		tmpPath, err := files.UploadTempPath(fPath, nameMax)
+		if err != nil {
+			return errToStatus(err), err
+		}
+		uploaded = append(uploaded, multipartFile{tmpPath: tmpPath, path: fPath})
+		if _, err := writeUpload(d.user.Fs, tmpPath, part, nil); err != nil {
+			return errToStatus(err), err
+		}
+	}
+
+	for len(uploaded) > 0 {
+		file := uploaded[0]
+		err := d.RunHook(func() error {
+			defer lockPaths(d, file.path)()
+
+			if d.server.KeepVersions > 0 {
+				snapshotVersion(d, file.path)
+			}
+			return d.user.Fs.Rename(file.tmpPath, file.path)
+		}, "upload", file.path, "", d.user)
+		if err != nil {
+			return errToStatus(err), err
+		}
+		uploaded = uploaded[1:]
+	}
+
+	return http.StatusOK, nil
+}
@@ -0,0 +1,49 @@
+package http
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMultipartUpload(t *testing.T) {
+	d := newTestData(t, map[string]string{"/up/.keep": ""})
+	d.server.MaxUploadFiles = 2
+
+	upload := func(names ...string) int {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		require.NoError(t, writer.WriteField("comment", "not a file"))
+		for _, name := range names {
+			part, err := writer.CreateFormFile("files", name)
+			require.NoError(t, err)
+			_, err = part.Write([]byte(name))
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+
+		r := httptest.NewRequest(http.MethodPost, "/up/", &body)
+		r.Header.Set("Content-Type", writer.FormDataContentType())
+		status, _ := resourcePostPut(httptest.NewRecorder(), r, d)
+		return status
+	}
+
+	require.Equal(t, http.StatusBadRequest, upload("a.txt", "b.txt", "c.txt"))
+	require.Equal(t, map[string]string{"/up/": "", "/up/.keep": ""}, fileTree(t, d), "nothing is written")
+
+	require.Equal(t, http.StatusOK, upload("a.txt", "../b.txt"))
+	require.Equal(t, map[string]string{
+		"/up/":      "",
+		"/up/.keep": "",
+		"/up/a.txt": "a.txt",
+		"/up/b.txt": "../b.txt",
+	}, fileTree(t, d))
+
+	require.Equal(t, http.StatusConflict, upload("c.txt", "a.txt"))
+	_, err := d.user.Fs.Stat("/up/c.txt")
+	require.Error(t, err, "a conflict writes none of the files")
+}
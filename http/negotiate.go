@@ -0,0 +1,118 @@
+package http
+
+import (
+	"bytes"
+	"html"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+// Representations of a markdown file.
+const (
+	markdownJSON = "application/json"
+	markdownHTML = "text/html"
+	markdownRaw  = "text/plain"
+)
+
+func isMarkdown(file *files.FileInfo) bool {
+	ext := strings.ToLower(file.Extension)
+	return !file.IsDir && (ext == ".md" || ext == ".markdown")
+}
+
+// negotiateMarkdown returns the representation of a markdown file the
+// request asks for: its source with the "raw" parameter, otherwise the
+// acceptable one with the highest quality. The metadata, as for any
+// other file, is the default.
+func negotiateMarkdown(r *http.Request) string {
+	if _, ok := r.URL.Query()["raw"]; ok {
+		return markdownRaw
+	}
+
+	for _, mediaType := range acceptedMediaTypes(r) {
+		switch mediaType {
+		case markdownJSON, "*/*", "application/*":
+			return markdownJSON
+		case markdownHTML:
+			return markdownHTML
+		case markdownRaw, "text/markdown":
+			return markdownRaw
+		}
+	}
+
+	return markdownJSON
+}
+
+// acceptedMediaTypes returns the media ranges of the Accept header of the
+// request, the ones of the highest quality first. The refused ones, of
+// quality 0, are left out.
+func acceptedMediaTypes(r *http.Request) []string {
+	type mediaRange struct {
+		mediaType string
+		quality   float64
+	}
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				continue
+			}
+		}
+
+		if quality > 0 {
+			ranges = append(ranges, mediaRange{mediaType, quality})
+		}
+	}
+
+	// The first of the media ranges of the highest quality wins.
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return ranges[i].quality > ranges[j].quality
+	})
+
+	mediaTypes := make([]string, len(ranges))
+	for i, mr := range ranges {
+		mediaTypes[i] = mr.mediaType
+	}
+	return mediaTypes
+}
+
+// serveMarkdown serves a markdown file rendered to HTML or as its exact
+// source, depending on the representation.
+func serveMarkdown(w http.ResponseWriter, r *http.Request, file *files.FileInfo, representation string) (int, error) {
+	content, err := afero.ReadFile(file.Fs, file.Path)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	if representation == markdownRaw {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		http.ServeContent(w, r, file.Name, file.ModTime, bytes.NewReader(content))
+		return 0, nil
+	}
+
+	// The rendered markdown has no scripts, the policy makes sure none run.
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "script-src 'none'")
+	page := "<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n<title>" + html.EscapeString(file.Name) +
+		"</title>\n</head>\n<body>\n" + files.RenderMarkdown(content) + "</body>\n</html>\n"
+	if _, err := w.Write([]byte(page)); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return 0, nil
+}
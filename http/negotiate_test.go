@@ -0,0 +1,63 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestNegotiateMarkdown(t *testing.T) {
+	testCases := map[string]struct {
+		target, accept string
+		want           string
+	}{
+		"no accept":         {"/a.md", "", markdownJSON},
+		"any":               {"/a.md", "*/*", markdownJSON},
+		"json":              {"/a.md", "application/json", markdownJSON},
+		"html":              {"/a.md", "text/html", markdownHTML},
+		"browser":           {"/a.md", "text/html,application/xhtml+xml,*/*;q=0.8", markdownHTML},
+		"plain":             {"/a.md", "text/plain", markdownRaw},
+		"markdown":          {"/a.md", "text/markdown", markdownRaw},
+		"quality":           {"/a.md", "text/html;q=0.5, text/plain", markdownRaw},
+		"refused":           {"/a.md", "text/html;q=0", markdownJSON},
+		"raw parameter":     {"/a.md?raw", "text/html", markdownRaw},
+		"raw with value":    {"/a.md?raw=true", "application/json", markdownRaw},
+		"unsupported first": {"/a.md", "image/png, text/html;q=0.9", markdownHTML},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			r.Header.Set("Accept", tc.accept)
+			assert.Equal(t, tc.want, negotiateMarkdown(r))
+		})
+	}
+}
+
+func TestServeMarkdown(t *testing.T) {
+	source := "# Title\n\n<script>alert(1)</script>\n[link](javascript:alert(1))\n"
+	fs := testutil.NewFs(t, map[string]string{"/a.md": source})
+
+	file, err := files.NewFileInfo(files.FileOptions{Fs: fs, Path: "/a.md", Checker: testutil.AllowAll{}})
+	require.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	_, err = serveMarkdown(w, httptest.NewRequest(http.MethodGet, "/a.md", nil), file, markdownRaw)
+	require.NoError(t, err)
+	assert.Equal(t, "text/markdown; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, source, w.Body.String(), "the source is served byte by byte")
+
+	w = httptest.NewRecorder()
+	_, err = serveMarkdown(w, httptest.NewRequest(http.MethodGet, "/a.md", nil), file, markdownHTML)
+	require.NoError(t, err)
+	assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<h1>Title</h1>")
+	assert.NotContains(t, w.Body.String(), "<script>")
+	assert.NotContains(t, w.Body.String(), "javascript:")
+}
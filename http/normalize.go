@@ -0,0 +1,68 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+// Headers of the saves overriding how the text is normalized.
+const (
+	lineEndingsHeader  = "X-Line-Endings"
+	finalNewlineHeader = "X-Final-Newline"
+)
+
+// saveNormalization returns how the content of the save is normalized:
+// as the headers of the request ask, or else as configured.
+func saveNormalization(r *http.Request, d *data) (files.TextNormalization, error) {
+	normalization := files.TextNormalization{
+		LineEndings:  d.server.SaveLineEndings,
+		FinalNewline: d.server.SaveFinalNewline,
+	}
+
+	if value := r.Header.Get(lineEndingsHeader); value != "" {
+		lineEndings, err := files.ParseLineEndings(value)
+		if err != nil {
+			return files.TextNormalization{}, err
+		}
+		normalization.LineEndings = lineEndings
+	}
+
+	if value := r.Header.Get(finalNewlineHeader); value != "" {
+		finalNewline, err := strconv.ParseBool(value)
+		if err != nil {
+			return files.TextNormalization{}, fmt.Errorf("invalid %s header: %w", finalNewlineHeader, errors.ErrInvalidRequestParams)
+		}
+		normalization.FinalNewline = finalNewline
+	}
+
+	return normalization, nil
+}
+
+// normalizeBody reads the whole body and returns it normalized. The
+// checksum, if any, is the one of the content sent, so it's verified
+// before the content is changed.
+func normalizeBody(body io.Reader, checksum *uploadChecksum, normalization files.TextNormalization) (io.Reader, error) {
+	if checksum != nil {
+		body = io.TeeReader(body, checksum)
+	}
+
+	content, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+
+	if checksum != nil {
+		if err := checksum.verify(); err != nil {
+			return nil, err
+		}
+	}
+
+	return bytes.NewReader(normalization.Apply(content)), nil
+}
@@ -0,0 +1,152 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+// officeExtensions are the extensions of the documents that can be
+// converted to PDF to be previewed.
+var officeExtensions = map[string]bool{
+	".doc":  true,
+	".docx": true,
+	".odt":  true,
+	".rtf":  true,
+	".xls":  true,
+	".xlsx": true,
+	".ods":  true,
+	".ppt":  true,
+	".pptx": true,
+	".odp":  true,
+}
+
+func isOfficeDocument(ext string) bool {
+	return officeExtensions[strings.ToLower(ext)]
+}
+
+// officePreviewHandler serves office documents converted to PDF by the
+// given LibreOffice binary. Without a converter, or for other files, the
+// raw file is sent as a download instead.
+func officePreviewHandler(fileCache FileCache, converter string, heavy *heavyOpLimiter) handleFunc {
+	raw := rawHandler(heavy)
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if !d.user.Perm.Download {
+			return http.StatusAccepted, nil
+		}
+
+		if converter == "" || !isOfficeDocument(filepath.Ext(r.URL.Path)) {
+			query := r.URL.Query()
+			query.Del("inline")
+			r.URL.RawQuery = query.Encode()
+			return raw(w, r, d)
+		}
+
+		file, err := files.NewFileInfo(files.FileOptions{
+			Fs:             d.user.Fs,
+			Path:           r.URL.Path,
+			Modify:         d.user.Perm.Modify,
+			Expand:         false,
+			ReadHeader:     d.server.TypeDetectionByHeader,
+			Checker:        d,
+			FollowSymlinks: d.server.FollowSymlinks,
+		})
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		if file.IsDir || file.IsSpecial {
+			return raw(w, r, d)
+		}
+
+		cacheKey := officeCacheKey(file)
+		content, ok, err := fileCache.Load(r.Context(), cacheKey)
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		if !ok {
+			content, err = convertToPDF(r.Context(), converter, file)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			go func() {
+				if err := fileCache.Store(context.Background(), cacheKey, content); err != nil {
+					log.Printf("failed to cache converted document: %v", err)
+				}
+			}()
+		}
+
+		name := strings.TrimSuffix(file.Name, file.Extension) + ".pdf"
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", "inline; filename*=utf-8''"+url.PathEscape(name))
+		http.ServeContent(w, r, name, file.ModTime, bytes.NewReader(content))
+		return 0, nil
+	})
+}
+
+// officeCacheKey identifies the conversion of a version of a document, so
+// the cache misses once it's modified.
+func officeCacheKey(file *files.FileInfo) string {
+	return fmt.Sprintf("pdf:%s:%d", file.Path, file.ModTime.UnixNano())
+}
+
+// convertToPDF copies the document to a temporary directory, where the
+// converter writes the PDF next to it. Each conversion gets its own
+// LibreOffice profile, as concurrent instances can't share one.
+func convertToPDF(ctx context.Context, converter string, file *files.FileInfo) ([]byte, error) {
+	tmp, err := ioutil.TempDir("", "filebrowser-office")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	input := filepath.Join(tmp, "document"+strings.ToLower(file.Extension))
+	if err := copyToLocal(file, input); err != nil {
+		return nil, err
+	}
+
+	outDir := filepath.Join(tmp, "out")
+	profile := url.URL{Scheme: "file", Path: filepath.ToSlash(filepath.Join(tmp, "profile"))}
+
+	cmd := exec.CommandContext(ctx, converter, //nolint:gosec
+		"-env:UserInstallation="+profile.String(),
+		"--headless", "--convert-to", "pdf", "--outdir", outDir, input)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("converting %s to PDF: %w: %s", file.Path, err, bytes.TrimSpace(output))
+	}
+
+	return ioutil.ReadFile(filepath.Join(outDir, "document.pdf"))
+}
+
+func copyToLocal(file *files.FileInfo, dst string) error {
+	src, err := file.Fs.Open(file.Path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
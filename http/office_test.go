@@ -0,0 +1,60 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+// fakeConverter copies the input document to the output directory, as
+// LibreOffice would write the converted one.
+const fakeConverter = `#!/bin/sh
+while [ $# -gt 1 ]; do
+	if [ "$1" = --outdir ]; then out=$2; fi
+	shift
+done
+mkdir -p "$out" && cp "$1" "$out/document.pdf"
+`
+
+func TestConvertToPDF(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake converter is a shell script")
+	}
+
+	converter := filepath.Join(t.TempDir(), "soffice")
+	require.NoError(t, ioutil.WriteFile(converter, []byte(fakeConverter), 0755)) //nolint:gosec
+
+	fs := testutil.NewFs(t, map[string]string{"/docs/report.docx": "%PDF-1.4"})
+	file, err := files.NewFileInfo(files.FileOptions{
+		Fs:      fs,
+		Path:    "/docs/report.docx",
+		Checker: testutil.AllowAll{},
+	})
+	require.NoError(t, err)
+
+	content, err := convertToPDF(context.Background(), converter, file)
+	require.NoError(t, err)
+	require.Equal(t, "%PDF-1.4", string(content))
+
+	_, err = convertToPDF(context.Background(), filepath.Join(t.TempDir(), "missing"), file)
+	require.Error(t, err)
+}
+
+func TestOfficeCacheKey(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{"/report.docx": "v1"})
+	file, err := files.NewFileInfo(files.FileOptions{Fs: fs, Path: "/report.docx", Checker: testutil.AllowAll{}})
+	require.NoError(t, err)
+
+	key := officeCacheKey(file)
+	file.ModTime = file.ModTime.Add(1)
+	require.NotEqual(t, key, officeCacheKey(file))
+	require.True(t, isOfficeDocument(".DOCX"))
+	require.False(t, isOfficeDocument(".pdf"))
+}
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+// pdfThumbHandler serves the first page of PDFs rendered as a PNG by
+// pdftoppm, scaled to the thumbnail size. Other files are sent raw. When
+// there's no renderer or rendering fails, clients show a PDF icon instead.
+func pdfThumbHandler(fileCache FileCache, renderer string, thumbSize int, heavy *heavyOpLimiter) handleFunc {
+	raw := rawHandler(heavy)
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if !d.user.Perm.Download {
+			return http.StatusAccepted, nil
+		}
+
+		if !strings.EqualFold(filepath.Ext(r.URL.Path), ".pdf") {
+			return raw(w, r, d)
+		}
+
+		if renderer == "" {
+			return http.StatusNotImplemented, errors.New("no PDF renderer is configured")
+		}
+
+		file, err := files.NewFileInfo(files.FileOptions{
+			Fs:             d.user.Fs,
+			Path:           r.URL.Path,
+			Modify:         d.user.Perm.Modify,
+			Expand:         false,
+			ReadHeader:     d.server.TypeDetectionByHeader,
+			Checker:        d,
+			FollowSymlinks: d.server.FollowSymlinks,
+		})
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		if file.IsDir || file.IsSpecial {
+			return http.StatusBadRequest, fmt.Errorf("%s is not a PDF", file.Path)
+		}
+
+		cacheKey := pdfThumbCacheKey(file, thumbSize)
+		thumb, ok, err := fileCache.Load(r.Context(), cacheKey)
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		if !ok {
+			thumb, err = renderPDFThumb(r.Context(), renderer, file, thumbSize)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+
+			go func() {
+				if err := fileCache.Store(context.Background(), cacheKey, thumb); err != nil {
+					log.Printf("failed to cache PDF thumbnail: %v", err)
+				}
+			}()
+		}
+
+		name := strings.TrimSuffix(file.Name, file.Extension) + ".png"
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Disposition", "inline")
+		http.ServeContent(w, r, name, file.ModTime, bytes.NewReader(thumb))
+		return 0, nil
+	})
+}
+
+func pdfThumbCacheKey(file *files.FileInfo, thumbSize int) string {
+	return fmt.Sprintf("pdfthumb:%s:%d:%d", file.Path, file.ModTime.UnixNano(), thumbSize)
+}
+
+// renderPDFThumb renders the first page of the PDF with its longest side
+// scaled to size pixels.
+func renderPDFThumb(ctx context.Context, renderer string, file *files.FileInfo, size int) ([]byte, error) {
+	tmp, err := ioutil.TempDir("", "filebrowser-pdf")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmp)
+
+	input := filepath.Join(tmp, "document.pdf")
+	if err := copyToLocal(file, input); err != nil {
+		return nil, err
+	}
+
+	output := filepath.Join(tmp, "thumb")
+	cmd := exec.CommandContext(ctx, renderer, //nolint:gosec
+		"-png", "-f", "1", "-l", "1", "-singlefile", "-scale-to", strconv.Itoa(size), input, output)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("rendering %s: %w: %s", file.Path, err, bytes.TrimSpace(out))
+	}
+
+	return ioutil.ReadFile(output + ".png")
+}
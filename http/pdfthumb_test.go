@@ -0,0 +1,42 @@
+package http
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+// fakeRenderer writes the arguments it got to the PNG pdftoppm would.
+const fakeRenderer = `#!/bin/sh
+for arg; do out=$arg; done
+echo "$@" > "$out.png"
+`
+
+func TestRenderPDFThumb(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake renderer is a shell script")
+	}
+
+	renderer := filepath.Join(t.TempDir(), "pdftoppm")
+	require.NoError(t, ioutil.WriteFile(renderer, []byte(fakeRenderer), 0755)) //nolint:gosec
+
+	fs := testutil.NewFs(t, map[string]string{"/docs/paper.pdf": "%PDF-1.4"})
+	file, err := files.NewFileInfo(files.FileOptions{Fs: fs, Path: "/docs/paper.pdf", Checker: testutil.AllowAll{}})
+	require.NoError(t, err)
+
+	thumb, err := renderPDFThumb(context.Background(), renderer, file, 200)
+	require.NoError(t, err)
+	require.Contains(t, string(thumb), "-png -f 1 -l 1 -singlefile -scale-to 200 ")
+
+	_, err = renderPDFThumb(context.Background(), filepath.Join(t.TempDir(), "missing"), file, 200)
+	require.Error(t, err)
+
+	require.NotEqual(t, pdfThumbCacheKey(file, 128), pdfThumbCacheKey(file, 200))
+}
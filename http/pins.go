@@ -0,0 +1,67 @@
+package http
+
+import (
+	"net/http"
+	"path"
+
+	"github.com/filebrowser/filebrowser/v2/pins"
+)
+
+func pinsGetHandler(pinStore *pins.Storage) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if pinStore == nil {
+			return http.StatusNotFound, nil
+		}
+
+		pinned, err := pinStore.Get(d.user.ID)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		// Pins whose paths were removed, or are no longer allowed, are
+		// silently dropped.
+		valid := []string{}
+		for _, p := range pinned {
+			if !d.Check(p) {
+				continue
+			}
+			if _, err := d.user.Fs.Stat(p); err != nil {
+				continue
+			}
+			valid = append(valid, p)
+		}
+
+		return renderJSON(w, r, valid)
+	})
+}
+
+func pinPostHandler(pinStore *pins.Storage) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if pinStore == nil {
+			return http.StatusNotFound, nil
+		}
+
+		p := path.Clean("/" + r.URL.Path)
+		if !d.Check(p) {
+			return http.StatusForbidden, nil
+		}
+
+		if _, err := d.user.Fs.Stat(p); err != nil {
+			return errToStatus(err), err
+		}
+
+		err := pinStore.Add(d.user.ID, p)
+		return errToStatus(err), err
+	})
+}
+
+func pinDeleteHandler(pinStore *pins.Storage) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if pinStore == nil {
+			return http.StatusNotFound, nil
+		}
+
+		err := pinStore.Remove(d.user.ID, r.URL.Path)
+		return errToStatus(err), err
+	})
+}
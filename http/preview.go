@@ -33,7 +33,7 @@ type FileCache interface {
 	Delete(ctx context.Context, key string) error
 }
 
-func previewHandler(imgSvc ImgService, fileCache FileCache, enableThumbnails, resizePreview bool) handleFunc {
+func previewHandler(imgSvc ImgService, fileCache FileCache, enableThumbnails, resizePreview bool, thumbSize int) handleFunc {
 	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 		if !d.user.Perm.Download {
 			return http.StatusAccepted, nil
@@ -45,13 +45,19 @@ func previewHandler(imgSvc ImgService, fileCache FileCache, enableThumbnails, re
 			return http.StatusBadRequest, err
 		}
 
+		fPath, err := files.Aliases(d.server.Aliases).Resolve("/" + vars["path"])
+		if err != nil {
+			return errToStatus(err), err
+		}
+
 		file, err := files.NewFileInfo(files.FileOptions{
-			Fs:         d.user.Fs,
-			Path:       "/" + vars["path"],
-			Modify:     d.user.Perm.Modify,
-			Expand:     true,
-			ReadHeader: d.server.TypeDetectionByHeader,
-			Checker:    d,
+			Fs:             d.user.Fs,
+			Path:           fPath,
+			Modify:         d.user.Perm.Modify,
+			Expand:         true,
+			ReadHeader:     d.server.TypeDetectionByHeader,
+			Checker:        d,
+			FollowSymlinks: d.server.FollowSymlinks,
 		})
 		if err != nil {
 			return errToStatus(err), err
@@ -61,25 +67,40 @@ func previewHandler(imgSvc ImgService, fileCache FileCache, enableThumbnails, re
 
 		switch file.Type {
 		case "image":
-			return handleImagePreview(w, r, imgSvc, fileCache, file, previewSize, enableThumbnails, resizePreview)
+			return handleImagePreview(w, r, d, imgSvc, fileCache, file, previewSize, enableThumbnails, resizePreview, thumbSize)
 		default:
 			return http.StatusNotImplemented, fmt.Errorf("can't create preview for %s type", file.Type)
 		}
 	})
 }
 
-func handleImagePreview(w http.ResponseWriter, r *http.Request, imgSvc ImgService, fileCache FileCache,
-	file *files.FileInfo, previewSize PreviewSize, enableThumbnails, resizePreview bool) (int, error) {
+func handleImagePreview(w http.ResponseWriter, r *http.Request, d *data, imgSvc ImgService, fileCache FileCache,
+	file *files.FileInfo, previewSize PreviewSize, enableThumbnails, resizePreview bool, thumbSize int) (int, error) {
 	format, err := imgSvc.FormatFromExtension(file.Extension)
 	if err != nil {
 		// Unsupported extensions directly return the raw data
 		if err == img.ErrUnsupportedFormat {
-			return rawFileHandler(w, r, file)
+			return rawFileHandler(w, r, d, file)
 		}
 		return errToStatus(err), err
 	}
 
-	cacheKey := previewCacheKey(file.Path, previewSize)
+	// Mobile devices get smaller images, cached apart.
+	bigSize := 1080
+	mobile := false
+	if limits := d.server.MobileLimits; limits.ThumbSize > 0 || limits.BigPreviewSize > 0 {
+		w.Header().Add("Vary", "User-Agent")
+		if mobile = isMobile(r); mobile {
+			if limits.ThumbSize > 0 {
+				thumbSize = limits.ThumbSize
+			}
+			if limits.BigPreviewSize > 0 {
+				bigSize = limits.BigPreviewSize
+			}
+		}
+	}
+
+	cacheKey := previewCacheKey(file.Path, previewSize, mobile)
 	cachedFile, ok, err := fileCache.Load(r.Context(), cacheKey)
 	if err != nil {
 		return errToStatus(err), err
@@ -103,15 +124,15 @@ func handleImagePreview(w http.ResponseWriter, r *http.Request, imgSvc ImgServic
 
 	switch {
 	case previewSize == PreviewSizeBig && resizePreview && format != img.FormatGif:
-		width = 1080
-		height = 1080
+		width = bigSize
+		height = bigSize
 		options = append(options, img.WithMode(img.ResizeModeFit), img.WithQuality(img.QualityMedium))
 	case previewSize == PreviewSizeThumb && enableThumbnails:
-		width = 128
-		height = 128
+		width = thumbSize
+		height = thumbSize
 		options = append(options, img.WithMode(img.ResizeModeFill), img.WithQuality(img.QualityLow), img.WithFormat(img.FormatJpeg))
 	default:
-		if _, err := rawFileHandler(w, r, file); err != nil {
+		if _, err := rawFileHandler(w, r, d, file); err != nil {
 			return errToStatus(err), err
 		}
 		return 0, nil
@@ -133,6 +154,9 @@ func handleImagePreview(w http.ResponseWriter, r *http.Request, imgSvc ImgServic
 	return 0, nil
 }
 
-func previewCacheKey(fPath string, previewSize PreviewSize) string {
+func previewCacheKey(fPath string, previewSize PreviewSize, mobile bool) string {
+	if mobile {
+		return fPath + previewSize.String() + "-mobile"
+	}
 	return fPath + previewSize.String()
 }
@@ -9,6 +9,8 @@ import (
 	"github.com/spf13/afero"
 
 	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/share"
+	"github.com/filebrowser/filebrowser/v2/users"
 )
 
 var withHashFile = func(fn handleFunc) handleFunc {
@@ -27,12 +29,25 @@ var withHashFile = func(fn handleFunc) handleFunc {
 		d.user = user
 
 		file, err := files.NewFileInfo(files.FileOptions{
-			Fs:         d.user.Fs,
-			Path:       link.Path,
-			Modify:     d.user.Perm.Modify,
-			Expand:     true,
-			ReadHeader: d.server.TypeDetectionByHeader,
-			Checker:    d,
+			Fs:                    d.user.Fs,
+			Path:                  link.Path,
+			Modify:                d.user.Perm.Modify,
+			Expand:                true,
+			ReadHeader:            d.server.TypeDetectionByHeader,
+			Checker:               d,
+			FollowSymlinks:        d.server.FollowSymlinks,
+			Columns:               d.server.ListingColumns,
+			LargeFileThreshold:    d.server.LargeFileThreshold,
+			DirMTimeFromContents:  d.server.DirMTimeFromContents,
+			ImageDimensions:       d.server.ImageDimensions,
+			ShowChildCounts:       d.server.ShowChildCounts,
+			DisplayName:           d.displayName(),
+			PreviewLimits:         d.requestPreviewLimits(w, r),
+			CaseInsensitive:       d.server.CaseInsensitive,
+			GracefulPreviewErrors: d.server.GracefulPreviewErrors,
+			BinaryThreshold:       d.server.BinaryThreshold,
+			TextExtensions:        d.server.TextExtensions,
+			Locale:                d.server.Locale,
 		})
 		if err != nil {
 			return errToStatus(err), err
@@ -40,14 +55,27 @@ var withHashFile = func(fn handleFunc) handleFunc {
 
 		if file.IsDir {
 			// set fs root to the shared folder
-			d.user.Fs = afero.NewBasePathFs(d.user.Fs, filepath.Dir(link.Path))
+			d.user.Fs = subFs(d.user.Fs, filepath.Dir(link.Path))
 
 			file, err = files.NewFileInfo(files.FileOptions{
-				Fs:      d.user.Fs,
-				Path:    path,
-				Modify:  d.user.Perm.Modify,
-				Expand:  true,
-				Checker: d,
+				Fs:                    d.user.Fs,
+				Path:                  path,
+				Modify:                d.user.Perm.Modify,
+				Expand:                true,
+				Checker:               d,
+				FollowSymlinks:        d.server.FollowSymlinks,
+				Columns:               d.server.ListingColumns,
+				LargeFileThreshold:    d.server.LargeFileThreshold,
+				DirMTimeFromContents:  d.server.DirMTimeFromContents,
+				ImageDimensions:       d.server.ImageDimensions,
+				ShowChildCounts:       d.server.ShowChildCounts,
+				DisplayName:           d.displayName(),
+				PreviewLimits:         d.requestPreviewLimits(w, r),
+				CaseInsensitive:       d.server.CaseInsensitive,
+				GracefulPreviewErrors: d.server.GracefulPreviewErrors,
+				BinaryThreshold:       d.server.BinaryThreshold,
+				TextExtensions:        d.server.TextExtensions,
+				Locale:                d.server.Locale,
 			})
 			if err != nil {
 				return errToStatus(err), err
@@ -79,18 +107,68 @@ var publicShareHandler = withHashFile(func(w http.ResponseWriter, r *http.Reques
 
 	if file.IsDir {
 		file.Listing.Sorting = files.Sorting{By: "name", Asc: false}
+		if sorting, ok := files.ReadSortFile(d.user.Fs, file.Path); ok {
+			file.Listing.Sorting = sorting
+		}
 		file.Listing.ApplySort()
+		file.RenderReadme(d.server.ReadmeNames, d.server.ReadmeMaxSize)
 		return renderJSON(w, r, file)
 	}
 
 	return renderJSON(w, r, file)
 })
 
-var publicDlHandler = withHashFile(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
-	file := d.raw.(*files.FileInfo)
-	if !file.IsDir {
-		return rawFileHandler(w, r, file)
+func publicDlHandler(heavy *heavyOpLimiter) handleFunc {
+	return withHashFile(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		file := d.raw.(*files.FileInfo)
+		return rawFileOrDirHandler(w, r, d, file, heavy)
+	})
+}
+
+func publicTokenHandler(heavy *heavyOpLimiter) handleFunc {
+	return func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		token, _ := ifPathWithName(r)
+		link, err := share.ParseToken(d.settings.ShareKey, token)
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		user, err := d.store.Users.Get(d.server.Root, link.UserID)
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		d.user = user
+
+		file, err := files.NewFileInfo(files.FileOptions{
+			Fs:             d.user.Fs,
+			Path:           link.Path,
+			Modify:         d.user.Perm.Modify,
+			Expand:         false,
+			ReadHeader:     d.server.TypeDetectionByHeader,
+			Checker:        d,
+			FollowSymlinks: d.server.FollowSymlinks,
+		})
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		return rawFileOrDirHandler(w, r, d, file, heavy)
 	}
+}
 
-	return rawDirHandler(w, r, d, file)
-})
+// subFs returns a filesystem rooted at dir of fs. Scoped filesystems of
+// the local disk are rebased instead of nested so the real paths of their
+// files, needed to resolve symbolic links, can still be found. The other
+// roots, such as S3 or the timeout wrapper, are kept.
+func subFs(fs afero.Fs, dir string) afero.Fs {
+	if _, ok := users.RootFs.(*afero.OsFs); !ok {
+		return afero.NewBasePathFs(fs, dir)
+	}
+	if bfs, ok := fs.(*afero.BasePathFs); ok {
+		if realPath, err := bfs.RealPath(dir); err == nil {
+			return afero.NewBasePathFs(afero.NewOsFs(), realPath)
+		}
+	}
+	return afero.NewBasePathFs(fs, dir)
+}
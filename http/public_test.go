@@ -0,0 +1,40 @@
+package http
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func TestSubFs(t *testing.T) {
+	rootFs := users.RootFs
+	t.Cleanup(func() { users.RootFs = rootFs })
+
+	// On the local disk, the shared directory is rebased on its real path.
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "scope", "shared"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "scope", "shared", "a.txt"), []byte("disk"), 0644))
+	users.RootFs = afero.NewOsFs()
+	fs := subFs(afero.NewBasePathFs(users.RootFs, filepath.Join(dir, "scope")), "/shared")
+	realPath, err := fs.(*afero.BasePathFs).RealPath("/a.txt")
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "scope", "shared", "a.txt"), realPath)
+	content, err := afero.ReadFile(fs, "/a.txt")
+	require.NoError(t, err)
+	require.Equal(t, "disk", string(content))
+
+	// Other roots, such as a bucket, are nested so the files are still
+	// read from them, not from the local disk at the same path.
+	users.RootFs = afero.NewMemMapFs()
+	require.NoError(t, afero.WriteFile(users.RootFs, filepath.Join(dir, "scope", "shared", "a.txt"), []byte("bucket"), 0644))
+	fs = subFs(afero.NewBasePathFs(users.RootFs, filepath.Join(dir, "scope")), "/shared")
+	content, err = afero.ReadFile(fs, "/a.txt")
+	require.NoError(t, err)
+	require.Equal(t, "bucket", string(content))
+}
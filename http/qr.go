@@ -0,0 +1,89 @@
+package http
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+// qrResourceHandler renders a PNG QR code encoding the URL of the file
+// of the request path in the web interface, so it can be opened from a
+// phone by scanning the screen.
+func qrResourceHandler(fileCache FileCache, size int) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		file, err := files.NewFileInfo(files.FileOptions{
+			Fs:             d.user.Fs,
+			Path:           r.URL.Path,
+			Checker:        d,
+			FollowSymlinks: d.server.FollowSymlinks,
+		})
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		target := pageURL(r, d, "/files", file.Path, file.IsDir)
+		return renderQR(w, r, fileCache, target, size)
+	})
+}
+
+// qrShareHandler is like qrResourceHandler for the page of a share.
+func qrShareHandler(fileCache FileCache, size int) handleFunc {
+	return withHashFile(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		file := d.raw.(*files.FileInfo)
+		id, fPath := ifPathWithName(r)
+
+		target := pageURL(r, d, "/share/"+id, fPath, file.IsDir)
+		return renderQR(w, r, fileCache, target, size)
+	})
+}
+
+// pageURL returns the absolute URL of a page of the web interface, based
+// on the host of the request and the base URL.
+func pageURL(r *http.Request, d *data, prefix, fPath string, isDir bool) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	} else if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+
+	p := path.Join(d.server.BaseURL, prefix, fPath)
+	if isDir && !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+
+	return scheme + "://" + r.Host + (&url.URL{Path: p}).EscapedPath()
+}
+
+func renderQR(w http.ResponseWriter, r *http.Request, fileCache FileCache, target string, size int) (int, error) {
+	// QR codes are deterministic, so they are cached by URL and size.
+	cacheKey := "qr:" + strconv.Itoa(size) + ":" + target
+	png, ok, err := fileCache.Load(r.Context(), cacheKey)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	if !ok {
+		png, err = qrcode.Encode(target, qrcode.Medium, size)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		if err := fileCache.Store(context.Background(), cacheKey, png); err != nil { //nolint:govet
+			log.Printf("failed to cache QR code: %v", err)
+		}
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "private")
+	_, err = w.Write(png)
+	return 0, err
+}
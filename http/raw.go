@@ -3,16 +3,22 @@ package http
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
+	"mime"
 	"net/http"
 	"net/url"
+	"os"
 	gopath "path"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/mholt/archiver"
 	"github.com/spf13/afero"
 
+	fbErrors "github.com/filebrowser/filebrowser/v2/errors"
 	"github.com/filebrowser/filebrowser/v2/files"
 	"github.com/filebrowser/filebrowser/v2/fileutils"
 	"github.com/filebrowser/filebrowser/v2/users"
@@ -78,36 +84,191 @@ func setContentDisposition(w http.ResponseWriter, r *http.Request, file *files.F
 	}
 }
 
-var rawHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
-	if !d.user.Perm.Download {
-		return http.StatusAccepted, nil
+// setCacheHeaders sets the ETag and, if one of the server's cache patterns
+// matches the file, the Cache-Control header.
+func setCacheHeaders(w http.ResponseWriter, d *data, file *files.FileInfo) {
+	w.Header().Set("ETag", fmt.Sprintf(`"%x%x"`, file.ModTime.UnixNano(), file.Size))
+
+	if maxAge, ok := cacheMaxAge(d.server.CacheMaxAge, file.Path); ok {
+		w.Header().Set("Cache-Control", "max-age="+strconv.Itoa(maxAge))
 	}
+}
 
-	file, err := files.NewFileInfo(files.FileOptions{
-		Fs:         d.user.Fs,
-		Path:       r.URL.Path,
-		Modify:     d.user.Perm.Modify,
-		Expand:     false,
-		ReadHeader: d.server.TypeDetectionByHeader,
-		Checker:    d,
-	})
-	if err != nil {
-		return errToStatus(err), err
+// cacheMaxAge returns the max-age of the most specific pattern matching
+// the file path, which is the one with the most non-wildcard characters.
+// Patterns without a slash are matched against the file name only.
+func cacheMaxAge(patterns map[string]int, fPath string) (int, bool) {
+	var (
+		best        string
+		specificity = -1
+	)
+
+	for pattern := range patterns {
+		name := gopath.Base(fPath)
+		if strings.Contains(pattern, "/") {
+			name = fPath
+		}
+
+		if ok, _ := gopath.Match(pattern, name); !ok {
+			continue
+		}
+
+		s := len(strings.Map(func(r rune) rune {
+			if strings.ContainsRune("*?[]", r) {
+				return -1
+			}
+			return r
+		}, pattern))
+
+		if s > specificity || (s == specificity && pattern < best) {
+			best, specificity = pattern, s
+		}
 	}
 
-	if files.IsNamedPipe(file.Mode) {
-		setContentDisposition(w, r, file)
-		return 0, nil
+	if specificity == -1 {
+		return 0, false
 	}
 
+	return patterns[best], true
+}
+
+// rawHandler sends files as downloads and directories as archives. Only
+// building archives takes a slot of heavy.
+func rawHandler(heavy *heavyOpLimiter) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if !d.user.Perm.Download {
+			return http.StatusAccepted, nil
+		}
+
+		closeArchive, err := mountArchive(r, d)
+		if err != nil {
+			return errToStatus(err), err
+		}
+		defer closeArchive()
+
+		var file *files.FileInfo
+		if version := r.URL.Query().Get("version"); version != "" {
+			file, err = versionFileInfo(d, r.URL.Path, version)
+		} else {
+			file, err = files.NewFileInfo(files.FileOptions{
+				Fs:              d.user.Fs,
+				Path:            r.URL.Path,
+				Modify:          d.user.Perm.Modify,
+				Expand:          false,
+				ReadHeader:      d.server.TypeDetectionByHeader,
+				Checker:         d,
+				FollowSymlinks:  d.server.FollowSymlinks,
+				CaseInsensitive: d.server.CaseInsensitive,
+			})
+		}
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		if file.IsSpecial {
+			return http.StatusBadRequest, fbErrors.ErrSpecialFile
+		}
+
+		return rawFileOrDirHandler(w, r, d, file, heavy)
+	})
+}
+
+// rawFileOrDirHandler sends file, or an archive of it if it's a directory
+// once there's a free slot in heavy. Directories with an index file send
+// it as a page instead when the index files are served, unless the
+// listing parameter is set.
+func rawFileOrDirHandler(w http.ResponseWriter, r *http.Request, d *data, file *files.FileInfo, heavy *heavyOpLimiter) (int, error) {
 	if !file.IsDir {
-		return rawFileHandler(w, r, file)
+		return rawFileHandler(w, r, d, file)
 	}
 
-	return rawDirHandler(w, r, d, file)
-})
+	if d.server.ServeIndexFiles && r.URL.Query().Get("listing") != "true" {
+		index, err := indexFile(d, file.Path)
+		if err != nil {
+			return errToStatus(err), err
+		}
+		if index != nil {
+			// The relative links of the page resolve in the directory.
+			if redirectCanonicalSlash(w, r, true) {
+				return 0, nil
+			}
+
+			query := r.URL.Query()
+			query.Set("inline", "true")
+			r.URL.RawQuery = query.Encode()
+			return rawFileHandler(w, r, d, index)
+		}
+	}
+
+	return heavy.limit(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		return rawDirHandler(w, r, d, file)
+	})(w, r, d)
+}
+
+// indexFile returns the index file of the directory, see
+// settings.Server.IndexNames, or nil if it has none.
+func indexFile(d *data, dir string) (*files.FileInfo, error) {
+	listing, err := files.NewFileInfo(files.FileOptions{
+		Fs:              d.user.Fs,
+		Path:            dir,
+		Expand:          true,
+		Checker:         d,
+		FollowSymlinks:  d.server.FollowSymlinks,
+		CaseInsensitive: d.server.CaseInsensitive,
+	})
+	if err != nil || listing.Listing == nil {
+		return nil, err
+	}
+
+	index := listing.Listing.FindFile(d.server.IndexNames...)
+	if index == nil {
+		return nil, nil
+	}
+
+	// Read again to check where it leads if it's a symbolic link.
+	return files.NewFileInfo(files.FileOptions{
+		Fs:             d.user.Fs,
+		Path:           index.Path,
+		Checker:        d,
+		ReadHeader:     d.server.TypeDetectionByHeader,
+		FollowSymlinks: d.server.FollowSymlinks,
+	})
+}
 
 func addFile(ar archiver.Writer, d *data, path, commonPath string) error {
+	return walkDownload(d, path, commonPath, func(path, name string, info os.FileInfo) error {
+		var (
+			file          afero.File
+			arcReadCloser = ioutil.NopCloser(&bytes.Buffer{})
+		)
+		if !info.IsDir() && !files.IsSpecial(info.Mode()) {
+			var err error
+			file, err = d.user.Fs.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			arcReadCloser = file
+		}
+
+		return ar.Write(archiver.File{
+			FileInfo: archiver.FileInfo{
+				FileInfo:   info,
+				CustomName: name,
+			},
+			ReadCloser: arcReadCloser,
+		})
+	}, func(_ string, err error) error {
+		return err
+	})
+}
+
+// walkDownload walks the files the download of path includes, skipping the
+// ones the user can't see. It calls fn with the name of each file in the
+// archive, except for the common path itself, and onError with the errors
+// reading them, which stops the walk unless it returns nil.
+func walkDownload(d *data, path, commonPath string, fn func(path, name string, info os.FileInfo) error,
+	onError func(path string, err error) error) error {
 	// Checks are always done with paths with "/" as path separator.
 	path = strings.Replace(path, "\\", "/", -1)
 	if !d.Check(path) {
@@ -116,49 +277,36 @@ func addFile(ar archiver.Writer, d *data, path, commonPath string) error {
 
 	info, err := d.user.Fs.Stat(path)
 	if err != nil {
-		return err
+		return onError(path, err)
 	}
 
-	var (
-		file          afero.File
-		arcReadCloser = ioutil.NopCloser(&bytes.Buffer{})
-	)
-	if !files.IsNamedPipe(info.Mode()) {
-		file, err = d.user.Fs.Open(path)
-		if err != nil {
+	if path != commonPath {
+		name := strings.TrimPrefix(path, commonPath)
+		name = strings.TrimPrefix(name, "/")
+		if err := fn(path, name, info); err != nil {
 			return err
 		}
-		defer file.Close()
-		arcReadCloser = file
 	}
 
-	if path != commonPath {
-		filename := strings.TrimPrefix(path, commonPath)
-		filename = strings.TrimPrefix(filename, "/")
-		err = ar.Write(archiver.File{
-			FileInfo: archiver.FileInfo{
-				FileInfo:   info,
-				CustomName: filename,
-			},
-			ReadCloser: arcReadCloser,
-		})
-		if err != nil {
-			return err
-		}
+	if !info.IsDir() {
+		return nil
+	}
+
+	dir, err := d.user.Fs.Open(path)
+	if err != nil {
+		return onError(path, err)
+	}
+	names, err := dir.Readdirnames(0)
+	dir.Close()
+	if err != nil {
+		return onError(path, err)
 	}
 
-	if info.IsDir() {
-		names, err := file.Readdirnames(0)
+	for _, name := range names {
+		err = walkDownload(d, filepath.Join(path, name), commonPath, fn, onError)
 		if err != nil {
 			return err
 		}
-
-		for _, name := range names {
-			err = addFile(ar, d, filepath.Join(path, name), commonPath)
-			if err != nil {
-				return err
-			}
-		}
 	}
 
 	return nil
@@ -200,15 +348,102 @@ func rawDirHandler(w http.ResponseWriter, r *http.Request, d *data, file *files.
 	return 0, nil
 }
 
-func rawFileHandler(w http.ResponseWriter, r *http.Request, file *files.FileInfo) (int, error) {
+func rawFileHandler(w http.ResponseWriter, r *http.Request, d *data, file *files.FileInfo) (int, error) {
 	fd, err := file.Fs.Open(file.Path)
 	if err != nil {
 		return http.StatusInternalServerError, err
 	}
 	defer fd.Close()
 
+	w = withStreamBuffer(w, d.server.StreamBufferSize)
+
+	meta, err := files.ReadMeta(file.Fs, file.Path)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	setMetaHeaders(w, meta)
+
+	setCacheHeaders(w, d, file)
+
+	if err := setContentType(w, d, file, fd); err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	if downloadForced(file.Name, d.server.ForceDownloadExtensions) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", "attachment; filename*=utf-8''"+url.PathEscape(file.Name))
+		http.ServeContent(w, r, file.Name, file.ModTime, fd)
+		return 0, nil
+	}
+
+	if mime.TypeByExtension(file.Extension) == svgMimeType {
+		return rawSVGHandler(w, r, d, file, fd)
+	}
+
 	setContentDisposition(w, r, file)
 
 	http.ServeContent(w, r, file.Name, file.ModTime, fd)
 	return 0, nil
 }
+
+// setContentType sets the type of the file from its extension or, failing
+// that, its first bytes. The files previewed as text because of their
+// extension are sent as plain text. When both fail, the default MIME
+// type is used and browsers are told not to guess another one, so they
+// don't render untrusted content inline.
+func setContentType(w http.ResponseWriter, d *data, file *files.FileInfo, fd io.ReadSeeker) error {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+
+	contentType := mime.TypeByExtension(file.Extension)
+	if files.IsTextExtension(d.server.TextExtensions, file.Extension) {
+		// They're previewed as text, their MIME type may not be one.
+		contentType = "text/plain; charset=utf-8"
+	}
+	if contentType == "" {
+		var buffer [512]byte
+		n, err := io.ReadFull(fd, buffer[:])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		if _, err := fd.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+
+		// Sniffing falls back to application/octet-stream.
+		if sniffed := http.DetectContentType(buffer[:n]); sniffed != "application/octet-stream" {
+			contentType = sniffed
+		}
+	}
+
+	if contentType == "" {
+		contentType = d.server.DefaultMimeType
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	return nil
+}
+
+func rawSVGHandler(w http.ResponseWriter, r *http.Request, d *data, file *files.FileInfo, fd io.ReadSeeker) (int, error) {
+	switch d.server.SVGHandling {
+	case svgHandlingRaw:
+		setContentDisposition(w, r, file)
+		http.ServeContent(w, r, file.Name, file.ModTime, fd)
+	case svgHandlingDownload:
+		w.Header().Set("Content-Disposition", "attachment; filename*=utf-8''"+url.PathEscape(file.Name))
+		http.ServeContent(w, r, file.Name, file.ModTime, fd)
+	default:
+		content, err := sanitizeSVG(fd)
+		if err != nil {
+			return http.StatusUnsupportedMediaType, err
+		}
+
+		setContentDisposition(w, r, file)
+		w.Header().Set("Content-Security-Policy", "script-src 'none'")
+		http.ServeContent(w, r, file.Name, file.ModTime, bytes.NewReader(content))
+	}
+
+	return 0, nil
+}
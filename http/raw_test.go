@@ -0,0 +1,150 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+func TestRawContentType(t *testing.T) {
+	testCases := map[string]struct {
+		defaultType string
+		want        string
+	}{
+		"/notes.txt":   {want: "text/plain; charset=utf-8"},
+		"/page":        {want: "text/html; charset=utf-8"},
+		"/blob.xyz":    {want: "application/octet-stream"},
+		"/custom.blob": {defaultType: "application/x-custom", want: "application/x-custom"},
+		"/config.toml": {want: "text/plain; charset=utf-8"},
+		"/image.svg":   {want: "image/svg+xml"},
+	}
+
+	d := newTestData(t, map[string]string{
+		"/notes.txt":   "hello",
+		"/page":        "<html><body>hi</body></html>",
+		"/blob.xyz":    "\x00\x01\x02\x03",
+		"/custom.blob": "\x00\x01\x02\x03",
+		"/config.toml": "\x00\x01\x02\x03",
+		"/image.svg":   "<svg></svg>",
+	})
+	d.server.TextExtensions = []string{".toml"}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			d.server.DefaultMimeType = tc.defaultType
+
+			file, err := files.NewFileInfo(files.FileOptions{Fs: d.user.Fs, Path: name, Checker: d})
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			_, err = rawFileHandler(w, httptest.NewRequest(http.MethodGet, name, nil), d, file)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, w.Header().Get("Content-Type"))
+			require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		})
+	}
+}
+
+func TestRawForceDownload(t *testing.T) {
+	testCases := map[string]bool{
+		"/setup.exe":       true,
+		"/install.sh":      true,
+		"/page.html":       true,
+		"/PAGE.HTML":       true,
+		"/page.html.":      true,
+		"/notes.txt":       false,
+		"/html.txt":        false,
+		"/archive.exe.txt": false,
+	}
+
+	entries := map[string]string{}
+	for name := range testCases {
+		entries[name] = "<html><body>hi</body></html>"
+	}
+	d := newTestData(t, entries)
+	d.server.ForceDownloadExtensions = []string{".exe", ".sh", ".html"}
+
+	for name, forced := range testCases {
+		t.Run(name, func(t *testing.T) {
+			file, err := files.NewFileInfo(files.FileOptions{Fs: d.user.Fs, Path: name, Checker: d})
+			require.NoError(t, err)
+
+			w := httptest.NewRecorder()
+			_, err = rawFileHandler(w, httptest.NewRequest(http.MethodGet, name+"?inline=true", nil), d, file)
+			require.NoError(t, err)
+			if forced {
+				require.Equal(t, "application/octet-stream", w.Header().Get("Content-Type"))
+				require.True(t, strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment;"))
+			} else {
+				require.Equal(t, "inline", w.Header().Get("Content-Disposition"))
+			}
+			require.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+		})
+	}
+}
+
+func TestRawOnlyArchivesAreHeavy(t *testing.T) {
+	d := newTestData(t, map[string]string{
+		"/dir/notes.txt": "hello",
+	})
+	d.user.Perm.Download = true
+
+	// Every slot is taken by another operation.
+	limiter := newHeavyOpLimiter(1, 10*time.Millisecond)
+	limiter.slots <- struct{}{}
+
+	for path, want := range map[string]int{
+		"/dir/notes.txt": 0,
+		"/dir":           http.StatusServiceUnavailable,
+	} {
+		file, err := files.NewFileInfo(files.FileOptions{Fs: d.user.Fs, Path: path, Checker: d})
+		require.NoError(t, err)
+
+		status, err := rawFileOrDirHandler(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil), d, file, limiter)
+		require.NoError(t, err)
+		require.Equal(t, want, status, path)
+	}
+}
+
+func TestRawServeIndexFiles(t *testing.T) {
+	d := newTestData(t, map[string]string{
+		"/site/index.html": "<html><body>home</body></html>",
+		"/site/style.css":  "body {}",
+	})
+	d.user.Perm.Download = true
+	d.server.ServeIndexFiles = true
+	d.server.IndexNames = []string{"index.html"}
+
+	get := func(url, fPath string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, url, nil)
+		r.URL.Path = fPath
+
+		file, err := files.NewFileInfo(files.FileOptions{Fs: d.user.Fs, Path: fPath, Checker: d})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		status, err := rawFileOrDirHandler(w, r, d, file, nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, status)
+		return w
+	}
+
+	w := get("/api/raw/site/", "/site/")
+	require.Equal(t, "<html><body>home</body></html>", w.Body.String())
+	require.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Equal(t, "inline", w.Header().Get("Content-Disposition"))
+
+	w = get("/api/raw/site", "/site")
+	require.Equal(t, http.StatusMovedPermanently, w.Code)
+	require.Equal(t, "/api/raw/site/", w.Header().Get("Location"))
+
+	w = get("/api/raw/site/?listing=true", "/site/")
+	require.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+	require.NotEqual(t, "<html><body>home</body></html>", w.Body.String())
+}
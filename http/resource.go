@@ -1,40 +1,224 @@
 package http
 
 import (
+	"context"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/spf13/afero"
 
+	"github.com/filebrowser/filebrowser/v2/checksums"
 	"github.com/filebrowser/filebrowser/v2/errors"
 	"github.com/filebrowser/filebrowser/v2/files"
 	"github.com/filebrowser/filebrowser/v2/fileutils"
+	"github.com/filebrowser/filebrowser/v2/transfers"
+	"github.com/filebrowser/filebrowser/v2/users"
 )
 
-var resourceGetHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+// pathLocks serializes the operations writing to the same files.
+var pathLocks = fileutils.NewLocker()
+
+// lockPaths locks the given paths of the user, see fileutils.Locker.
+func lockPaths(d *data, paths ...string) (unlock func()) {
+	keys := make([]string, len(paths))
+	for i, p := range paths {
+		keys[i] = path.Clean("/" + p)
+		if _, ok := d.user.Fs.(*afero.BasePathFs); ok {
+			keys[i] = d.user.FullPath(keys[i])
+		}
+	}
+	return pathLocks.Lock(keys...)
+}
+
+func resourceGetHandler(checksumStore *checksums.Store) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		return resourceGet(w, r, d, checksumStore)
+	})
+}
+
+var resourceHeadHandler = withUser(resourceHead)
+
+// resourceHead answers the probes of the existence of files with
+// their size and modification time, without reading them. Files and
+// directories which don't exist are both 404, with an X-Exists header if
+// configured so the answer isn't mistaken for a missing route.
+func resourceHead(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 	file, err := files.NewFileInfo(files.FileOptions{
-		Fs:         d.user.Fs,
-		Path:       r.URL.Path,
-		Modify:     d.user.Perm.Modify,
-		Expand:     true,
-		ReadHeader: d.server.TypeDetectionByHeader,
-		Checker:    d,
+		Fs:              d.user.Fs,
+		Path:            r.URL.Path,
+		Modify:          d.user.Perm.Modify,
+		Expand:          false,
+		Checker:         d,
+		FollowSymlinks:  d.server.FollowSymlinks,
+		CaseInsensitive: d.server.CaseInsensitive,
 	})
+	if errToStatus(err) == http.StatusNotFound {
+		if d.server.HeadExistsHeader {
+			w.Header().Set("X-Exists", "false")
+		}
+		w.WriteHeader(http.StatusNotFound)
+		return 0, nil
+	}
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	if d.server.HeadExistsHeader {
+		w.Header().Set("X-Exists", "true")
+	}
+	if !file.IsDir {
+		w.Header().Set("Content-Length", strconv.FormatInt(file.Size, 10))
+	}
+	w.Header().Set("Last-Modified", file.ModTime.UTC().Format(http.TimeFormat))
+	w.WriteHeader(http.StatusOK)
+	return 0, nil
+}
+
+func resourceGet(w http.ResponseWriter, r *http.Request, d *data, checksumStore *checksums.Store) (int, error) {
+	closeArchive, err := mountArchive(r, d)
+	if err != nil {
+		return errToStatus(err), err
+	}
+	defer closeArchive()
+
+	fields, err := parseFields(r)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	line, err := parseLine(r)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	byteWindow, err := parseByteWindow(r)
+	if err != nil {
+		return errToStatus(err), err
+	}
+	if line > 0 && byteWindow != nil {
+		return http.StatusBadRequest, fmt.Errorf("line and offset are exclusive: %w", errors.ErrInvalidRequestParams)
+	}
+
+	flatten, err := parseFlatten(r, d.server.FlattenMaxFiles)
 	if err != nil {
 		return errToStatus(err), err
 	}
 
+	if r.URL.Query().Get("tree") == "true" {
+		return resourceTree(w, r, d)
+	}
+
+	checksumCache := d.checksumCache(checksumStore)
+	if noCache(r) {
+		invalidateCache(d, r.URL.Path)
+		checksumCache = files.RefreshChecksums(checksumCache)
+	}
+
+	// The index files are read with the same options as the directory.
+	opts := files.FileOptions{
+		Fs:                    d.user.Fs,
+		Path:                  r.URL.Path,
+		Modify:                d.user.Perm.Modify,
+		Expand:                true,
+		ReadHeader:            d.server.TypeDetectionByHeader,
+		Checker:               d,
+		FollowSymlinks:        d.server.FollowSymlinks,
+		Columns:               d.server.ListingColumns,
+		LargeFileThreshold:    d.server.LargeFileThreshold,
+		DirMTimeFromContents:  d.server.DirMTimeFromContents,
+		ImageDimensions:       d.server.ImageDimensions,
+		ShowChildCounts:       d.server.ShowChildCounts,
+		DisplayName:           d.displayName(),
+		PreviewLimits:         d.requestPreviewLimits(w, r),
+		CaseInsensitive:       d.server.CaseInsensitive,
+		GracefulPreviewErrors: d.server.GracefulPreviewErrors,
+		BinaryThreshold:       d.server.BinaryThreshold,
+		TextExtensions:        d.server.TextExtensions,
+		Locale:                d.server.Locale,
+		SymlinkDepth:          d.server.SymlinkResolveDepth,
+		HashOnStat:            d.server.HashOnStat,
+		Checksums:             checksumCache,
+		Line:                  line,
+		ByteWindow:            byteWindow,
+		Flatten:               flatten,
+	}
+	file, err := files.NewFileInfo(opts)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	if d.server.CanonicalSlash && redirectCanonicalSlash(w, r, file.IsDir) {
+		return 0, nil
+	}
+
+	if file.IsDir && d.server.ServeIndexFiles && r.URL.Query().Get("listing") != "true" {
+		if index := file.Listing.FindFile(d.server.IndexNames...); index != nil {
+			opts.Path = index.Path
+			file, err = files.NewFileInfo(opts)
+			if err != nil {
+				return errToStatus(err), err
+			}
+		}
+	}
+
 	if file.IsDir {
-		file.Listing.Sorting = d.user.Sorting
+		file.Listing.Sorting = listingSorting(r, d, file.Path)
+		if by := file.Listing.Sorting.By; by != "" && !files.SortableBy(d.server.ListingColumns, by) {
+			file.Listing.Sorting.By = "name"
+		}
+
+		view, err := listingView(r, d, file.Path, file.Listing)
+		if err != nil {
+			return errToStatus(err), err
+		}
+		file.Listing.View = string(view)
+
+		etag := listingETag(r, d, file)
+		w.Header().Set("ETag", etag)
+		if etagMatches(r.Header.Get("If-None-Match"), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return 0, nil
+		}
+
+		if r.URL.Query().Get("format") == "rss" {
+			return renderRSS(w, r, d, file)
+		}
+
 		file.Listing.ApplySort()
-		return renderJSON(w, r, file)
+		if groupByDate(r) {
+			file.Listing.Groups = file.Listing.GroupByDate(time.Now(), d.timezone())
+		}
+
+		if r.URL.Query().Get("format") == "m3u" {
+			return renderM3U(w, r, d, file)
+		}
+
+		file.RenderReadme(d.server.ReadmeNames, d.server.ReadmeMaxSize)
+		if d.aliased != "" {
+			file.ShowUnder(d.aliased)
+		}
+		return renderFileJSON(w, r, file, fields)
+	}
+
+	if isMarkdown(file) {
+		if representation := negotiateMarkdown(r); representation != markdownJSON {
+			return serveMarkdown(w, r, file, representation)
+		}
+	}
+
+	if d.server.ShowXattrs {
+		file.ReadXattrs()
 	}
 
 	if checksum := r.URL.Query().Get("checksum"); checksum != "" {
@@ -49,48 +233,308 @@ var resourceGetHandler = withUser(func(w http.ResponseWriter, r *http.Request, d
 		file.Content = ""
 	}
 
-	return renderJSON(w, r, file)
-})
+	if r.URL.Query().Get("hex") == "true" {
+		hex, err := file.HexDump(int64(d.server.HexDumpMaxSize))
+		if err == errors.ErrFileTooLarge {
+			msg := fmt.Sprintf("the file is larger than %d bytes, download it instead", d.server.HexDumpMaxSize)
+			writeError(w, r, http.StatusRequestEntityTooLarge, msg)
+			return 0, nil
+		} else if err != nil {
+			return errToStatus(err), err
+		}
+
+		file.Hex = hex
+		file.Content = ""
+	}
+
+	return renderFileJSON(w, r, file, fields)
+}
+
+// listingETag returns the ETag of the listing of the directory. It changes
+// when the directory or its entries change, and with the query and the
+// sorting, which change the representation of the listing.
+func listingETag(r *http.Request, d *data, dir *files.FileInfo) string {
+	var latest time.Time
+	h := fnv.New64a()
+	for _, item := range dir.Items {
+		if item.ModTime.After(latest) {
+			latest = item.ModTime
+		}
+		fmt.Fprintf(h, "%s\x00", item.Name)
+	}
+
+	fmt.Fprintf(h, "%d %d %d %s %v %s", dir.ModTime.UnixNano(), len(dir.Items), latest.UnixNano(), r.URL.RawQuery, dir.Sorting, dir.View)
+	if groupByDate(r) {
+		// The groups of today and yesterday change at midnight.
+		fmt.Fprintf(h, " %s", time.Now().In(d.timezone()).Format("2006-01-02"))
+	}
+	return fmt.Sprintf(`W/"%x"`, h.Sum64())
+}
+
+func groupByDate(r *http.Request) bool {
+	return r.URL.Query().Get("group") == "date"
+}
+
+// etagMatches checks if the If-None-Match header matches the ETag, using
+// the weak comparison.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLine parses the "line" query parameter, the line to open a text
+// file at. It returns 0 if the parameter is not set, and the lines before
+// the first are clamped to it.
+func parseLine(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("line")
+	if value == "" {
+		return 0, nil
+	}
+
+	line, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid line %q: %w", value, errors.ErrInvalidRequestParams)
+	}
+	if line < 1 {
+		line = 1
+	}
+	return line, nil
+}
+
+// parseByteWindow parses the offset and length parameters opening a text
+// file at a window of bytes. It returns nil if there's no offset.
+func parseByteWindow(r *http.Request) (*files.ByteWindow, error) {
+	query := r.URL.Query()
+	if query.Get("offset") == "" {
+		return nil, nil
+	}
+
+	offset, err := strconv.ParseInt(query.Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return nil, fmt.Errorf("invalid offset %q: %w", query.Get("offset"), errors.ErrInvalidRequestParams)
+	}
+
+	var length int64
+	if value := query.Get("length"); value != "" {
+		length, err = strconv.ParseInt(value, 10, 64)
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("invalid length %q: %w", value, errors.ErrInvalidRequestParams)
+		}
+	}
+
+	return &files.ByteWindow{Offset: offset, Length: length}, nil
+}
+
+// noCache tells if the request asks, with the nocache parameter, for what
+// is cached about the path to be read again, such as after the files were
+// changed behind the back of the server. Clients can use it to refresh a
+// listing or a checksum.
+func noCache(r *http.Request) bool {
+	value := r.URL.Query().Get("nocache")
+	return value == "1" || value == "true"
+}
+
+// invalidateCache drops what the root filesystem caches about the path,
+// if it caches anything.
+func invalidateCache(d *data, p string) {
+	if invalidator, ok := users.RootFs.(files.CacheInvalidator); ok {
+		invalidator.InvalidateCache(d.user.FullPath(p))
+	}
+}
+
+// parseFlatten parses the flatten parameter, which lists the files of the
+// subdirectories too, up to the depth and maxfiles parameters. The number
+// of files is capped by maxFiles, unless it's 0.
+func parseFlatten(r *http.Request, maxFiles int) (*files.Flatten, error) {
+	query := r.URL.Query()
+	if query.Get("flatten") != "true" {
+		return nil, nil
+	}
+
+	flatten := &files.Flatten{}
+	for param, value := range map[string]*int{"depth": &flatten.Depth, "maxfiles": &flatten.MaxFiles} {
+		if query.Get(param) == "" {
+			continue
+		}
+		n, err := strconv.Atoi(query.Get(param))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid %s %q: %w", param, query.Get(param), errors.ErrInvalidRequestParams)
+		}
+		*value = n
+	}
+
+	if maxFiles > 0 && (flatten.MaxFiles == 0 || flatten.MaxFiles > maxFiles) {
+		flatten.MaxFiles = maxFiles
+	}
+	return flatten, nil
+}
+
+// resourceTree renders the tree of the directory, down to the "depth"
+// parameter and with at most as many nodes as the "maxnodes" parameter
+// and the configured maximum allow.
+func resourceTree(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	query := r.URL.Query()
+
+	var depth, maxNodes int
+	for param, value := range map[string]*int{"depth": &depth, "maxnodes": &maxNodes} {
+		if query.Get(param) == "" {
+			continue
+		}
+		n, err := strconv.Atoi(query.Get(param))
+		if err != nil || n < 0 {
+			return http.StatusBadRequest, fmt.Errorf("invalid %s %q: %w", param, query.Get(param), errors.ErrInvalidRequestParams)
+		}
+		*value = n
+	}
+
+	if d.server.TreeMaxNodes > 0 && (maxNodes == 0 || maxNodes > d.server.TreeMaxNodes) {
+		maxNodes = d.server.TreeMaxNodes
+	}
+
+	tree, err := files.NewTree(files.FileOptions{
+		Fs:              d.user.Fs,
+		Path:            r.URL.Path,
+		Modify:          d.user.Perm.Modify,
+		ReadHeader:      d.server.TypeDetectionByHeader,
+		Checker:         d,
+		FollowSymlinks:  d.server.FollowSymlinks,
+		CaseInsensitive: d.server.CaseInsensitive,
+		TextExtensions:  d.server.TextExtensions,
+	}, depth, maxNodes)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return renderJSON(w, r, tree)
+}
+
+// redirectCanonicalSlash redirects the requests to directories without a
+// trailing slash, and to files with one, so relative URLs resolve. It
+// returns false if the path is already canonical.
+func redirectCanonicalSlash(w http.ResponseWriter, r *http.Request, isDir bool) bool {
+	if strings.HasSuffix(r.URL.Path, "/") == isDir {
+		return false
+	}
+
+	// The URL path was stripped of the prefix of the route.
+	u, err := url.ParseRequestURI(r.RequestURI)
+	if err != nil {
+		return false
+	}
+
+	if isDir {
+		u.RawPath = u.EscapedPath() + "/"
+		u.Path += "/"
+	} else {
+		u.RawPath = strings.TrimRight(u.EscapedPath(), "/")
+		u.Path = strings.TrimRight(u.Path, "/")
+	}
+
+	http.Redirect(w, r, u.RequestURI(), http.StatusMovedPermanently)
+	return true
+}
 
 func resourceDeleteHandler(fileCache FileCache) handleFunc {
 	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
-		if r.URL.Path == "/" || !d.user.Perm.Delete {
-			return http.StatusForbidden, nil
-		}
-
-		file, err := files.NewFileInfo(files.FileOptions{
-			Fs:         d.user.Fs,
-			Path:       r.URL.Path,
-			Modify:     d.user.Perm.Modify,
-			Expand:     true,
-			ReadHeader: d.server.TypeDetectionByHeader,
-			Checker:    d,
-		})
-		if err != nil {
-			return errToStatus(err), err
-		}
+		return deleteResource(r.Context(), d, fileCache, r.URL.Path)
+	})
+}
+
+func deleteResource(ctx context.Context, d *data, fileCache FileCache, fPath string) (int, error) {
+	if fPath == "/" || !d.user.Perm.Delete {
+		return http.StatusForbidden, nil
+	}
+	if inArchive(d, fPath) {
+		return http.StatusMethodNotAllowed, nil
+	}
 
-		// delete thumbnails
-		for _, previewSizeName := range PreviewSizeNames() {
-			size, _ := ParsePreviewSize(previewSizeName)
-			if err := fileCache.Delete(r.Context(), previewCacheKey(file.Path, size)); err != nil { //nolint:govet
+	file, err := files.NewFileInfo(files.FileOptions{
+		Fs:             d.user.Fs,
+		Path:           fPath,
+		Modify:         d.user.Perm.Modify,
+		Expand:         true,
+		ReadHeader:     d.server.TypeDetectionByHeader,
+		Checker:        d,
+		FollowSymlinks: d.server.FollowSymlinks,
+	})
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	// delete thumbnails
+	for _, previewSizeName := range PreviewSizeNames() {
+		size, _ := ParsePreviewSize(previewSizeName)
+		for _, mobile := range []bool{false, true} {
+			if err := fileCache.Delete(ctx, previewCacheKey(file.Path, size, mobile)); err != nil { //nolint:govet
 				return errToStatus(err), err
 			}
 		}
+	}
 
-		err = d.RunHook(func() error {
-			return d.user.Fs.RemoveAll(r.URL.Path)
-		}, "delete", r.URL.Path, "", d.user)
+	err = d.RunHook(func() error {
+		defer lockPaths(d, fPath)()
 
-		if err != nil {
-			return errToStatus(err), err
+		if err := d.user.Fs.RemoveAll(fPath); err != nil {
+			return err
 		}
+		return files.WriteMeta(d.user.Fs, fPath, nil)
+	}, "delete", fPath, "", d.user)
 
-		return http.StatusOK, nil
-	})
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return http.StatusOK, nil
+}
+
+// listingSorting returns the sorting of the listing of dir: the one of
+// the "sort" and "order" parameters, or else the one the directory
+// declares in its sort file, or else the one of the user.
+func listingSorting(r *http.Request, d *data, dir string) files.Sorting {
+	if by := r.URL.Query().Get("sort"); by != "" {
+		if sorting, ok := files.ParseSorting(by + " " + r.URL.Query().Get("order")); ok {
+			return sorting
+		}
+	}
+
+	if sorting, ok := files.ReadSortFile(d.user.Fs, dir); ok {
+		return sorting
+	}
+
+	return d.user.Sorting
 }
 
-var resourcePostPutHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+// listingView returns the view mode of the listing of dir: the one of the
+// "view" parameter, or else the one the directory declares in its view
+// file, or else mosaic for the directories mostly holding images if the
+// view is automatic, or else the one of the user.
+func listingView(r *http.Request, d *data, dir string, listing *files.Listing) (users.ViewMode, error) {
+	switch view := users.ViewMode(r.URL.Query().Get("view")); view {
+	case users.ListViewMode, users.MosaicViewMode:
+		return view, nil
+	case "":
+	default:
+		return "", fmt.Errorf("invalid view %q: %w", view, errors.ErrInvalidRequestParams)
+	}
+
+	if view, ok := files.ReadViewFile(d.user.Fs, dir, string(users.ListViewMode), string(users.MosaicViewMode)); ok {
+		return users.ViewMode(view), nil
+	}
+
+	if d.server.AutoView && listing.MostlyImages() {
+		return users.MosaicViewMode, nil
+	}
+	return d.user.ViewMode, nil
+}
+
+var resourcePostPutHandler = withUser(resourcePostPut)
+
+func resourcePostPut(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
 	if !d.user.Perm.Create && r.Method == http.MethodPost {
 		return http.StatusForbidden, nil
 	}
@@ -103,13 +547,44 @@ var resourcePostPutHandler = withUser(func(w http.ResponseWriter, r *http.Reques
 		_, _ = io.Copy(ioutil.Discard, r.Body)
 	}()
 
-	// For directories, only allow POST for creation.
+	if inArchive(d, r.URL.Path) {
+		return http.StatusMethodNotAllowed, nil
+	}
+
+	if err := d.checkWriteSymlinks(r.URL.Path); err != nil {
+		return errToStatus(err), err
+	}
+
+	meta, ok := parseMetaHeaders(r)
+	if !ok {
+		return http.StatusRequestHeaderFieldsTooLarge, nil
+	}
+
+	nameMax := files.NameMax(d.user.Fs, path.Dir(r.URL.Path))
+	if err := files.CheckNameLength(r.URL.Path, nameMax); err != nil {
+		return errToStatus(err), err
+	}
+
+	// For directories, only allow POST for creation, and for uploading
+	// files into them with a multipart body.
 	if strings.HasSuffix(r.URL.Path, "/") {
 		if r.Method == http.MethodPut {
 			return http.StatusMethodNotAllowed, nil
 		}
 
 		err := d.user.Fs.MkdirAll(r.URL.Path, 0775)
+		if err == nil && isMultipartUpload(r) {
+			return multipartUpload(r, d, r.URL.Path)
+		}
+		return errToStatus(err), err
+	}
+
+	if !uploadExtensionAllowed(r.URL.Path, d.server.UploadAllowExtensions, d.server.UploadBlockExtensions) {
+		return http.StatusUnsupportedMediaType, nil
+	}
+
+	checksum, err := parseUploadChecksum(r)
+	if err != nil {
 		return errToStatus(err), err
 	}
 
@@ -119,97 +594,230 @@ var resourcePostPutHandler = withUser(func(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
+	var body io.Reader = r.Body
 	action := "upload"
 	if r.Method == http.MethodPut {
 		action = "save"
+
+		// Saves, from editors, may normalize the text they write.
+		normalization, err := saveNormalization(r, d) //nolint:govet
+		if err != nil {
+			return errToStatus(err), err
+		}
+		if !normalization.IsZero() {
+			if body, err = normalizeBody(r.Body, checksum, normalization); err != nil {
+				return errToStatus(err), err
+			}
+			checksum = nil
+		}
 	}
 
-	err := d.RunHook(func() error {
+	err = d.RunHook(func() error {
+		defer lockPaths(d, r.URL.Path)()
+
 		dir, _ := path.Split(r.URL.Path)
 		err := d.user.Fs.MkdirAll(dir, 0775)
 		if err != nil {
 			return err
 		}
 
-		file, err := d.user.Fs.OpenFile(r.URL.Path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0775)
+		// The content is written to a temporary file which is only moved
+		// into place once complete, so half-written files are never
+		// served and overwriting is atomic.
+		tmpPath, err := files.UploadTempPath(r.URL.Path, nameMax)
 		if err != nil {
 			return err
 		}
-		defer file.Close()
 
-		_, err = io.Copy(file, r.Body)
+		info, err := writeUpload(d.user.Fs, tmpPath, body, checksum)
+		if err == nil && d.server.UploadHook != "" && d.server.RejectOnHookFailure {
+			// The hook may change the file, such as optimizing an image.
+			if err = runUploadHook(d, tmpPath); err == nil {
+				info, err = d.user.Fs.Stat(tmpPath)
+			}
+		}
+		if err == nil && d.server.KeepVersions > 0 {
+			snapshotVersion(d, r.URL.Path)
+		}
+		if err == nil {
+			err = d.user.Fs.Rename(tmpPath, r.URL.Path)
+		}
 		if err != nil {
+			_ = d.user.Fs.Remove(tmpPath)
 			return err
 		}
 
-		// Gets the info about the file.
-		info, err := file.Stat()
-		if err != nil {
-			return err
+		if d.server.UploadHook != "" && !d.server.RejectOnHookFailure {
+			go func() {
+				if err := runUploadHook(d, r.URL.Path); err != nil {
+					log.Printf("upload hook of %s: %v", r.URL.Path, err)
+				}
+			}()
 		}
 
 		etag := fmt.Sprintf(`"%x%x"`, info.ModTime().UnixNano(), info.Size())
 		w.Header().Set("ETag", etag)
-		return nil
+
+		// The metadata describes the previous content, if any, so it is
+		// replaced even if none was sent.
+		return files.WriteMeta(d.user.Fs, r.URL.Path, meta)
 	}, action, r.URL.Path, "", d.user)
 
+	return errToStatus(err), err
+}
+
+// runUploadHook runs the upload hook on the uploaded file. When uploads
+// are rejected if it fails, it runs on the temporary file of the upload,
+// so a rejected upload doesn't replace the previous content of the file.
+func runUploadHook(d *data, fPath string) error {
+	if err := d.Exec(d.server.UploadHook, "upload_hook", fPath, d.user); err != nil {
+		return fmt.Errorf("%w: %v", errors.ErrUploadRejected, err)
+	}
+	return nil
+}
+
+// writeUpload writes the body of an upload to fPath, verifying its
+// checksum if any, and returns the info of the written file.
+func writeUpload(fs afero.Fs, fPath string, body io.Reader, checksum *uploadChecksum) (os.FileInfo, error) {
+	file, err := fs.OpenFile(fPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0775)
 	if err != nil {
-		_ = d.user.Fs.RemoveAll(r.URL.Path)
+		return nil, err
 	}
+	defer file.Close()
 
-	return errToStatus(err), err
-})
+	// The checksum is computed while writing so the content isn't
+	// read again.
+	var writer io.Writer = file
+	if checksum != nil {
+		writer = io.MultiWriter(file, checksum)
+	}
 
-var resourcePatchHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
-	src := r.URL.Path
-	dst := r.URL.Query().Get("destination")
-	action := r.URL.Query().Get("action")
-	dst, err := url.QueryUnescape(dst)
+	if _, err := io.Copy(writer, body); err != nil { //nolint:govet
+		return nil, err
+	}
+
+	if checksum != nil {
+		if err := checksum.verify(); err != nil { //nolint:govet
+			return nil, err
+		}
+	}
+
+	info, err := file.Stat()
 	if err != nil {
-		return errToStatus(err), err
+		return nil, err
+	}
+
+	// The file is closed before being renamed into place.
+	return info, file.Close()
+}
+
+func resourcePatchHandler(transfersReg *transfers.Registry) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		dst, err := url.QueryUnescape(r.URL.Query().Get("destination"))
+		if err != nil {
+			return errToStatus(err), err
+		}
+
+		action := r.URL.Query().Get("action")
+		override := r.URL.Query().Get("override") == "true"
+		rename := r.URL.Query().Get("rename") == "true"
+		if r.URL.Query().Get("async") == "true" {
+			return startTransfer(w, r, d, transfersReg, action, r.URL.Path, dst, override, rename)
+		}
+		return patchResource(d, action, r.URL.Path, dst, override, rename)
+	})
+}
+
+func patchResource(d *data, action, src, dst string, override, rename bool) (int, error) {
+	dst, status, err := preparePatch(d, src, dst, override, rename)
+	if status != 0 {
+		return status, err
 	}
+
+	err = runPatch(context.Background(), d, action, src, dst, nil)
+	return errToStatus(err), err
+}
+
+// preparePatch checks a copy or move can be done and returns its
+// destination. It returns a status if it can't.
+func preparePatch(d *data, src, dst string, override, rename bool) (string, int, error) {
 	if dst == "/" || src == "/" {
-		return http.StatusForbidden, nil
+		return "", http.StatusForbidden, nil
+	}
+	if inArchive(d, src) || inArchive(d, dst) {
+		return "", http.StatusMethodNotAllowed, nil
 	}
-	if err = checkParent(src, dst); err != nil {
-		return http.StatusBadRequest, err
+	if err := checkParent(src, dst); err != nil {
+		return "", http.StatusBadRequest, err
 	}
 
-	override := r.URL.Query().Get("override") == "true"
-	rename := r.URL.Query().Get("rename") == "true"
 	if !override && !rename {
-		if _, err = d.user.Fs.Stat(dst); err == nil {
-			return http.StatusConflict, nil
+		if _, err := d.user.Fs.Stat(dst); err == nil {
+			return "", http.StatusConflict, nil
 		}
 	}
 	if rename {
 		dst = addVersionSuffix(dst, d.user.Fs)
 	}
 
-	err = d.RunHook(func() error {
-		switch action {
-		// TODO: use enum
-		case "copy":
-			if !d.user.Perm.Create {
-				return errors.ErrPermissionDenied
-			}
+	if err := files.CheckNameLength(dst, files.NameMax(d.user.Fs, path.Dir(dst))); err != nil {
+		return "", http.StatusBadRequest, err
+	}
+
+	return dst, 0, nil
+}
+
+// checkPatchAction checks the action is a known one the user may do.
+func checkPatchAction(d *data, action string) error {
+	switch action {
+	// TODO: use enum
+	case "copy":
+		if !d.user.Perm.Create {
+			return errors.ErrPermissionDenied
+		}
+	case "rename":
+		if !d.user.Perm.Rename {
+			return errors.ErrPermissionDenied
+		}
+	default:
+		return fmt.Errorf("unsupported action %s: %w", action, errors.ErrInvalidRequestParams)
+	}
+	return nil
+}
 
-			return fileutils.Copy(d.user.Fs, src, dst)
-		case "rename":
-			if !d.user.Perm.Rename {
-				return errors.ErrPermissionDenied
+// runPatch copies or moves src to dst until the context is done, giving
+// the bytes copied to the progress if it isn't nil. The partial copies
+// are removed when the context is done.
+func runPatch(ctx context.Context, d *data, action, src, dst string, progress fileutils.Progress) error {
+	if err := checkPatchAction(d, action); err != nil {
+		return err
+	}
+
+	return d.RunHook(func() error {
+		defer lockPaths(d, src, dst)()
+
+		if action == "copy" {
+			err := fileutils.CopyContext(ctx, d.user.Fs, src, dst, progress)
+			if err != nil && ctx.Err() != nil {
+				_ = d.user.Fs.RemoveAll(dst)
 			}
-			src = path.Clean("/" + src)
-			dst = path.Clean("/" + dst)
+			return err
+		}
 
-			return fileutils.MoveFile(d.user.Fs, src, dst)
-		default:
-			return fmt.Errorf("unsupported action %s: %w", action, errors.ErrInvalidRequestParams)
+		src = path.Clean("/" + src)
+		dst = path.Clean("/" + dst)
+
+		if err := fileutils.MoveFileContext(ctx, d.user.Fs, src, dst, progress); err != nil {
+			return err
+		}
+		if err := moveMeta(d, src, dst); err != nil {
+			return err
 		}
-	}, action, src, dst, d.user)
 
-	return errToStatus(err), err
-})
+		recordMove(d, src, dst)
+		return nil
+	}, action, src, dst, d.user)
+}
 
 func checkParent(src, dst string) error {
 	rel, err := filepath.Rel(src, dst)
@@ -242,3 +850,19 @@ func addVersionSuffix(source string, fs afero.Fs) string {
 
 	return source
 }
+
+// moveMeta moves the metadata sidecar of src, if any, along with it.
+func moveMeta(d *data, src, dst string) error {
+	if _, err := d.user.Fs.Stat(files.MetaPath(src)); os.IsNotExist(err) {
+		return nil
+	}
+	if err := d.user.Fs.MkdirAll(path.Dir(files.MetaPath(dst)), 0775); err != nil {
+		return err
+	}
+	if err := fileutils.MoveFile(d.user.Fs, files.MetaPath(src), files.MetaPath(dst)); err != nil {
+		return err
+	}
+	// Fails if there are other sidecars left.
+	_ = d.user.Fs.Remove(path.Dir(files.MetaPath(src)))
+	return nil
+}
@@ -0,0 +1,655 @@
+package http
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/diskcache"
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/rules"
+	"github.com/filebrowser/filebrowser/v2/runner"
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/testutil"
+	"github.com/filebrowser/filebrowser/v2/transfers"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func newTestData(t *testing.T, entries map[string]string) *data {
+	t.Helper()
+
+	set := &settings.Settings{}
+	return &data{
+		Runner:   &runner.Runner{Settings: set},
+		settings: set,
+		server:   &settings.Server{},
+		user: &users.User{
+			Username: "test",
+			Fs:       testutil.NewFs(t, entries),
+			Perm:     users.Permissions{Create: true, Rename: true, Modify: true, Delete: true},
+		},
+	}
+}
+
+// fileTree returns the tree of the filesystem of the user, without the
+// history files the moves leave behind.
+func fileTree(t *testing.T, d *data) map[string]string {
+	t.Helper()
+
+	tree := testutil.Tree(t, d.user.Fs)
+	for name := range tree {
+		if path.Base(name) == files.HistoryFile {
+			delete(tree, name)
+		}
+	}
+	return tree
+}
+
+func TestPatchResource(t *testing.T) {
+	entries := map[string]string{
+		"/a.txt":     "a",
+		"/dir/b.txt": "b",
+	}
+
+	testCases := map[string]struct {
+		action   string
+		src, dst string
+		override bool
+		rename   bool
+		want     map[string]string
+		status   int
+	}{
+		"rename": {
+			action: "rename", src: "/a.txt", dst: "/c.txt",
+			want:   map[string]string{"/c.txt": "a", "/dir/": "", "/dir/b.txt": "b"},
+			status: http.StatusOK,
+		},
+		"move into directory": {
+			action: "rename", src: "/a.txt", dst: "/dir/a.txt",
+			want:   map[string]string{"/dir/": "", "/dir/a.txt": "a", "/dir/b.txt": "b"},
+			status: http.StatusOK,
+		},
+		"copy": {
+			action: "copy", src: "/dir", dst: "/copy",
+			want: map[string]string{
+				"/a.txt": "a", "/dir/": "", "/dir/b.txt": "b", "/copy/": "", "/copy/b.txt": "b",
+			},
+			status: http.StatusOK,
+		},
+		"copy with version suffix": {
+			action: "copy", src: "/a.txt", dst: "/a.txt", rename: true,
+			want:   map[string]string{"/a.txt": "a", "/a(1).txt": "a", "/dir/": "", "/dir/b.txt": "b"},
+			status: http.StatusOK,
+		},
+		"existing destination": {
+			action: "rename", src: "/a.txt", dst: "/dir/b.txt",
+			want:   map[string]string{"/a.txt": "a", "/dir/": "", "/dir/b.txt": "b"},
+			status: http.StatusConflict,
+		},
+		"into itself": {
+			action: "copy", src: "/dir", dst: "/dir/sub",
+			want:   map[string]string{"/a.txt": "a", "/dir/": "", "/dir/b.txt": "b"},
+			status: http.StatusBadRequest,
+		},
+		"name too long": {
+			action: "rename", src: "/a.txt", dst: "/" + strings.Repeat("a", 300) + ".txt",
+			want:   map[string]string{"/a.txt": "a", "/dir/": "", "/dir/b.txt": "b"},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for name, tt := range testCases {
+		t.Run(name, func(t *testing.T) {
+			d := newTestData(t, entries)
+
+			status, _ := patchResource(d, tt.action, tt.src, tt.dst, tt.override, tt.rename)
+			require.Equal(t, tt.status, status)
+
+			require.Equal(t, tt.want, fileTree(t, d))
+		})
+	}
+}
+
+func TestDeleteResource(t *testing.T) {
+	d := newTestData(t, map[string]string{
+		"/a.txt":     "a",
+		"/dir/b.txt": "b",
+	})
+
+	status, err := deleteResource(context.Background(), d, diskcache.NewNoOp(), "/dir")
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, map[string]string{"/a.txt": "a"}, testutil.Tree(t, d.user.Fs))
+
+	status, _ = deleteResource(context.Background(), d, diskcache.NewNoOp(), "/")
+	require.Equal(t, http.StatusForbidden, status)
+
+	d.user.Perm.Delete = false
+	status, _ = deleteResource(context.Background(), d, diskcache.NewNoOp(), "/a.txt")
+	require.Equal(t, http.StatusForbidden, status)
+}
+
+func TestUploadChecksum(t *testing.T) {
+	content := "hello world"
+	sum := sha256.Sum256([]byte(content))
+
+	testCases := map[string]struct {
+		header, value string
+		body          string
+		status        int
+		want          map[string]string
+	}{
+		"matching sha256": {
+			header: "X-Content-SHA256", value: hex.EncodeToString(sum[:]), body: content,
+			status: http.StatusOK, want: map[string]string{"/up.txt": content},
+		},
+		"matching md5": {
+			header: "X-Content-MD5", value: "5eb63bbbe01eeed093cb22bb8f5acdc3", body: content,
+			status: http.StatusOK, want: map[string]string{"/up.txt": content},
+		},
+		"corrupted body": {
+			header: "X-Content-SHA256", value: hex.EncodeToString(sum[:]), body: "hello wor1d",
+			status: http.StatusUnprocessableEntity, want: map[string]string{},
+		},
+		"truncated body": {
+			header: "X-Content-SHA1", value: "2aae6c35c94fcfb415dbe95f408b9ce91ee846ed", body: "hello",
+			status: http.StatusUnprocessableEntity, want: map[string]string{},
+		},
+		"invalid checksum": {
+			header: "X-Content-SHA256", value: "not hex", body: content,
+			status: http.StatusBadRequest, want: map[string]string{},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			d := newTestData(t, nil)
+
+			r := httptest.NewRequest(http.MethodPost, "/up.txt", strings.NewReader(tc.body))
+			r.Header.Set(tc.header, tc.value)
+
+			status, _ := resourcePostPut(httptest.NewRecorder(), r, d)
+			require.Equal(t, tc.status, status)
+			require.Equal(t, tc.want, fileTree(t, d))
+		})
+	}
+}
+
+func TestSaveNormalization(t *testing.T) {
+	sent := "a\r\nb"
+	sum := sha256.Sum256([]byte(sent))
+
+	testCases := map[string]struct {
+		method  string
+		headers map[string]string
+		status  int
+		want    string
+	}{
+		"configured":        {http.MethodPut, nil, http.StatusOK, "a\nb\n"},
+		"header overrides":  {http.MethodPut, map[string]string{lineEndingsHeader: "crlf", finalNewlineHeader: "false"}, http.StatusOK, "a\r\nb"},
+		"preserved":         {http.MethodPut, map[string]string{lineEndingsHeader: "preserve", finalNewlineHeader: "false"}, http.StatusOK, sent},
+		"checksum of sent":  {http.MethodPut, map[string]string{"X-Content-SHA256": hex.EncodeToString(sum[:])}, http.StatusOK, "a\nb\n"},
+		"invalid header":    {http.MethodPut, map[string]string{finalNewlineHeader: "maybe"}, http.StatusBadRequest, sent},
+		"uploads untouched": {http.MethodPost, nil, http.StatusOK, sent},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			d := newTestData(t, map[string]string{"/a.txt": sent})
+			d.server.SaveLineEndings = files.LineEndingsLF
+			d.server.SaveFinalNewline = true
+
+			r := httptest.NewRequest(tc.method, "/a.txt?override=true", strings.NewReader(sent))
+			for header, value := range tc.headers {
+				r.Header.Set(header, value)
+			}
+
+			status, _ := resourcePostPut(httptest.NewRecorder(), r, d)
+			require.Equal(t, tc.status, status)
+			require.Equal(t, map[string]string{"/a.txt": tc.want}, fileTree(t, d))
+		})
+	}
+}
+
+// abortedBody is the body of an upload whose client goes away midway.
+type abortedBody struct {
+	sent bool
+}
+
+func (b *abortedBody) Read(p []byte) (int, error) {
+	if b.sent {
+		return 0, io.ErrUnexpectedEOF
+	}
+	b.sent = true
+	return copy(p, "partial"), nil
+}
+
+func TestUploadAborted(t *testing.T) {
+	testCases := map[string]struct {
+		entries map[string]string
+		method  string
+		target  string
+	}{
+		"new file": {
+			entries: map[string]string{"/dir/": ""},
+			method:  http.MethodPost, target: "/dir/up.txt",
+		},
+		"overwrite": {
+			entries: map[string]string{"/dir/": "", "/dir/up.txt": "original"},
+			method:  http.MethodPost, target: "/dir/up.txt?override=true",
+		},
+		"save": {
+			entries: map[string]string{"/dir/": "", "/dir/up.txt": "original"},
+			method:  http.MethodPut, target: "/dir/up.txt",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			d := newTestData(t, tc.entries)
+
+			r := httptest.NewRequest(tc.method, tc.target, &abortedBody{})
+			status, err := resourcePostPut(httptest.NewRecorder(), r, d)
+			require.Error(t, err)
+			require.NotEqual(t, http.StatusOK, status)
+
+			// The target is untouched and no temporary file remains.
+			require.Equal(t, tc.entries, fileTree(t, d))
+		})
+	}
+}
+
+func TestUploadHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the hooks are shell commands")
+	}
+
+	testCases := map[string]struct {
+		exit   int
+		reject bool
+		status int
+		want   map[string]string
+	}{
+		"rejected": {
+			exit: 1, reject: true,
+			status: http.StatusUnprocessableEntity, want: map[string]string{"/up.txt": "original"},
+		},
+		"accepted": {
+			exit: 0, reject: true,
+			status: http.StatusOK, want: map[string]string{"/up.txt": "new"},
+		},
+		"failed without rejection": {
+			exit: 1, reject: false,
+			status: http.StatusOK, want: map[string]string{"/up.txt": "new"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			hooked := filepath.Join(t.TempDir(), "hooked")
+			d := newTestData(t, map[string]string{"/up.txt": "original"})
+			d.server.UploadHook = fmt.Sprintf("sh -c 'echo $FILE > %s; exit %d'", hooked, tc.exit)
+			d.server.RejectOnHookFailure = tc.reject
+
+			r := httptest.NewRequest(http.MethodPut, "/up.txt", strings.NewReader("new"))
+			status, _ := resourcePostPut(httptest.NewRecorder(), r, d)
+			require.Equal(t, tc.status, status)
+			require.Equal(t, tc.want, fileTree(t, d))
+
+			// Hooks that can reject the upload run before it completes.
+			var hookedPath string
+			require.Eventually(t, func() bool {
+				content, err := ioutil.ReadFile(hooked)
+				hookedPath = strings.TrimSpace(string(content))
+				return err == nil && hookedPath != ""
+			}, time.Second, 10*time.Millisecond)
+			if tc.reject {
+				require.True(t, files.IsUploadTemp(path.Base(hookedPath)), hookedPath)
+			} else {
+				require.Equal(t, "/up.txt", hookedPath)
+			}
+		})
+	}
+}
+
+func TestUploadInvisibleUntilComplete(t *testing.T) {
+	d := newTestData(t, map[string]string{"/up.txt": "original"})
+
+	body, writer := io.Pipe()
+	done := make(chan int)
+	go func() {
+		r := httptest.NewRequest(http.MethodPut, "/up.txt", body)
+		status, _ := resourcePostPut(httptest.NewRecorder(), r, d)
+		done <- status
+	}()
+
+	_, err := writer.Write([]byte("new "))
+	require.NoError(t, err)
+
+	// While the upload runs, the old content is still served and the
+	// temporary file isn't listed.
+	content, err := afero.ReadFile(d.user.Fs, "/up.txt")
+	require.NoError(t, err)
+	require.Equal(t, "original", string(content))
+
+	listing, err := files.NewFileInfo(files.FileOptions{Fs: d.user.Fs, Path: "/", Expand: true, Checker: d})
+	require.NoError(t, err)
+	require.Len(t, listing.Items, 1)
+
+	_, err = writer.Write([]byte("content"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+	require.Equal(t, http.StatusOK, <-done)
+
+	require.Equal(t, map[string]string{"/up.txt": "new content"}, fileTree(t, d))
+}
+
+func TestCanonicalSlash(t *testing.T) {
+	testCases := map[string]struct {
+		target, path string
+		location     string
+	}{
+		"directory without slash": {
+			target: "/api/resources/dir?listing=true", path: "/dir",
+			location: "/api/resources/dir/?listing=true",
+		},
+		"file with slash": {
+			target: "/api/resources/dir/a%20b.txt/", path: "/dir/a b.txt/",
+			location: "/api/resources/dir/a%20b.txt",
+		},
+		"canonical directory": {
+			target: "/api/resources/dir/", path: "/dir/",
+		},
+		"canonical file": {
+			target: "/api/resources/dir/a%20b.txt", path: "/dir/a b.txt",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			d := newTestData(t, map[string]string{"/dir/a b.txt": "a"})
+			d.server.CanonicalSlash = true
+
+			r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+			r.URL.Path = tc.path
+			w := httptest.NewRecorder()
+
+			status, err := resourceGet(w, r, d, nil)
+			require.NoError(t, err)
+			require.Equal(t, 0, status)
+			if tc.location == "" {
+				require.Equal(t, http.StatusOK, w.Code)
+			} else {
+				require.Equal(t, http.StatusMovedPermanently, w.Code)
+				require.Equal(t, tc.location, w.Header().Get("Location"))
+			}
+		})
+	}
+}
+
+func TestListingETag(t *testing.T) {
+	d := newTestData(t, map[string]string{"/dir/a.txt": "a"})
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/dir/", nil)
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		status, err := resourceGet(w, r, d, nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, status)
+		return w
+	}
+
+	etag := get("").Header().Get("ETag")
+	require.NotEmpty(t, etag)
+	require.Equal(t, http.StatusNotModified, get(etag).Code)
+
+	require.NoError(t, afero.WriteFile(d.user.Fs, "/dir/b.txt", []byte("b"), 0644))
+
+	w := get(etag)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotEqual(t, etag, w.Header().Get("ETag"))
+	etag = w.Header().Get("ETag")
+
+	require.NoError(t, d.user.Fs.Rename("/dir/b.txt", "/dir/c.txt"))
+	require.NotEqual(t, etag, get(etag).Header().Get("ETag"))
+}
+
+func TestResourceHead(t *testing.T) {
+	d := newTestData(t, map[string]string{"/docs/a.txt": "hello"})
+
+	head := func(p string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		status, err := resourceHead(w, httptest.NewRequest(http.MethodHead, p, nil), d)
+		require.NoError(t, err)
+		require.Zero(t, status)
+		return w
+	}
+
+	w := head("/docs/a.txt")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "5", w.Header().Get("Content-Length"))
+	require.NotEmpty(t, w.Header().Get("Last-Modified"))
+	require.Empty(t, w.Header().Get("X-Exists"))
+
+	w = head("/missing.txt")
+	require.Equal(t, http.StatusNotFound, w.Code)
+	require.Empty(t, w.Header().Get("X-Exists"))
+
+	d.server.HeadExistsHeader = true
+	for _, p := range []string{"/missing.txt", "/missing/", "/docs/missing/b.txt"} {
+		w = head(p)
+		require.Equal(t, http.StatusNotFound, w.Code, p)
+		require.Equal(t, "false", w.Header().Get("X-Exists"), p)
+		require.Zero(t, w.Body.Len(), p)
+	}
+
+	w = head("/docs/")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "true", w.Header().Get("X-Exists"))
+	require.Empty(t, w.Header().Get("Content-Length"))
+}
+
+func TestListingView(t *testing.T) {
+	d := newTestData(t, map[string]string{"/photos/a.jpg": "a", "/photos/b.png": "b", "/photos/c.txt": "c"})
+	d.user.ViewMode = users.ListViewMode
+
+	view := func(query string) (string, int) {
+		r := httptest.NewRequest(http.MethodGet, "/photos/"+query, nil)
+		w := httptest.NewRecorder()
+		status, err := resourceGet(w, r, d, nil)
+		if err != nil {
+			return "", status
+		}
+
+		var listing struct {
+			View string `json:"view"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listing))
+		return listing.View, status
+	}
+
+	v, _ := view("")
+	require.Equal(t, "list", v)
+	v, _ = view("?view=mosaic")
+	require.Equal(t, "mosaic", v)
+
+	d.server.AutoView = true
+	v, _ = view("")
+	require.Equal(t, "mosaic", v)
+	v, _ = view("?view=list")
+	require.Equal(t, "list", v)
+
+	require.NoError(t, afero.WriteFile(d.user.Fs, "/photos/.view", []byte("list"), 0644))
+	v, _ = view("")
+	require.Equal(t, "list", v, "the directory's view wins over the automatic one")
+	v, _ = view("?view=mosaic")
+	require.Equal(t, "mosaic", v)
+
+	_, status := view("?view=grid")
+	require.Equal(t, http.StatusBadRequest, status)
+}
+
+func TestServeIndexFiles(t *testing.T) {
+	var lines []string
+	for i := 1; i <= 1500; i++ {
+		lines = append(lines, fmt.Sprintf("<p>line %d</p>", i))
+	}
+	d := newTestData(t, map[string]string{
+		"/site/index.html": strings.Join(lines, "\n"),
+		"/site/style.css":  "body {}",
+	})
+	d.server.ServeIndexFiles = true
+	d.server.IndexNames = []string{"index.html"}
+
+	get := func(query string) map[string]interface{} {
+		r := httptest.NewRequest(http.MethodGet, "/site/"+query, nil)
+		w := httptest.NewRecorder()
+		status, err := resourceGet(w, r, d, nil)
+		require.NoError(t, err)
+		require.Equal(t, 0, status)
+
+		var file map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &file))
+		return file
+	}
+
+	file := get("")
+	require.Equal(t, "/site/index.html", file["path"])
+	require.Contains(t, file["content"], "<p>line 1</p>")
+
+	// The options of the request apply to the index file too.
+	file = get("?line=1200")
+	require.Equal(t, "/site/index.html", file["path"])
+	require.EqualValues(t, 1200, file["line"])
+	require.EqualValues(t, 700, file["firstLine"])
+	require.NotContains(t, file["content"], "<p>line 1</p>")
+	require.Contains(t, file["content"], "<p>line 1200</p>")
+
+	file = get("?listing=true")
+	require.Equal(t, "/site/", file["path"])
+	require.Equal(t, true, file["isDir"])
+}
+
+func TestAsyncCopy(t *testing.T) {
+	d := newTestData(t, map[string]string{"/dir/a.txt": "aaaa", "/dir/b.txt": "bb"})
+	reg := transfers.NewRegistry(time.Hour)
+
+	r := httptest.NewRequest(http.MethodPatch, "/dir", nil)
+	w := httptest.NewRecorder()
+	status, err := startTransfer(w, r, d, reg, "copy", "/dir", "/copy", false, false)
+	require.NoError(t, err)
+	require.Equal(t, 0, status)
+	require.Equal(t, http.StatusAccepted, w.Code)
+
+	var started transfers.Transfer
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&started))
+	require.Equal(t, int64(6), started.Total)
+
+	var transfer transfers.Transfer
+	require.Eventually(t, func() bool {
+		var ok bool
+		transfer, ok = reg.Get(d.user.ID, started.ID)
+		return ok && transfer.Status != transfers.Running
+	}, time.Second, time.Millisecond)
+
+	require.Equal(t, transfers.Done, transfer.Status)
+	require.Equal(t, int64(6), transfer.Done)
+	require.Equal(t, "aaaa", fileTree(t, d)["/copy/a.txt"])
+
+	status, _ = startTransfer(httptest.NewRecorder(), r, d, reg, "copy", "/dir", "/copy", false, false)
+	require.Equal(t, http.StatusConflict, status)
+}
+
+func TestUploadNameTooLong(t *testing.T) {
+	testCases := map[string]struct {
+		path   string
+		status int
+	}{
+		"long name":              {path: "/" + strings.Repeat("a", 300) + ".txt", status: http.StatusBadRequest},
+		"long directory":         {path: "/" + strings.Repeat("a", 300) + "/", status: http.StatusBadRequest},
+		"multibyte name":         {path: "/" + strings.Repeat("é", 130), status: http.StatusBadRequest},
+		"longest multibyte name": {path: "/" + strings.Repeat("é", 127), status: http.StatusOK},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			d := newTestData(t, nil)
+
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("content"))
+			r.URL.Path = tc.path
+			status, _ := resourcePostPut(httptest.NewRecorder(), r, d)
+			require.Equal(t, tc.status, status)
+
+			if tc.status != http.StatusOK {
+				require.Empty(t, fileTree(t, d))
+			}
+		})
+	}
+}
+
+func TestResourceAlias(t *testing.T) {
+	d := newTestData(t, map[string]string{
+		"/releases/v2.3.1/app":       "v2.3.1",
+		"/releases/v2.3.1/bin/tool":  "tool",
+		"/releases/v2.2.0/app":       "v2.2.0",
+		"/releases/v2.3.1/notes.txt": "notes",
+	})
+	d.server.Aliases = map[string]string{"/latest": "/releases/v2.3.1", "/loop": "/loop/x"}
+	get := func(p string) (*httptest.ResponseRecorder, int, error) {
+		// Each request has its own data.
+		reqData := *d
+		w := httptest.NewRecorder()
+		status, err := withAlias(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+			return resourceGet(w, r, d, nil)
+		})(w, httptest.NewRequest(http.MethodGet, p, nil), &reqData)
+		return w, status, err
+	}
+
+	w, status, err := get("/latest/")
+	require.NoError(t, err)
+	require.Zero(t, status)
+	var listing struct {
+		Path  string `json:"path"`
+		Items []struct {
+			Path string `json:"path"`
+		} `json:"items"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &listing))
+	require.Equal(t, "/latest/", listing.Path)
+	paths := []string{}
+	for _, item := range listing.Items {
+		paths = append(paths, item.Path)
+	}
+	require.ElementsMatch(t, []string{"/latest/app", "/latest/bin", "/latest/notes.txt"}, paths)
+
+	w, status, err = get("/latest/notes.txt")
+	require.NoError(t, err)
+	require.Zero(t, status)
+	require.Contains(t, w.Body.String(), `"content":"notes"`)
+
+	_, status, err = get("/loop/")
+	require.Error(t, err)
+	require.Equal(t, http.StatusLoopDetected, status)
+
+	d.user.Rules = []rules.Rule{{Path: "/releases", Allow: false}}
+	_, status, _ = get("/latest/app")
+	require.Equal(t, http.StatusForbidden, status, "the rules apply to the real paths")
+}
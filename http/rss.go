@@ -0,0 +1,116 @@
+package http
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title       string    `xml:"title"`
+	Link        string    `xml:"link"`
+	Description string    `xml:"description"`
+	Items       []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	Description string  `xml:"description"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+// renderRSS renders an RSS feed of the files of the listing, the most
+// recently modified first, so new files in a directory can be followed
+// from a feed reader. The listing only has the files the user can see.
+func renderRSS(w http.ResponseWriter, r *http.Request, d *data, dir *files.FileInfo) (int, error) {
+	title := path.Base(dir.Path)
+	if title == "/" || title == "." {
+		title = d.settings.Branding.Name
+		if title == "" {
+			title = "File Browser"
+		}
+	}
+
+	items := make([]*files.FileInfo, 0, dir.NumFiles)
+	for _, item := range dir.Items {
+		if !item.IsDir {
+			items = append(items, item)
+		}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		return items[i].ModTime.After(items[j].ModTime)
+	})
+
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:       title,
+			Link:        pageURL(r, d, "/files", dir.Path, true),
+			Description: "Files of " + dir.Path,
+			Items:       make([]rssItem, 0, len(items)),
+		},
+	}
+
+	for _, item := range items {
+		link := pageURL(r, d, "/files", item.Path, false)
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title: item.Name,
+			Link:  link,
+			// The modification time is part of the id so readers notify
+			// about files whose content changed too.
+			GUID: rssGUID{
+				Value: link + "#" + item.ModTime.UTC().Format(time.RFC3339Nano),
+			},
+			Description: humanSize(item.Size) + ", modified " + item.ModTime.Format(time.RFC1123Z),
+			PubDate:     item.ModTime.Format(time.RFC1123Z),
+		})
+	}
+
+	output, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+	if _, err := w.Write([]byte(xml.Header)); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if _, err := w.Write(output); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return 0, nil
+}
+
+// humanSize formats a size in bytes with a binary unit, e.g. "1.5 KiB".
+func humanSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
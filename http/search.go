@@ -4,25 +4,45 @@ import (
 	"net/http"
 	"os"
 
+	"github.com/spf13/afero"
+
 	"github.com/filebrowser/filebrowser/v2/search"
 )
 
-var searchHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
-	response := []map[string]interface{}{}
-	query := r.URL.Query().Get("query")
+// searchHandler searches the files of the user, through the index if it
+// is ready, or else by walking their directory.
+func searchHandler(index *search.Index) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		response := []map[string]interface{}{}
+		query := r.URL.Query().Get("query")
 
-	err := search.Search(d.user.Fs, r.URL.Path, query, d, func(path string, f os.FileInfo) error {
-		response = append(response, map[string]interface{}{
-			"dir":  f.IsDir(),
-			"path": path,
-		})
+		found := func(path string, isDir bool) error {
+			response = append(response, map[string]interface{}{
+				"dir":  isDir,
+				"path": path,
+			})
 
-		return nil
-	})
+			return nil
+		}
 
-	if err != nil {
-		return http.StatusInternalServerError, err
-	}
+		if _, ok := d.user.Fs.(*afero.BasePathFs); ok && index.Ready() {
+			searched, err := index.Search(d.user.FullPath("/"), r.URL.Path, query, d, found)
+			if err != nil {
+				return http.StatusInternalServerError, err
+			}
+			if searched {
+				return renderJSON(w, r, response)
+			}
+		}
 
-	return renderJSON(w, r, response)
-})
+		err := search.Search(d.user.Fs, r.URL.Path, query, d, func(path string, f os.FileInfo) error {
+			return found(path, f.IsDir())
+		})
+
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+
+		return renderJSON(w, r, response)
+	})
+}
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+// selectHandler returns the paths of the entries of the directory whose
+// name matches the glob of the query, for the clients to run a batch
+// operation on all of them. They're the entries the listing shows, so the
+// selection respects the hidden files and rules of the user.
+var selectHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if !d.Check(r.URL.Path) {
+		return http.StatusForbidden, nil
+	}
+
+	if !d.server.FollowSymlinks {
+		if err := files.CheckSymlinks(d.user.Fs, r.URL.Path); err != nil {
+			return errToStatus(err), err
+		}
+	}
+
+	info, err := d.user.Fs.Stat(r.URL.Path)
+	if err != nil {
+		return errToStatus(err), err
+	}
+	if !info.IsDir() {
+		return http.StatusBadRequest, errors.ErrInvalidRequestParams
+	}
+
+	paths, err := files.Select(d.user.Fs, r.URL.Path, r.URL.Query().Get("glob"), d)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return renderJSON(w, r, paths)
+})
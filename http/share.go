@@ -109,19 +109,7 @@ var sharePostHandler = withPermShare(func(w http.ResponseWriter, r *http.Request
 			return http.StatusInternalServerError, err
 		}
 
-		var add time.Duration
-		switch unit {
-		case "seconds":
-			add = time.Second * time.Duration(num)
-		case "minutes":
-			add = time.Minute * time.Duration(num)
-		case "days":
-			add = time.Hour * 24 * time.Duration(num)
-		default:
-			add = time.Hour * time.Duration(num)
-		}
-
-		expire = time.Now().Add(add).Unix()
+		expire = time.Now().Add(expireDuration(num, unit)).Unix()
 	}
 
 	s = &share.Link{
@@ -137,3 +125,50 @@ var sharePostHandler = withPermShare(func(w http.ResponseWriter, r *http.Request
 
 	return renderJSON(w, r, s)
 })
+
+func expireDuration(num int, unit string) time.Duration {
+	switch unit {
+	case "seconds":
+		return time.Second * time.Duration(num)
+	case "minutes":
+		return time.Minute * time.Duration(num)
+	case "days":
+		return time.Hour * 24 * time.Duration(num)
+	default:
+		return time.Hour * time.Duration(num)
+	}
+}
+
+type shareToken struct {
+	Token  string `json:"token"`
+	URL    string `json:"url"`
+	Expire int64  `json:"expire"`
+}
+
+var shareTokenPostHandler = withPermShare(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	num, err := strconv.Atoi(r.URL.Query().Get("expires"))
+	if err != nil || num <= 0 {
+		return http.StatusBadRequest, err
+	}
+
+	if !d.Check(r.URL.Path) {
+		return http.StatusForbidden, nil
+	}
+
+	if _, err := d.user.Fs.Stat(r.URL.Path); err != nil {
+		return errToStatus(err), err
+	}
+
+	link := &share.Link{
+		Path:   r.URL.Path,
+		UserID: d.user.ID,
+		Expire: time.Now().Add(expireDuration(num, r.URL.Query().Get("unit"))).Unix(),
+	}
+	token := share.NewToken(d.settings.ShareKey, link)
+
+	return renderJSON(w, r, &shareToken{
+		Token:  token,
+		URL:    path.Join(d.server.BaseURL, "/api/public/token/", token),
+		Expire: link.Expire,
+	})
+})
@@ -0,0 +1,26 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/tomasen/realip"
+
+	"github.com/filebrowser/filebrowser/v2/signedurl"
+)
+
+// requireSignature only lets the requests with a valid signed URL through,
+// see signedurl.SignURL. The signed path is the one of the request, below
+// the base URL, such as /api/raw/docs/report.pdf.
+func requireSignature(next http.Handler, secret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := signedurl.Verify(r.URL, secret, time.Now()); err != nil {
+			log.Printf("%s: %v %s %v", r.URL.Path, http.StatusForbidden, realip.FromRequest(r), err)
+			writeError(w, r, http.StatusForbidden, "")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,129 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asdine/storm"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/settings"
+	"github.com/filebrowser/filebrowser/v2/signedurl"
+	"github.com/filebrowser/filebrowser/v2/storage/bolt"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+func TestRequireSignature(t *testing.T) {
+	next := http.StripPrefix("/api/raw", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	}))
+	handler := requireSignature(next, "secret")
+
+	testCases := map[string]struct {
+		url    string
+		status int
+	}{
+		"signed":       {url: signedurl.SignURL("/api/raw/docs/a b.txt", time.Minute, "secret") + "&inline=true", status: http.StatusOK},
+		"unsigned":     {url: "/api/raw/docs/a%20b.txt", status: http.StatusForbidden},
+		"expired":      {url: signedurl.SignURL("/api/raw/docs/a b.txt", -time.Minute, "secret"), status: http.StatusForbidden},
+		"other secret": {url: signedurl.SignURL("/api/raw/docs/a b.txt", time.Minute, "other"), status: http.StatusForbidden},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.url, nil))
+			require.Equal(t, tc.status, w.Code)
+			if tc.status == http.StatusOK {
+				require.Equal(t, "/docs/a b.txt", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRequireSignatureRoutes(t *testing.T) {
+	db, err := storm.Open(filepath.Join(t.TempDir(), "filebrowser.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	store, err := bolt.NewStorage(db)
+	require.NoError(t, err)
+
+	handler, err := newRouter(http.NotFoundHandler(), http.NotFoundHandler(), nil, nil, store, &settings.Server{
+		Root:              t.TempDir(),
+		EnableWebDAV:      true,
+		RequireSignedURLs: true,
+		URLSigningSecret:  "secret",
+	})
+	require.NoError(t, err)
+
+	// Whatever their prefix, the routes touching files refuse the unsigned
+	// requests before reading anything.
+	for _, tc := range []struct{ method, target, body string }{
+		{"PROPFIND", "/dav/docs/", ""},
+		{http.MethodGet, "/dav/docs/a.txt", ""},
+		{http.MethodPost, "/api/batch", `[{"op":"stat","path":"/docs/a.txt"}]`},
+		{http.MethodGet, "/api/pins", ""},
+		{http.MethodGet, "/api/clipboard", ""},
+		{http.MethodGet, "/api/resources/docs/", ""},
+		{http.MethodGet, "/api/raw/docs/a.txt", ""},
+	} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)))
+		require.Equal(t, http.StatusForbidden, w.Code, "%s %s", tc.method, tc.target)
+	}
+}
+
+func TestRequireSignatureBodyRoutes(t *testing.T) {
+	db, err := storm.Open(filepath.Join(t.TempDir(), "filebrowser.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	store, err := bolt.NewStorage(db)
+	require.NoError(t, err)
+	set := &settings.Settings{Key: []byte("key")}
+	require.NoError(t, store.Settings.Save(set))
+	user := &users.User{Username: "admin", Password: "password", Scope: ".", Perm: users.Permissions{Delete: true}}
+	require.NoError(t, store.Users.Save(user))
+
+	w := httptest.NewRecorder()
+	_, err = printToken(w, nil, &data{settings: set}, user)
+	require.NoError(t, err)
+	token := w.Body.String()
+
+	handler, err := newRouter(http.NotFoundHandler(), http.NotFoundHandler(), nil, nil, store, &settings.Server{
+		Root:              t.TempDir(),
+		RequireSignedURLs: true,
+		URLSigningSecret:  "secret",
+	})
+	require.NoError(t, err)
+
+	signed := func(path string) string {
+		return signedurl.SignURL(path, time.Minute, "secret")
+	}
+
+	// The routes taking their paths from the body are refused even when
+	// signed, the signature not covering the body.
+	for _, tc := range []struct{ method, target, body string }{
+		{http.MethodPost, signed("/api/batch"), `[{"op":"delete","params":{"path":"/"}}]`},
+		{http.MethodGet, signed("/api/clipboard"), ""},
+		{http.MethodPost, signed("/api/clipboard"), `{"items":["/"]}`},
+		{http.MethodPost, signed("/api/paste/"), ""},
+		{http.MethodPost, signed("/api/compress/"), `{"sources":["/"],"archiveName":"a.zip"}`},
+	} {
+		r := httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body))
+		r.Header.Set("X-Auth", token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		require.Equal(t, http.StatusForbidden, w.Code, "%s %s", tc.method, tc.target)
+	}
+
+	// The other signed routes are served.
+	r := httptest.NewRequest(http.MethodGet, signed("/api/resources/"), nil)
+	r.Header.Set("X-Auth", token)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code)
+}
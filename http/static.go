@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
 	"path"
 	"path/filepath"
@@ -13,13 +14,15 @@ import (
 	rice "github.com/GeertJohan/go.rice"
 
 	"github.com/filebrowser/filebrowser/v2/auth"
+	"github.com/filebrowser/filebrowser/v2/files"
 	"github.com/filebrowser/filebrowser/v2/settings"
 	"github.com/filebrowser/filebrowser/v2/storage"
 	"github.com/filebrowser/filebrowser/v2/version"
 )
 
-func handleWithStaticData(w http.ResponseWriter, _ *http.Request, d *data, box *rice.Box, file, contentType string) (int, error) {
+func handleWithStaticData(w http.ResponseWriter, r *http.Request, d *data, box *rice.Box, file, contentType string) (int, error) {
 	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept-Language")
 
 	auther, err := d.store.Auth.Get(d.settings.AuthMethod)
 	if err != nil {
@@ -42,6 +45,17 @@ func handleWithStaticData(w http.ResponseWriter, _ *http.Request, d *data, box *
 		"EnableThumbs":    d.server.EnableThumbnails,
 		"ResizePreview":   d.server.ResizePreview,
 		"EnableExec":      d.server.EnableExec,
+		"ListingColumns":  d.server.ListingColumns,
+		"OfficePreview":   d.server.OfficeConverter != "",
+		"PDFThumbs":       d.server.PDFRenderer != "",
+	}
+
+	locales := offeredLocales(d.server.UILocales)
+	data["Locales"] = locales
+	data["Locale"], data["LocaleExplicit"] = requestLocale(r, locales)
+
+	if d.server.ListingColumns == nil {
+		data["ListingColumns"] = files.DefaultListingColumns
 	}
 
 	if d.settings.Branding.Files != "" {
@@ -79,6 +93,10 @@ func handleWithStaticData(w http.ResponseWriter, _ *http.Request, d *data, box *
 
 	data["Json"] = string(b)
 
+	// Added after the JSON since they are only needed by the template.
+	data["HeaderHTML"] = d.server.HeaderHTML
+	data["FooterHTML"] = d.server.FooterHTML
+
 	fileContents, err := box.String(file)
 	if err != nil {
 		if err == os.ErrNotExist {
@@ -104,6 +122,11 @@ func getStaticHandlers(store *storage.Storage, server *settings.Server) (index,
 			return http.StatusNotFound, nil
 		}
 
+		if home := homeRedirect(r, d.server); home != "" {
+			http.Redirect(w, r, home, http.StatusFound)
+			return 0, nil
+		}
+
 		w.Header().Set("x-xss-protection", "1; mode=block")
 		return handleWithStaticData(w, r, d, box, "index.html", "text/html; charset=utf-8")
 	}, "", store, server)
@@ -136,3 +159,20 @@ func getStaticHandlers(store *storage.Storage, server *settings.Server) (index,
 
 	return index, static
 }
+
+// homeRedirect returns the URL of the home path the root of the files
+// should redirect to, or an empty string if there is no redirect. The
+// nohome parameter allows to see the real root.
+func homeRedirect(r *http.Request, server *settings.Server) string {
+	home := path.Clean("/" + server.HomePath)
+	if server.HomePath == "" || home == "/" || r.URL.Query().Get("nohome") != "" {
+		return ""
+	}
+
+	switch r.URL.Path {
+	case "", "/", "/files", "/files/":
+		return server.BaseURL + "/files" + (&url.URL{Path: home}).EscapedPath() + "/"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,29 @@
+package http
+
+import (
+	"io"
+	"net/http"
+)
+
+// streamWriter copies the content served into its response through a
+// buffer of its size, instead of the one of the server.
+type streamWriter struct {
+	http.ResponseWriter
+	size int
+}
+
+// withStreamBuffer returns the response writer copying the content served
+// to w, such as by http.ServeContent, through a size bytes buffer. It
+// returns w if size isn't positive.
+func withStreamBuffer(w http.ResponseWriter, size int) http.ResponseWriter {
+	if size <= 0 {
+		return w
+	}
+	return streamWriter{ResponseWriter: w, size: size}
+}
+
+// ReadFrom implements io.ReaderFrom, which io.Copy uses.
+func (w streamWriter) ReadFrom(r io.Reader) (int64, error) {
+	// Hiding the ReadFrom of the response makes the buffer the one used.
+	return io.CopyBuffer(struct{ io.Writer }{w.ResponseWriter}, r, make([]byte, w.size))
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// chunkRecorder records the size of the largest write.
+type chunkRecorder struct {
+	*httptest.ResponseRecorder
+	largest int
+}
+
+func (w *chunkRecorder) Write(p []byte) (int, error) {
+	if len(p) > w.largest {
+		w.largest = len(p)
+	}
+	return w.ResponseRecorder.Write(p)
+}
+
+func TestStreamBuffer(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 10000)
+
+	serve := func(size int, rangeHeader string) *chunkRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/big.bin", nil)
+		if rangeHeader != "" {
+			r.Header.Set("Range", rangeHeader)
+		}
+		w := &chunkRecorder{ResponseRecorder: httptest.NewRecorder()}
+		http.ServeContent(withStreamBuffer(w, size), r, "big.bin", time.Time{}, bytes.NewReader(content))
+		return w
+	}
+
+	w := serve(1000, "")
+	require.Equal(t, content, w.Body.Bytes())
+	require.Equal(t, 1000, w.largest)
+
+	w = serve(1000, "bytes=10-19")
+	require.Equal(t, http.StatusPartialContent, w.Code)
+	require.Equal(t, "0123456789", w.Body.String())
+
+	w = serve(0, "")
+	require.Equal(t, content, w.Body.Bytes())
+	require.Equal(t, 32*1024, w.largest, "the default of io.Copy")
+}
+
+func BenchmarkStreamBuffer(b *testing.B) {
+	content := bytes.Repeat([]byte{0xa5}, 64<<20)
+
+	for _, size := range []int{0, 4 << 10, 32 << 10, 256 << 10, 1 << 20} {
+		b.Run(strconv.Itoa(size), func(b *testing.B) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				http.ServeContent(withStreamBuffer(w, size), r, "big.bin", time.Time{}, bytes.NewReader(content))
+			}))
+			defer server.Close()
+
+			b.SetBytes(int64(len(content)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				resp, err := http.Get(server.URL)
+				if err != nil {
+					b.Fatal(err)
+				}
+				_, _ = io.Copy(ioutil.Discard, resp.Body)
+				resp.Body.Close()
+			}
+		})
+	}
+}
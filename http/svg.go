@@ -0,0 +1,130 @@
+package http
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+)
+
+const (
+	svgHandlingSanitize = "sanitize"
+	svgHandlingDownload = "download"
+	svgHandlingRaw      = "raw"
+
+	svgMimeType = "image/svg+xml"
+)
+
+// svgForbiddenElements are the elements that are dropped, together with
+// all of their children, when sanitizing an SVG. Style sheets are dropped
+// as they can load URLs, e.g. with @import or url().
+var svgForbiddenElements = map[string]bool{
+	"script":        true,
+	"foreignobject": true,
+	"iframe":        true,
+	"embed":         true,
+	"object":        true,
+	"style":         true,
+}
+
+// sanitizeSVG strips scripts, style sheets, event handlers, javascript
+// URLs and data URLs other than images from an SVG document. Comments
+// and directives (such as DOCTYPE, which may declare entities) are
+// dropped as well.
+func sanitizeSVG(in io.Reader) ([]byte, error) {
+	dec := xml.NewDecoder(in)
+	dec.Strict = false
+
+	var (
+		buf  bytes.Buffer
+		skip int
+	)
+
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if skip > 0 || svgForbiddenElements[strings.ToLower(t.Name.Local)] {
+				skip++
+				continue
+			}
+
+			buf.WriteString("<" + svgQualifiedName(t.Name))
+			for _, attr := range t.Attr {
+				if !svgAttrAllowed(attr) {
+					continue
+				}
+				buf.WriteString(" " + svgQualifiedName(attr.Name) + `="`)
+				_ = xml.EscapeText(&buf, []byte(attr.Value))
+				buf.WriteString(`"`)
+			}
+			buf.WriteString(">")
+		case xml.EndElement:
+			if skip > 0 {
+				skip--
+				continue
+			}
+			buf.WriteString("</" + svgQualifiedName(t.Name) + ">")
+		case xml.CharData:
+			if skip > 0 {
+				continue
+			}
+			_ = xml.EscapeText(&buf, t)
+		case xml.ProcInst:
+			if skip > 0 || t.Target != "xml" {
+				continue
+			}
+			buf.WriteString("<?xml " + string(t.Inst) + "?>")
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func svgQualifiedName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return name.Space + ":" + name.Local
+}
+
+func svgAttrAllowed(attr xml.Attr) bool {
+	if strings.HasPrefix(strings.ToLower(attr.Name.Local), "on") {
+		return false
+	}
+
+	// Strip whitespace and control characters browsers ignore when
+	// parsing URL schemes, e.g. "java\tscript:".
+	value := strings.Map(func(r rune) rune {
+		if r <= ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(attr.Value))
+
+	for _, scheme := range []string{"javascript:", "vbscript:"} {
+		if strings.Contains(value, scheme) {
+			return false
+		}
+	}
+
+	// Data URLs are only kept for raster images: others, such as SVG
+	// documents in <use>, would get around the sanitizing.
+	for rest := value; ; {
+		i := strings.Index(rest, "data:")
+		if i < 0 {
+			return true
+		}
+		rest = rest[i+len("data:"):]
+		if !strings.HasPrefix(rest, "image/") || strings.HasPrefix(rest, "image/svg") {
+			return false
+		}
+	}
+}
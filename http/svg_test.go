@@ -0,0 +1,81 @@
+package http
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSanitizeSVG(t *testing.T) {
+	for _, tc := range []struct {
+		name, in string
+		dropped  []string
+		kept     []string
+	}{
+		{
+			name:    "script",
+			in:      `<svg><script>alert(1)</script><circle r="1"/></svg>`,
+			dropped: []string{"script", "alert"},
+			kept:    []string{`<circle r="1">`},
+		},
+		{
+			name:    "event handler",
+			in:      `<svg onload="alert(1)"><rect onClick="alert(2)" width="1"/></svg>`,
+			dropped: []string{"onload", "onClick", "alert"},
+			kept:    []string{`<rect width="1">`},
+		},
+		{
+			name:    "javascript href",
+			in:      `<svg><a href="javascript:alert(1)"><text>x</text></a><a xlink:href="java&#x9;script:alert(2)">y</a></svg>`,
+			dropped: []string{"javascript", "alert"},
+			kept:    []string{"<text>x</text>"},
+		},
+		{
+			name:    "vbscript href",
+			in:      `<svg><a href="VBScript:msgbox(1)">x</a></svg>`,
+			dropped: []string{"msgbox"},
+			kept:    []string{"<a>x</a>"},
+		},
+		{
+			name:    "foreignObject",
+			in:      `<svg><foreignObject><iframe src="https://example.com"></iframe><p>html</p></foreignObject></svg>`,
+			dropped: []string{"foreignObject", "iframe", "html"},
+		},
+		{
+			name:    "style",
+			in:      `<svg><style>@import url(https://example.com/x.css); rect { fill: url(javascript:alert(1)) }</style><rect/></svg>`,
+			dropped: []string{"style", "@import", "alert"},
+			kept:    []string{"<rect>"},
+		},
+		{
+			name:    "svg data URL",
+			in:      `<svg><use href="data:image/svg+xml;base64,PHN2ZyBvbmxvYWQ9ImFsZXJ0KDEpIi8+#x"/><use xlink:href="DATA:text/html,&lt;script&gt;"/></svg>`,
+			dropped: []string{"data:", "DATA:"},
+			kept:    []string{"<use></use>"},
+		},
+		{
+			name: "image data URL",
+			in:   `<svg><image href="data:image/png;base64,iVBORw0KGgo="/></svg>`,
+			kept: []string{`href="data:image/png;base64,iVBORw0KGgo="`},
+		},
+		{
+			name:    "doctype entities",
+			in:      `<?xml version="1.0"?><!DOCTYPE svg [<!ENTITY x "y">]><!-- comment --><svg/>`,
+			dropped: []string{"DOCTYPE", "ENTITY", "comment"},
+			kept:    []string{`<?xml version="1.0"?>`, "<svg>"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := sanitizeSVG(strings.NewReader(tc.in))
+			require.NoError(t, err)
+			for _, s := range tc.dropped {
+				assert.NotContains(t, string(out), s)
+			}
+			for _, s := range tc.kept {
+				assert.Contains(t, string(out), s)
+			}
+		})
+	}
+}
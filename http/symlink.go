@@ -0,0 +1,106 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+var symlinkHandler = withUser(createSymlink)
+
+// createSymlink creates a symbolic link at the path pointing to the
+// relative path of the "target" parameter. The target must resolve inside
+// of the scope of the user, even through the links it goes through, so
+// the link can't be used to reach other files, and must exist.
+func createSymlink(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if !d.server.AllowSymlinkCreation {
+		return http.StatusNotFound, nil
+	}
+	if !d.user.Perm.Create {
+		return http.StatusForbidden, nil
+	}
+
+	// Links are created on the disk the scope is on.
+	if _, ok := users.RootFs.(*afero.OsFs); !ok {
+		return http.StatusNotImplemented, nil
+	}
+	if _, ok := d.user.Fs.(*afero.BasePathFs); !ok {
+		return http.StatusNotImplemented, nil
+	}
+
+	target := r.URL.Query().Get("target")
+	if target == "" {
+		return http.StatusBadRequest, fmt.Errorf("no target: %w", errors.ErrInvalidRequestParams)
+	}
+
+	link := path.Clean("/" + r.URL.Path)
+	if link == "/" || inArchive(d, link) {
+		return http.StatusBadRequest, nil
+	}
+
+	resolved, err := symlinkTarget(link, target)
+	if err != nil {
+		return http.StatusForbidden, err
+	}
+
+	if !d.Check(link) || !d.Check(resolved) {
+		return http.StatusForbidden, nil
+	}
+	if err := files.CheckSymlinks(d.user.Fs, path.Dir(link)); err != nil { //nolint:govet
+		return errToStatus(err), err
+	}
+	if err := files.CheckSymlinks(d.user.Fs, resolved); err != nil { //nolint:govet
+		return errToStatus(err), err
+	}
+	if _, err := d.user.Fs.Stat(resolved); err != nil { //nolint:govet
+		return errToStatus(err), err
+	}
+
+	err = d.RunHook(func() error {
+		defer lockPaths(d, link)()
+		// An existing file, even a broken link, fails with a conflict.
+		return os.Symlink(filepath.FromSlash(target), d.user.FullPath(link))
+	}, "symlink", link, resolved, d.user)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	file, err := files.NewFileInfo(files.FileOptions{
+		Fs:             d.user.Fs,
+		Path:           link,
+		Modify:         d.user.Perm.Modify,
+		Expand:         false,
+		ReadHeader:     d.server.TypeDetectionByHeader,
+		Checker:        d,
+		FollowSymlinks: d.server.FollowSymlinks,
+	})
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return renderJSON(w, r, file)
+}
+
+// symlinkTarget returns the path, in the scope, the target of a link at
+// link resolves to. Absolute targets and those going above the root of
+// the scope are refused with os.ErrPermission.
+func symlinkTarget(link, target string) (string, error) {
+	if path.IsAbs(target) || filepath.IsAbs(target) || strings.Contains(target, "\\") {
+		return "", fmt.Errorf("absolute target %q: %w", target, os.ErrPermission)
+	}
+
+	rel := path.Join(strings.TrimPrefix(path.Dir(link), "/"), target)
+	if rel == ".." || strings.HasPrefix(rel, "../") {
+		return "", fmt.Errorf("target %q outside of the scope: %w", target, os.ErrPermission)
+	}
+	return path.Clean("/" + rel), nil
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateSymlink(t *testing.T) {
+	dir := t.TempDir()
+	scope := filepath.Join(dir, "scope")
+	require.NoError(t, os.MkdirAll(filepath.Join(scope, "docs"), 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(scope, "docs", "a.txt"), []byte("a"), 0644))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "secret"), []byte("secret"), 0644))
+	require.NoError(t, os.Symlink(dir, filepath.Join(scope, "out")))
+
+	d := newTestData(t, nil)
+	d.user.Fs = afero.NewBasePathFs(afero.NewOsFs(), scope)
+
+	create := func(link, target string) int {
+		r := httptest.NewRequest(http.MethodPost, link+"?target="+url.QueryEscape(target), nil)
+		status, _ := createSymlink(httptest.NewRecorder(), r, d)
+		return status
+	}
+
+	require.Equal(t, http.StatusNotFound, create("/docs/link", "a.txt"), "disabled by default")
+	d.server.AllowSymlinkCreation = true
+
+	// The cases run in order, the later ones relying on the earlier links.
+	testCases := []struct {
+		name, link, target string
+		status             int
+	}{
+		{"sibling", "/docs/link", "a.txt", 0},
+		{"existing", "/docs/link", "a.txt", http.StatusConflict},
+		{"parent", "/up", "docs/a.txt", 0},
+		{"missing", "/docs/later", "b.txt", http.StatusNotFound},
+		{"absolute", "/docs/abs", "/etc/passwd", http.StatusForbidden},
+		{"escaping", "/docs/esc", "../../secret", http.StatusForbidden},
+		{"escaping from root", "/esc", "../secret", http.StatusForbidden},
+		{"through a link", "/docs/via", "../out/secret", http.StatusForbidden},
+		{"in an escaping dir", "/out/link", "../docs/a.txt", http.StatusForbidden},
+		{"back slashes", "/docs/win", "..\\..\\secret", http.StatusForbidden},
+		{"no target", "/docs/none", "", http.StatusBadRequest},
+		{"staying after a climb", "/docs/climb", "../docs/a.txt", 0},
+	}
+
+	for _, tc := range testCases {
+		require.Equal(t, tc.status, create(tc.link, tc.target), tc.name)
+	}
+
+	target, err := os.Readlink(filepath.Join(scope, "docs", "link"))
+	require.NoError(t, err)
+	require.Equal(t, "a.txt", target)
+
+	for _, link := range []string{"later", "abs", "esc", "via", "win", "none"} {
+		_, err := os.Lstat(filepath.Join(scope, "docs", link))
+		require.True(t, os.IsNotExist(err), link)
+	}
+	_, err = os.Lstat(filepath.Join(dir, "link"))
+	require.True(t, os.IsNotExist(err))
+}
+
+func TestWriteThroughEscapingSymlink(t *testing.T) {
+	dir := t.TempDir()
+	scope := filepath.Join(dir, "scope")
+	require.NoError(t, os.MkdirAll(scope, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "secret"), []byte("secret"), 0644))
+	require.NoError(t, os.Symlink(dir, filepath.Join(scope, "out")))
+	require.NoError(t, os.Symlink(filepath.Join(dir, "secret"), filepath.Join(scope, "secret")))
+
+	old := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "secret"), old, old))
+
+	d := newTestData(t, nil)
+	d.user.Fs = afero.NewBasePathFs(afero.NewOsFs(), scope)
+
+	for _, name := range []string{"/secret", "/out/secret", "/out/new?create=true"} {
+		status, _ := touch(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, name, nil), d)
+		require.Equal(t, http.StatusForbidden, status, name)
+	}
+
+	for _, name := range []string{"/out/new.txt", "/out/sub/new.txt", "/out/sub/"} {
+		r := httptest.NewRequest(http.MethodPost, name, strings.NewReader("new"))
+		status, _ := resourcePostPut(httptest.NewRecorder(), r, d)
+		require.Equal(t, http.StatusForbidden, status, name)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "secret"))
+	require.NoError(t, err)
+	require.True(t, info.ModTime().Equal(old), "the modification time is untouched")
+
+	entries, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "nothing is written out of the scope")
+}
@@ -0,0 +1,99 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"time"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/files"
+)
+
+const touchTimeHeader = "X-Modification-Time"
+
+// touchHandler sets the modification time of a file to the time given in
+// the X-Modification-Time header, or to now. Missing files are only
+// created if the create parameter is set.
+var touchHandler = withUser(touch)
+
+func touch(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if !d.user.Perm.Modify {
+		return http.StatusForbidden, nil
+	}
+
+	fPath := path.Clean("/" + r.URL.Path)
+	if !d.Check(fPath) {
+		return http.StatusForbidden, nil
+	}
+	if err := d.checkWriteSymlinks(fPath); err != nil {
+		return errToStatus(err), err
+	}
+
+	mtime, err := parseTouchTime(r.Header.Get(touchTimeHeader))
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	_, err = d.user.Fs.Stat(fPath)
+	create := os.IsNotExist(err)
+	if create {
+		if r.URL.Query().Get("create") != "true" {
+			return http.StatusNotFound, nil
+		}
+		if !d.user.Perm.Create {
+			return http.StatusForbidden, nil
+		}
+	} else if err != nil {
+		return errToStatus(err), err
+	}
+
+	err = d.RunHook(func() error {
+		if create {
+			file, err := d.user.Fs.OpenFile(fPath, os.O_RDONLY|os.O_CREATE, 0775) //nolint:govet
+			if err != nil {
+				return err
+			}
+			if err = file.Close(); err != nil {
+				return err
+			}
+		}
+
+		return d.user.Fs.Chtimes(fPath, mtime, mtime)
+	}, "touch", fPath, "", d.user)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	file, err := files.NewFileInfo(files.FileOptions{
+		Fs:             d.user.Fs,
+		Path:           fPath,
+		Modify:         d.user.Perm.Modify,
+		Expand:         false,
+		ReadHeader:     d.server.TypeDetectionByHeader,
+		Checker:        d,
+		FollowSymlinks: d.server.FollowSymlinks,
+	})
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return renderJSON(w, r, file)
+}
+
+// parseTouchTime parses a time in RFC 3339 or HTTP date format. An empty
+// value means now.
+func parseTouchTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Now(), nil
+	}
+
+	for _, layout := range []string{time.RFC3339Nano, http.TimeFormat} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid modification time %q: %w", value, errors.ErrInvalidRequestParams)
+}
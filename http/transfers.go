@@ -0,0 +1,71 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/filebrowser/filebrowser/v2/fileutils"
+	"github.com/filebrowser/filebrowser/v2/transfers"
+)
+
+// transfersTTL is how long the finished transfers can still be followed.
+const transfersTTL = time.Hour
+
+// startTransfer starts copying or moving src to dst in the background and
+// returns the transfer, whose progress can then be followed.
+func startTransfer(w http.ResponseWriter, r *http.Request, d *data, reg *transfers.Registry,
+	action, src, dst string, override, rename bool) (int, error) {
+	if err := checkPatchAction(d, action); err != nil {
+		return errToStatus(err), err
+	}
+
+	dst, status, err := preparePatch(d, src, dst, override, rename)
+	if status != 0 {
+		return status, err
+	}
+
+	total, err := fileutils.Size(d.user.Fs, src)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	transfer, err := reg.Start(d.user.ID, action, src, dst, total, func(ctx context.Context, progress func(int64)) error {
+		return runPatch(ctx, d, action, src, dst, progress)
+	})
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusAccepted)
+	return renderJSON(w, r, transfer)
+}
+
+// transfersGetHandler returns the transfer whose ID is the request path,
+// or all the transfers of the user.
+func transfersGetHandler(reg *transfers.Registry) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		id := strings.Trim(r.URL.Path, "/")
+		if id == "" {
+			return renderJSON(w, r, reg.List(d.user.ID))
+		}
+
+		transfer, ok := reg.Get(d.user.ID, id)
+		if !ok {
+			return http.StatusNotFound, nil
+		}
+		return renderJSON(w, r, transfer)
+	})
+}
+
+// transferDeleteHandler cancels the transfer whose ID is the request path.
+func transferDeleteHandler(reg *transfers.Registry) handleFunc {
+	return withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+		if !reg.Cancel(d.user.ID, strings.Trim(r.URL.Path, "/")) {
+			return http.StatusNotFound, nil
+		}
+		return http.StatusOK, nil
+	})
+}
@@ -6,7 +6,9 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path"
 	"strings"
+	"syscall"
 
 	libErrors "github.com/filebrowser/filebrowser/v2/errors"
 )
@@ -42,6 +44,20 @@ func errToStatus(err error) int {
 		return http.StatusBadRequest
 	case errors.Is(err, libErrors.ErrRootUserDeletion):
 		return http.StatusForbidden
+	case errors.Is(err, libErrors.ErrUnsafeArchivePath):
+		return http.StatusBadRequest
+	case errors.Is(err, libErrors.ErrOperationTimeout):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, libErrors.ErrChecksumMismatch):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, libErrors.ErrSpecialFile):
+		return http.StatusBadRequest
+	case errors.Is(err, libErrors.ErrUploadRejected):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, libErrors.ErrNameTooLong), errors.Is(err, syscall.ENAMETOOLONG):
+		return http.StatusBadRequest
+	case errors.Is(err, libErrors.ErrAliasLoop):
+		return http.StatusLoopDetected
 	default:
 		return http.StatusInternalServerError
 	}
@@ -64,3 +80,12 @@ func stripPrefix(prefix string, h http.Handler) http.Handler {
 		h.ServeHTTP(w, r2)
 	})
 }
+
+// pathDepth returns the number of segments of the path once cleaned.
+func pathDepth(p string) int {
+	p = path.Clean("/" + p)
+	if p == "/" {
+		return 0
+	}
+	return strings.Count(p, "/")
+}
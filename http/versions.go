@@ -0,0 +1,110 @@
+package http
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/users"
+	"github.com/filebrowser/filebrowser/v2/versions"
+)
+
+// versionsLayout returns where the versions of the files are kept.
+func (d *data) versionsLayout() versions.Layout {
+	if d.server.VersionsLayout == "" {
+		return versions.DefaultLayout
+	}
+	return versions.Layout(d.server.VersionsLayout)
+}
+
+// originalChecker checks the path of a file instead of the one of its
+// versions, which may be in a hidden directory.
+type originalChecker struct {
+	d    *data
+	path string
+}
+
+func (c originalChecker) Check(string) bool {
+	return c.d.Check(c.path)
+}
+
+var versionsGetHandler = withUser(func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	if !d.Check(r.URL.Path) {
+		return http.StatusForbidden, nil
+	}
+
+	list, err := d.versionsLayout().List(d.user.Fs, r.URL.Path)
+	if err != nil {
+		return errToStatus(err), err
+	}
+
+	return renderJSON(w, r, list)
+})
+
+// versionFileInfo returns the info of the version of the file, named like
+// the file. Versions are always read-only.
+func versionFileInfo(d *data, fPath, version string) (*files.FileInfo, error) {
+	versionPath, err := d.versionsLayout().Path(fPath, version)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := files.NewFileInfo(files.FileOptions{
+		Fs:             d.user.Fs,
+		Path:           versionPath,
+		Modify:         false,
+		Expand:         false,
+		ReadHeader:     d.server.TypeDetectionByHeader,
+		Checker:        originalChecker{d: d, path: fPath},
+		FollowSymlinks: d.server.FollowSymlinks,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if file.IsDir {
+		return nil, errors.ErrIsDirectory
+	}
+
+	file.Name = path.Base(fPath)
+	file.Extension = filepath.Ext(file.Name)
+	return file, nil
+}
+
+// snapshotVersion keeps the content of the file as a version before it is
+// replaced. Failing to do so doesn't fail the write.
+func snapshotVersion(d *data, fPath string) {
+	info, err := d.user.Fs.Stat(fPath)
+	if err != nil || info.IsDir() {
+		return
+	}
+
+	err = d.versionsLayout().Snapshot(d.user.Fs, fPath, time.Now(), d.server.KeepVersions, hardLinker(d))
+	if err != nil {
+		log.Printf("couldn't keep the version of %s: %v", fPath, err)
+	}
+}
+
+// hardLinker returns a function hard linking the files of the user, or
+// nil if they aren't on the local filesystem. The content the version
+// shares with the file isn't changed by the write, as uploads replace
+// files rather than writing over them.
+func hardLinker(d *data) func(oldname, newname string) error {
+	if _, ok := users.RootFs.(*afero.OsFs); !ok {
+		return nil
+	}
+	if _, ok := d.user.Fs.(*afero.BasePathFs); !ok {
+		return nil
+	}
+
+	return func(oldname, newname string) error {
+		return os.Link(d.user.FullPath(oldname), d.user.FullPath(newname))
+	}
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/rules"
+)
+
+func TestVersionFileInfo(t *testing.T) {
+	d := newTestData(t, map[string]string{
+		"/docs/a.txt":                  "current",
+		"/docs/.versions/a.txt/1":      "first",
+		"/docs/secret.txt":             "current",
+		"/docs/.versions/secret.txt/1": "first",
+	})
+	d.user.HideDotfiles = true
+	d.user.Rules = []rules.Rule{{Path: "/docs/secret.txt", Allow: false}}
+
+	file, err := versionFileInfo(d, "/docs/a.txt", "1")
+	require.NoError(t, err)
+	require.Equal(t, "a.txt", file.Name)
+	require.Equal(t, ".txt", file.Extension)
+	require.Equal(t, "/docs/.versions/a.txt/1", file.Path)
+	require.Equal(t, int64(5), file.Size)
+
+	_, err = versionFileInfo(d, "/docs/secret.txt", "1")
+	require.Error(t, err)
+	require.Equal(t, 403, errToStatus(err))
+
+	_, err = versionFileInfo(d, "/docs/a.txt", "2")
+	require.Equal(t, 404, errToStatus(err))
+
+	_, err = versionFileInfo(d, "/docs/a.txt", "../../secret.txt")
+	require.Equal(t, 400, errToStatus(err))
+}
+
+func TestUploadKeepsVersions(t *testing.T) {
+	d := newTestData(t, map[string]string{"/docs/a.txt": "v1"})
+	d.server.KeepVersions = 1
+
+	for _, content := range []string{"v2", "v3"} {
+		r := httptest.NewRequest(http.MethodPut, "/docs/a.txt", strings.NewReader(content))
+		status, err := resourcePostPut(httptest.NewRecorder(), r, d)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, status)
+	}
+
+	list, err := d.versionsLayout().List(d.user.Fs, "/docs/a.txt")
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+
+	file, err := versionFileInfo(d, "/docs/a.txt", list[0].Version)
+	require.NoError(t, err)
+	content, err := afero.ReadFile(d.user.Fs, file.Path)
+	require.NoError(t, err)
+	require.Equal(t, "v2", string(content))
+
+	// The versions aren't listed.
+	listing, err := files.NewFileInfo(files.FileOptions{Fs: d.user.Fs, Path: "/docs", Expand: true, Checker: d})
+	require.NoError(t, err)
+	require.Len(t, listing.Items, 1)
+	require.Equal(t, "a.txt", listing.Items[0].Name)
+}
@@ -0,0 +1,202 @@
+package http
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/spf13/afero"
+	"golang.org/x/net/webdav"
+
+	"github.com/filebrowser/filebrowser/v2/auth"
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/users"
+)
+
+// davLockSystem holds the WebDAV locks, shared by every request.
+var davLockSystem = webdav.NewMemLS()
+
+// davHandler serves the files of the user through WebDAV so they can be
+// mounted as a network drive. With the JSON authentication method, the
+// users authenticate with their username and password using HTTP Basic
+// authentication. Other methods are used as they are.
+var davHandler = func(w http.ResponseWriter, r *http.Request, d *data) (int, error) {
+	user, err := davUser(r, d)
+	if err == os.ErrPermission {
+		w.Header().Set("WWW-Authenticate", `Basic realm="File Browser"`)
+		return http.StatusUnauthorized, nil
+	} else if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	d.user = user
+
+	if (r.Method == http.MethodGet || r.Method == http.MethodHead) && !d.user.Perm.Download {
+		return http.StatusForbidden, nil
+	}
+
+	// The destination of MOVE and COPY requests is an absolute URL, so
+	// the prefix must include the base URL the router strips.
+	r.URL.Path = d.server.BaseURL + r.URL.Path
+
+	handler := &webdav.Handler{
+		Prefix:     d.server.BaseURL + "/dav",
+		FileSystem: davFileSystem{d: d},
+		LockSystem: davLockSystem,
+		Logger: func(r *http.Request, err error) {
+			if err != nil {
+				log.Printf("%s %s: %v", r.Method, r.URL.Path, err)
+			}
+		},
+	}
+	handler.ServeHTTP(w, r)
+	return 0, nil
+}
+
+func davUser(r *http.Request, d *data) (*users.User, error) {
+	if d.settings.AuthMethod != auth.MethodJSONAuth {
+		auther, err := d.store.Auth.Get(d.settings.AuthMethod)
+		if err != nil {
+			return nil, err
+		}
+		return auther.Auth(r, d.store.Users, d.server.Root)
+	}
+
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, os.ErrPermission
+	}
+
+	user, err := d.store.Users.Get(d.server.Root, username)
+	if err != nil || !users.CheckPwd(password, user.Password) {
+		return nil, os.ErrPermission
+	}
+
+	return user, nil
+}
+
+// davFileSystem implements webdav.FileSystem on top of the filesystem of
+// the user, enforcing the same rules and permissions as the API.
+type davFileSystem struct {
+	d *data
+}
+
+func (fs davFileSystem) allowed(name string) bool {
+	if !fs.d.Check(name) {
+		return false
+	}
+	return fs.d.server.FollowSymlinks || files.CheckSymlinks(fs.d.user.Fs, name) == nil
+}
+
+func (fs davFileSystem) Mkdir(_ context.Context, name string, perm os.FileMode) error {
+	name = path.Clean("/" + name)
+	if !fs.d.user.Perm.Create || !fs.allowed(name) {
+		return os.ErrPermission
+	}
+	return fs.d.user.Fs.Mkdir(name, perm)
+}
+
+func (fs davFileSystem) OpenFile(_ context.Context, name string, flag int, perm os.FileMode) (webdav.File, error) {
+	name = path.Clean("/" + name)
+	if !fs.allowed(name) {
+		return nil, os.ErrPermission
+	}
+
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) == 0 {
+		file, err := fs.d.user.Fs.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		return &davFile{File: file, fs: fs, name: name}, nil
+	}
+
+	if !uploadExtensionAllowed(name, fs.d.server.UploadAllowExtensions, fs.d.server.UploadBlockExtensions) {
+		return nil, os.ErrPermission
+	}
+
+	_, err := fs.d.user.Fs.Stat(name)
+	switch {
+	case os.IsNotExist(err) && !fs.d.user.Perm.Create:
+		return nil, os.ErrPermission
+	case err == nil && !fs.d.user.Perm.Modify:
+		return nil, os.ErrPermission
+	}
+
+	unlock := lockPaths(fs.d, name)
+	file, err := fs.d.user.Fs.OpenFile(name, flag, perm)
+	if err != nil {
+		unlock()
+		return nil, err
+	}
+
+	return &davFile{File: file, fs: fs, name: name, unlock: unlock}, nil
+}
+
+func (fs davFileSystem) RemoveAll(_ context.Context, name string) error {
+	name = path.Clean("/" + name)
+	if name == "/" || !fs.d.user.Perm.Delete || !fs.allowed(name) {
+		return os.ErrPermission
+	}
+
+	return fs.d.RunHook(func() error {
+		defer lockPaths(fs.d, name)()
+		return fs.d.user.Fs.RemoveAll(name)
+	}, "delete", name, "", fs.d.user)
+}
+
+func (fs davFileSystem) Rename(_ context.Context, oldName, newName string) error {
+	oldName = path.Clean("/" + oldName)
+	newName = path.Clean("/" + newName)
+	if oldName == "/" || !fs.d.user.Perm.Rename || !fs.allowed(oldName) || !fs.allowed(newName) {
+		return os.ErrPermission
+	}
+
+	return fs.d.RunHook(func() error {
+		defer lockPaths(fs.d, oldName, newName)()
+		if err := fs.d.user.Fs.Rename(oldName, newName); err != nil {
+			return err
+		}
+
+		recordMove(fs.d, oldName, newName)
+		return nil
+	}, "rename", oldName, newName, fs.d.user)
+}
+
+func (fs davFileSystem) Stat(_ context.Context, name string) (os.FileInfo, error) {
+	name = path.Clean("/" + name)
+	if !fs.allowed(name) {
+		return nil, os.ErrPermission
+	}
+	return fs.d.user.Fs.Stat(name)
+}
+
+// davFile is a file opened through WebDAV. Directory entries the user
+// isn't allowed to see are not listed.
+type davFile struct {
+	afero.File
+	fs     davFileSystem
+	name   string
+	unlock func()
+}
+
+func (f *davFile) Readdir(count int) ([]os.FileInfo, error) {
+	infos, err := f.File.Readdir(count)
+
+	allowed := infos[:0]
+	for _, info := range infos {
+		if info.Name() == files.HistoryFile || !f.fs.allowed(path.Join(f.name, info.Name())) {
+			continue
+		}
+		allowed = append(allowed, info)
+	}
+
+	return allowed, err
+}
+
+func (f *davFile) Close() error {
+	if f.unlock != nil {
+		defer f.unlock()
+	}
+	return f.File.Close()
+}
@@ -0,0 +1,189 @@
+// Package iofs adapts an io/fs.FS, such as an embed.FS, to a read-only
+// afero.Fs. Assigning one to users.RootFs makes a binary serve the file
+// tree it ships with.
+package iofs
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Fs is a read-only afero.Fs backed by an fs.FS. Every change fails with
+// os.ErrPermission.
+type Fs struct {
+	fsys fs.FS
+}
+
+// New creates a filesystem from an fs.FS.
+func New(fsys fs.FS) *Fs {
+	return &Fs{fsys: fsys}
+}
+
+// fsName converts an afero path to an fs.FS one, which are unrooted.
+func fsName(name string) string {
+	name = strings.TrimPrefix(path.Clean("/"+filepath.ToSlash(name)), "/")
+	if name == "" {
+		return "."
+	}
+	return name
+}
+
+func readOnly(op, name string) error {
+	return &os.PathError{Op: op, Path: name, Err: os.ErrPermission}
+}
+
+// Name implements afero.Fs.
+func (f *Fs) Name() string {
+	return "IOFs"
+}
+
+// Stat implements afero.Fs.
+func (f *Fs) Stat(name string) (os.FileInfo, error) {
+	return fs.Stat(f.fsys, fsName(name))
+}
+
+// Open implements afero.Fs.
+func (f *Fs) Open(name string) (afero.File, error) {
+	file, err := f.fsys.Open(fsName(name))
+	if err != nil {
+		return nil, err
+	}
+	return &File{File: file, name: name}, nil
+}
+
+// OpenFile implements afero.Fs. Only reading is allowed.
+func (f *Fs) OpenFile(name string, flag int, _ os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_APPEND|os.O_TRUNC) != 0 {
+		return nil, readOnly("open", name)
+	}
+	return f.Open(name)
+}
+
+// Create implements afero.Fs.
+func (f *Fs) Create(name string) (afero.File, error) {
+	return nil, readOnly("create", name)
+}
+
+// Mkdir implements afero.Fs.
+func (f *Fs) Mkdir(name string, _ os.FileMode) error {
+	return readOnly("mkdir", name)
+}
+
+// MkdirAll implements afero.Fs.
+func (f *Fs) MkdirAll(name string, _ os.FileMode) error {
+	return readOnly("mkdir", name)
+}
+
+// Remove implements afero.Fs.
+func (f *Fs) Remove(name string) error {
+	return readOnly("remove", name)
+}
+
+// RemoveAll implements afero.Fs.
+func (f *Fs) RemoveAll(name string) error {
+	return readOnly("remove", name)
+}
+
+// Rename implements afero.Fs.
+func (f *Fs) Rename(oldname, _ string) error {
+	return readOnly("rename", oldname)
+}
+
+// Chmod implements afero.Fs.
+func (f *Fs) Chmod(name string, _ os.FileMode) error {
+	return readOnly("chmod", name)
+}
+
+// Chtimes implements afero.Fs.
+func (f *Fs) Chtimes(name string, _, _ time.Time) error {
+	return readOnly("chtimes", name)
+}
+
+// File is a file of an Fs. Seeking and reading at an offset only work if
+// the underlying file supports them, which embed.FS files do.
+type File struct {
+	fs.File
+	name string
+}
+
+// Name implements afero.File.
+func (f *File) Name() string {
+	return f.name
+}
+
+// Seek implements afero.File.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	if seeker, ok := f.File.(io.Seeker); ok {
+		return seeker.Seek(offset, whence)
+	}
+	return 0, &os.PathError{Op: "seek", Path: f.name, Err: os.ErrInvalid}
+}
+
+// ReadAt implements afero.File.
+func (f *File) ReadAt(p []byte, off int64) (int, error) {
+	if readerAt, ok := f.File.(io.ReaderAt); ok {
+		return readerAt.ReadAt(p, off)
+	}
+	return 0, &os.PathError{Op: "read", Path: f.name, Err: os.ErrInvalid}
+}
+
+// Readdir implements afero.File.
+func (f *File) Readdir(count int) ([]os.FileInfo, error) {
+	dir, ok := f.File.(fs.ReadDirFile)
+	if !ok {
+		return nil, &os.PathError{Op: "readdir", Path: f.name, Err: os.ErrInvalid}
+	}
+
+	entries, err := dir.ReadDir(count)
+	infos := make([]os.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info() //nolint:govet
+		if err != nil {
+			return infos, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, err
+}
+
+// Readdirnames implements afero.File.
+func (f *File) Readdirnames(n int) ([]string, error) {
+	infos, err := f.Readdir(n)
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name()
+	}
+	return names, err
+}
+
+// Write implements afero.File.
+func (f *File) Write([]byte) (int, error) {
+	return 0, readOnly("write", f.name)
+}
+
+// WriteAt implements afero.File.
+func (f *File) WriteAt([]byte, int64) (int, error) {
+	return 0, readOnly("write", f.name)
+}
+
+// WriteString implements afero.File.
+func (f *File) WriteString(string) (int, error) {
+	return 0, readOnly("write", f.name)
+}
+
+// Truncate implements afero.File.
+func (f *File) Truncate(int64) error {
+	return readOnly("truncate", f.name)
+}
+
+// Sync implements afero.File.
+func (f *File) Sync() error {
+	return nil
+}
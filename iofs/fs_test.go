@@ -0,0 +1,79 @@
+package iofs
+
+import (
+	"embed"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+//go:embed testdata
+var testdata embed.FS
+
+func newTestFs() afero.Fs {
+	// Users get the root filesystem scoped, see users.User.Clean.
+	return afero.NewBasePathFs(New(testdata), "/testdata")
+}
+
+func TestListing(t *testing.T) {
+	file, err := files.NewFileInfo(files.FileOptions{
+		Fs:      newTestFs(),
+		Path:    "/",
+		Expand:  true,
+		Checker: testutil.AllowAll{},
+	})
+	require.NoError(t, err)
+	require.True(t, file.IsDir)
+	require.Equal(t, 1, file.NumDirs)
+	require.Equal(t, 1, file.NumFiles)
+
+	names := []string{}
+	for _, item := range file.Items {
+		names = append(names, item.Name)
+	}
+	require.ElementsMatch(t, []string{"docs", "README.txt"}, names)
+}
+
+func TestOpen(t *testing.T) {
+	file, err := files.NewFileInfo(files.FileOptions{
+		Fs:      newTestFs(),
+		Path:    "/docs/guide.md",
+		Expand:  true,
+		Checker: testutil.AllowAll{},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "# Guide\n\nHello.\n", file.Content)
+
+	// Files are served with http.ServeContent, which seeks.
+	f, err := newTestFs().Open("/docs/guide.md")
+	require.NoError(t, err)
+	defer f.Close()
+
+	_, err = f.Seek(2, 0)
+	require.NoError(t, err)
+	content, err := ioutil.ReadAll(f)
+	require.NoError(t, err)
+	require.Equal(t, "Guide\n\nHello.\n", string(content))
+}
+
+func TestReadOnly(t *testing.T) {
+	fs := newTestFs()
+
+	_, err := fs.OpenFile("/new.txt", os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	require.True(t, os.IsPermission(err))
+	require.True(t, os.IsPermission(fs.MkdirAll("/dir", 0755)))
+	require.True(t, os.IsPermission(fs.RemoveAll("/docs")))
+	require.True(t, os.IsPermission(fs.Rename("/README.txt", "/readme.txt")))
+
+	f, err := fs.Open("/README.txt")
+	require.NoError(t, err)
+	defer f.Close()
+	_, err = f.Write([]byte("changed"))
+	require.True(t, os.IsPermission(err))
+}
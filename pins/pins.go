@@ -0,0 +1,129 @@
+// Package pins stores the paths users pinned for quick access.
+package pins
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+)
+
+// Storage stores the pinned paths of each user in a JSON file.
+type Storage struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewStorage creates a pins storage backed by the JSON file at path.
+func NewStorage(path string) *Storage {
+	return &Storage{path: path}
+}
+
+// Get gets the paths pinned by the user, in the order they were pinned.
+func (s *Storage) Get(userID uint) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pins, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+
+	if pins[userID] == nil {
+		return []string{}, nil
+	}
+	return pins[userID], nil
+}
+
+// Add pins a path for the user. Pinning a path twice does nothing.
+func (s *Storage) Add(userID uint, p string) error {
+	p = path.Clean("/" + p)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pins, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	for _, pin := range pins[userID] {
+		if pin == p {
+			return nil
+		}
+	}
+
+	pins[userID] = append(pins[userID], p)
+	return s.save(pins)
+}
+
+// Remove unpins a path of the user. It returns errors.ErrNotExist if the
+// path isn't pinned.
+func (s *Storage) Remove(userID uint, p string) error {
+	p = path.Clean("/" + p)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pins, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	for i, pin := range pins[userID] {
+		if pin == p {
+			pins[userID] = append(pins[userID][:i], pins[userID][i+1:]...)
+			return s.save(pins)
+		}
+	}
+
+	return errors.ErrNotExist
+}
+
+func (s *Storage) load() (map[uint][]string, error) {
+	pins := map[uint][]string{}
+
+	content, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return pins, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(content, &pins); err != nil {
+		return nil, err
+	}
+	return pins, nil
+}
+
+// save writes the pins to a temporary file first so a failed write
+// doesn't lose the existing ones.
+func (s *Storage) save(pins map[uint][]string) error {
+	content, err := json.Marshal(pins)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path))
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,241 @@
+// Package remotefetch downloads files from remote HTTP servers on behalf
+// of users, refusing the private and internal addresses so the server
+// can't be used to reach its own network.
+package remotefetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	fbErrors "github.com/filebrowser/filebrowser/v2/errors"
+)
+
+var (
+	// ErrBlockedAddress is returned when the URL leads to a private or
+	// internal address.
+	ErrBlockedAddress = errors.New("the URL leads to a private or internal address")
+	// ErrTooLarge is returned when the remote file is larger than the
+	// maximum size.
+	ErrTooLarge = errors.New("the remote file is too large")
+	// ErrFailed wraps the errors of the remote servers and of the
+	// connections to them.
+	ErrFailed = errors.New("fetching the remote file failed")
+)
+
+// maxRedirects is the number of redirects followed before giving up.
+const maxRedirects = 10
+
+// blockedNetworks are the networks which aren't reachable from the
+// internet, or not meant to be: loopback, private, link-local, shared,
+// benchmarking, multicast and reserved ranges.
+var blockedNetworks = parseNetworks(
+	"0.0.0.0/8",
+	"10.0.0.0/8",
+	"100.64.0.0/10",
+	"127.0.0.0/8",
+	"169.254.0.0/16",
+	"172.16.0.0/12",
+	"192.0.0.0/24",
+	"192.0.2.0/24",
+	"192.168.0.0/16",
+	"198.18.0.0/15",
+	"198.51.100.0/24",
+	"203.0.113.0/24",
+	"224.0.0.0/4",
+	"240.0.0.0/4",
+	"::/128",
+	"::1/128",
+	"64:ff9b::/96",
+	"100::/64",
+	"2001:db8::/32",
+	"fc00::/7",
+	"fe80::/10",
+	"ff00::/8",
+)
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// IsPublic tells if the address is reachable from the internet.
+func IsPublic(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseURL parses a URL to fetch, which must be an http or https one with
+// a host. It returns errors.ErrInvalidRequestParams otherwise.
+func ParseURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", raw, fbErrors.ErrInvalidRequestParams)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid URL %q, only http and https ones can be fetched: %w", raw, fbErrors.ErrInvalidRequestParams)
+	}
+	return u, nil
+}
+
+// Fetcher fetches remote files.
+type Fetcher struct {
+	client  *http.Client
+	maxSize int64
+}
+
+// New creates a fetcher giving up on the fetches after the timeout, and
+// refusing the files larger than maxSize bytes, unless it's 0.
+func New(timeout time.Duration, maxSize int64) *Fetcher {
+	return newFetcher(timeout, maxSize, IsPublic)
+}
+
+// newFetcher creates a fetcher only connecting to the addresses allowed.
+// The addresses are checked once resolved, right before connecting, so a
+// host can't resolve to another address than the checked one.
+func newFetcher(timeout time.Duration, maxSize int64, allowed func(net.IP) bool) *Fetcher {
+	dialer := &net.Dialer{
+		Timeout: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !allowed(ip) {
+				return fmt.Errorf("%s: %w", host, ErrBlockedAddress)
+			}
+			return nil
+		},
+	}
+
+	return &Fetcher{
+		client: &http.Client{
+			Timeout: timeout,
+			// No proxy, which would connect on our behalf unchecked.
+			Transport: &http.Transport{
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= maxRedirects {
+					return fmt.Errorf("stopped after %d redirects", maxRedirects)
+				}
+				_, err := ParseURL(req.URL.String())
+				return err
+			},
+		},
+		maxSize: maxSize,
+	}
+}
+
+// Response is the body of a remote file being fetched.
+type Response struct {
+	io.ReadCloser
+	// Name is the name of the file, from its Content-Disposition header
+	// or else its URL.
+	Name string
+}
+
+// Fetch starts fetching the file at u. Reading the body returns
+// ErrTooLarge once past the maximum size.
+func (f *Fetcher) Fetch(ctx context.Context, u *url.URL) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrBlockedAddress) {
+			return nil, ErrBlockedAddress
+		}
+		return nil, fmt.Errorf("%w: %v", ErrFailed, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s: %s", ErrFailed, u.Redacted(), resp.Status)
+	}
+
+	if f.maxSize > 0 && resp.ContentLength > f.maxSize {
+		resp.Body.Close()
+		return nil, ErrTooLarge
+	}
+
+	return &Response{
+		ReadCloser: &body{ReadCloser: resp.Body, maxSize: f.maxSize},
+		Name:       fileName(resp),
+	}, nil
+}
+
+// fileName returns the name of the file of the response, falling back to
+// "download" if neither the headers nor the final URL tell a usable one.
+func fileName(resp *http.Response) string {
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		if name := cleanName(params["filename"]); name != "" {
+			return name
+		}
+	}
+
+	if name := cleanName(path.Base(resp.Request.URL.Path)); name != "" {
+		return name
+	}
+	return "download"
+}
+
+func cleanName(name string) string {
+	name = path.Base(strings.Replace(name, "\\", "/", -1))
+	if name == "." || name == ".." || name == "/" {
+		return ""
+	}
+	return strings.TrimSpace(name)
+}
+
+// body wraps the errors of reading the response in ErrFailed, and fails
+// with ErrTooLarge once more than maxSize bytes are read, unless it's 0,
+// where io.LimitReader would end early silently.
+type body struct {
+	io.ReadCloser
+	maxSize int64
+	read    int64
+}
+
+func (b *body) Read(p []byte) (int, error) {
+	if b.maxSize > 0 && int64(len(p)) > b.maxSize-b.read+1 {
+		// One more byte tells if the file is larger.
+		p = p[:b.maxSize-b.read+1]
+	}
+
+	n, err := b.ReadCloser.Read(p)
+	b.read += int64(n)
+	if b.maxSize > 0 && b.read > b.maxSize {
+		return n, ErrTooLarge
+	}
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("%w: %v", ErrFailed, err)
+	}
+	return n, err
+}
@@ -0,0 +1,126 @@
+package remotefetch
+
+import (
+	"context"
+	stderrors "errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+)
+
+func TestIsPublic(t *testing.T) {
+	for ip, want := range map[string]bool{
+		"1.1.1.1":          true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.20.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"::ffff:127.0.0.1": false,
+		"fd00::1":          false,
+		"fe80::1":          false,
+	} {
+		require.Equal(t, want, IsPublic(net.ParseIP(ip)), ip)
+	}
+}
+
+func TestParseURL(t *testing.T) {
+	for _, raw := range []string{"https://example.com/a.zip", "http://example.com:8080/"} {
+		_, err := ParseURL(raw)
+		require.NoError(t, err, raw)
+	}
+
+	for _, raw := range []string{"", "file:///etc/passwd", "ftp://example.com/a", "http:///a", "gopher://example.com"} {
+		_, err := ParseURL(raw)
+		require.True(t, stderrors.Is(err, errors.ErrInvalidRequestParams), raw)
+	}
+}
+
+func TestFetchBlocksPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the private address was reached")
+	}))
+	defer server.Close()
+
+	u, err := ParseURL(server.URL + "/secret")
+	require.NoError(t, err)
+
+	_, err = New(time.Second, 0).Fetch(context.Background(), u)
+	require.True(t, stderrors.Is(err, ErrBlockedAddress), err)
+}
+
+func TestFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/files/report.pdf", http.StatusFound)
+		case "/attachment":
+			w.Header().Set("Content-Disposition", `attachment; filename="../../notes.txt"`)
+			_, _ = w.Write([]byte("notes"))
+		case "/stream":
+			// Without a length, the size is only known while reading.
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte("0123456789"))
+		case "/missing":
+			http.NotFound(w, r)
+		default:
+			_, _ = w.Write([]byte("content"))
+		}
+	}))
+	defer server.Close()
+
+	fetch := func(maxSize int64, p string) (*Response, error) {
+		u, err := ParseURL(server.URL + p)
+		require.NoError(t, err)
+		allowAll := func(net.IP) bool { return true }
+		return newFetcher(time.Second, maxSize, allowAll).Fetch(context.Background(), u)
+	}
+
+	resp, err := fetch(0, "/redirect")
+	require.NoError(t, err)
+	require.Equal(t, "report.pdf", resp.Name)
+	content, err := ioutil.ReadAll(resp)
+	require.NoError(t, err)
+	require.Equal(t, "content", string(content))
+	resp.Close()
+
+	resp, err = fetch(0, "/attachment")
+	require.NoError(t, err)
+	require.Equal(t, "notes.txt", resp.Name)
+	resp.Close()
+
+	resp, err = fetch(0, "/")
+	require.NoError(t, err)
+	require.Equal(t, "download", resp.Name)
+	resp.Close()
+
+	_, err = fetch(0, "/missing")
+	require.True(t, stderrors.Is(err, ErrFailed), err)
+
+	_, err = fetch(3, "/")
+	require.True(t, stderrors.Is(err, ErrTooLarge), err)
+
+	resp, err = fetch(10, "/stream")
+	require.NoError(t, err)
+	content, err = ioutil.ReadAll(resp)
+	require.NoError(t, err)
+	require.Equal(t, "0123456789", string(content))
+	resp.Close()
+
+	resp, err = fetch(9, "/stream")
+	require.NoError(t, err)
+	_, err = ioutil.ReadAll(resp)
+	require.True(t, stderrors.Is(err, ErrTooLarge), err)
+	resp.Close()
+}
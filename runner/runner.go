@@ -52,6 +52,13 @@ func (r *Runner) RunHook(fn func() error, evt, path, dst string, user *users.Use
 	return nil
 }
 
+// Exec runs the command on the file as the hooks of the event are run,
+// regardless of whether running commands is enabled. Commands ending with
+// "&" don't block.
+func (r *Runner) Exec(command, evt, path string, user *users.User) error {
+	return r.exec(command, evt, user.FullPath(path), "", user)
+}
+
 func (r *Runner) exec(raw, evt, path, dst string, user *users.User) error {
 	blocking := true
 
@@ -0,0 +1,251 @@
+package s3fs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"syscall"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+)
+
+// readOnlyFile implements the writing methods of afero.File for the
+// files opened for reading.
+type readOnlyFile struct {
+	name string
+}
+
+func (f *readOnlyFile) Name() string { return f.name }
+
+func (f *readOnlyFile) Write([]byte) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: os.ErrPermission}
+}
+
+func (f *readOnlyFile) WriteAt([]byte, int64) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: os.ErrPermission}
+}
+
+func (f *readOnlyFile) WriteString(string) (int, error) {
+	return 0, &os.PathError{Op: "write", Path: f.name, Err: os.ErrPermission}
+}
+
+func (f *readOnlyFile) Truncate(int64) error {
+	return &os.PathError{Op: "truncate", Path: f.name, Err: os.ErrPermission}
+}
+
+func (f *readOnlyFile) Sync() error { return nil }
+
+// dirFile is a directory opened for reading.
+type dirFile struct {
+	readOnlyFile
+	fs      *Fs
+	info    os.FileInfo
+	entries []os.FileInfo
+	loaded  bool
+}
+
+func (f *dirFile) Close() error                   { return nil }
+func (f *dirFile) Stat() (os.FileInfo, error)     { return f.info, nil }
+func (f *dirFile) Seek(int64, int) (int64, error) { return 0, nil }
+
+func (f *dirFile) Read([]byte) (int, error) {
+	return 0, &os.PathError{Op: "read", Path: f.name, Err: syscall.EISDIR}
+}
+
+func (f *dirFile) ReadAt([]byte, int64) (int, error) {
+	return 0, &os.PathError{Op: "read", Path: f.name, Err: syscall.EISDIR}
+}
+
+func (f *dirFile) Readdir(count int) ([]os.FileInfo, error) {
+	if !f.loaded {
+		entries, err := f.fs.readDir(f.name)
+		if err != nil {
+			return nil, err
+		}
+		f.entries, f.loaded = entries, true
+	}
+
+	if count <= 0 {
+		entries := f.entries
+		f.entries = nil
+		return entries, nil
+	}
+
+	if len(f.entries) == 0 {
+		return nil, io.EOF
+	}
+
+	if count > len(f.entries) {
+		count = len(f.entries)
+	}
+	entries := f.entries[:count]
+	f.entries = f.entries[count:]
+	return entries, nil
+}
+
+func (f *dirFile) Readdirnames(n int) ([]string, error) {
+	infos, err := f.Readdir(n)
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name()
+	}
+	return names, err
+}
+
+// objectFile is an object opened for reading. The object is downloaded
+// as it is read, seeking starts a new download at the wanted offset.
+type objectFile struct {
+	readOnlyFile
+	fs   *Fs
+	info os.FileInfo
+	body io.ReadCloser
+	pos  int64
+	rpos int64
+}
+
+func (f *objectFile) Stat() (os.FileInfo, error) { return f.info, nil }
+
+func (f *objectFile) Readdir(int) ([]os.FileInfo, error) {
+	return nil, &os.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+}
+
+func (f *objectFile) Readdirnames(int) ([]string, error) {
+	return nil, &os.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+}
+
+func (f *objectFile) Close() error {
+	if f.body != nil {
+		return f.body.Close()
+	}
+	return nil
+}
+
+func (f *objectFile) get(start, end int64) (io.ReadCloser, error) {
+	obj, err := f.fs.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(f.fs.bucket),
+		Key:    aws.String(f.fs.key(f.name)),
+		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+	})
+	if err != nil {
+		return nil, pathError("read", f.name, err)
+	}
+	return obj.Body, nil
+}
+
+func (f *objectFile) Read(p []byte) (int, error) {
+	if f.pos >= f.info.Size() {
+		return 0, io.EOF
+	}
+
+	if f.body == nil || f.rpos != f.pos {
+		if f.body != nil {
+			_ = f.body.Close()
+		}
+
+		body, err := f.get(f.pos, f.info.Size()-1)
+		if err != nil {
+			f.body = nil
+			return 0, err
+		}
+		f.body, f.rpos = body, f.pos
+	}
+
+	n, err := f.body.Read(p)
+	f.pos += int64(n)
+	f.rpos += int64(n)
+	return n, err
+}
+
+func (f *objectFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= f.info.Size() {
+		return 0, io.EOF
+	}
+
+	end := off + int64(len(p)) - 1
+	if end >= f.info.Size() {
+		end = f.info.Size() - 1
+	}
+
+	body, err := f.get(off, end)
+	if err != nil {
+		return 0, err
+	}
+	defer body.Close()
+
+	n, err := io.ReadFull(body, p[:end-off+1])
+	if err == nil && n < len(p) {
+		err = io.EOF
+	}
+	return n, err
+}
+
+func (f *objectFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		offset += f.info.Size()
+	default:
+		return 0, os.ErrInvalid
+	}
+
+	if offset < 0 {
+		return 0, os.ErrInvalid
+	}
+
+	f.pos = offset
+	return offset, nil
+}
+
+// writeFile is a file opened for writing. It is written to a temporary
+// file which is uploaded when closed.
+type writeFile struct {
+	*os.File
+	fs   *Fs
+	name string
+}
+
+func (f *writeFile) Name() string { return f.name }
+
+func (f *writeFile) Stat() (os.FileInfo, error) {
+	info, err := f.File.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return &fileInfo{name: path.Base(f.fs.key(f.name)), size: info.Size(), modTime: info.ModTime()}, nil
+}
+
+func (f *writeFile) Readdir(int) ([]os.FileInfo, error) {
+	return nil, &os.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+}
+
+func (f *writeFile) Readdirnames(int) ([]string, error) {
+	return nil, &os.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+}
+
+func (f *writeFile) Close() error {
+	defer f.discard()
+	defer f.fs.invalidate()
+
+	if _, err := f.File.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	_, err := f.fs.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(f.fs.bucket),
+		Key:    aws.String(f.fs.key(f.name)),
+		Body:   f.File,
+	})
+	if err != nil {
+		return pathError("write", f.name, err)
+	}
+	return nil
+}
+
+func (f *writeFile) discard() {
+	_ = f.File.Close()
+	_ = os.Remove(f.File.Name())
+}
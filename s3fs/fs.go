@@ -0,0 +1,514 @@
+// Package s3fs implements an afero.Fs storing the files as the objects
+// of an S3 bucket. Directories are key prefixes: they exist as long as
+// an object is stored under them, and Mkdir stores an empty "dir/"
+// marker object so empty directories can exist too.
+package s3fs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/spf13/afero"
+)
+
+// Scheme is the scheme of the server roots stored in S3, which look
+// like "s3://bucket/prefix".
+const Scheme = "s3://"
+
+// ErrUnsupported is returned by the operations S3 can't do.
+var ErrUnsupported = errors.New("operation not supported by S3")
+
+// Fs is an afero.Fs backed by an S3 bucket.
+type Fs struct {
+	client s3iface.S3API
+	bucket string
+	prefix string
+
+	// Listing a prefix is slow and rate limited, so listings are
+	// cached for ttl. Every change clears the cache.
+	ttl      time.Duration
+	mu       sync.Mutex
+	listings map[string]cachedListing
+}
+
+type cachedListing struct {
+	infos   []os.FileInfo
+	expires time.Time
+}
+
+// New creates a filesystem storing the files in the bucket, under the
+// given key prefix.
+func New(client s3iface.S3API, bucket, prefix string, ttl time.Duration) *Fs {
+	return &Fs{
+		client:   client,
+		bucket:   bucket,
+		prefix:   strings.Trim(prefix, "/"),
+		ttl:      ttl,
+		listings: map[string]cachedListing{},
+	}
+}
+
+// NewFromURL creates a filesystem from an URL like "s3://bucket/prefix".
+// The credentials and region are read from the environment, as the AWS
+// tools do. The endpoint may be set to use S3 compatible services.
+func NewFromURL(root, endpoint string, ttl time.Duration) (*Fs, error) {
+	u, err := url.Parse(root)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme+"://" != Scheme || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 root %q", root)
+	}
+
+	config := aws.Config{}
+	if endpoint != "" {
+		config.Endpoint = aws.String(endpoint)
+		config.S3ForcePathStyle = aws.Bool(true)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return New(s3.New(sess), u.Host, u.Path, ttl), nil
+}
+
+func (fs *Fs) key(name string) string {
+	name = path.Clean("/" + filepath.ToSlash(name))
+	return strings.TrimPrefix(path.Join("/", fs.prefix, name), "/")
+}
+
+// dirKey returns the prefix of the keys stored in the directory.
+func (fs *Fs) dirKey(name string) string {
+	key := fs.key(name)
+	if key == "" {
+		return ""
+	}
+	return key + "/"
+}
+
+func (fs *Fs) isRoot(name string) bool {
+	return path.Clean("/"+filepath.ToSlash(name)) == "/"
+}
+
+func isNotFound(err error) bool {
+	if reqErr, ok := err.(awserr.RequestFailure); ok && reqErr.StatusCode() == 404 {
+		return true
+	}
+	if awsErr, ok := err.(awserr.Error); ok {
+		return awsErr.Code() == s3.ErrCodeNoSuchKey || awsErr.Code() == "NotFound"
+	}
+	return false
+}
+
+func pathError(op, name string, err error) error {
+	if isNotFound(err) {
+		err = os.ErrNotExist
+	}
+	return &os.PathError{Op: op, Path: name, Err: err}
+}
+
+func (fs *Fs) invalidate() {
+	fs.mu.Lock()
+	fs.listings = map[string]cachedListing{}
+	fs.mu.Unlock()
+}
+
+// InvalidateCache implements files.CacheInvalidator, dropping the cached
+// listings of the file, if it's a directory, and of its directory.
+func (fs *Fs) InvalidateCache(name string) {
+	fs.mu.Lock()
+	delete(fs.listings, fs.dirKey(name))
+	delete(fs.listings, fs.dirKey(path.Dir(path.Clean("/"+filepath.ToSlash(name)))))
+	fs.mu.Unlock()
+}
+
+// Name implements afero.Fs.
+func (fs *Fs) Name() string {
+	return "S3Fs"
+}
+
+// Stat implements afero.Fs.
+func (fs *Fs) Stat(name string) (os.FileInfo, error) {
+	if fs.isRoot(name) {
+		return &fileInfo{name: "/", dir: true}, nil
+	}
+
+	head, err := fs.client.HeadObject(&s3.HeadObjectInput{
+		Bucket: aws.String(fs.bucket),
+		Key:    aws.String(fs.key(name)),
+	})
+	if err == nil {
+		return &fileInfo{
+			name:    path.Base(fs.key(name)),
+			size:    aws.Int64Value(head.ContentLength),
+			modTime: aws.TimeValue(head.LastModified),
+		}, nil
+	}
+	if !isNotFound(err) {
+		return nil, pathError("stat", name, err)
+	}
+
+	list, err := fs.client.ListObjectsV2(&s3.ListObjectsV2Input{
+		Bucket:  aws.String(fs.bucket),
+		Prefix:  aws.String(fs.dirKey(name)),
+		MaxKeys: aws.Int64(1),
+	})
+	if err != nil {
+		return nil, pathError("stat", name, err)
+	}
+	if aws.Int64Value(list.KeyCount) == 0 {
+		return nil, pathError("stat", name, os.ErrNotExist)
+	}
+
+	return &fileInfo{name: path.Base(fs.key(name)), dir: true}, nil
+}
+
+func (fs *Fs) readDir(name string) ([]os.FileInfo, error) {
+	prefix := fs.dirKey(name)
+
+	fs.mu.Lock()
+	cached, ok := fs.listings[prefix]
+	fs.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return append([]os.FileInfo{}, cached.infos...), nil
+	}
+
+	var infos []os.FileInfo
+	err := fs.client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket:    aws.String(fs.bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, p := range page.CommonPrefixes {
+			dirName := strings.TrimSuffix(strings.TrimPrefix(aws.StringValue(p.Prefix), prefix), "/")
+			infos = append(infos, &fileInfo{name: dirName, dir: true})
+		}
+		for _, obj := range page.Contents {
+			key := aws.StringValue(obj.Key)
+			if key == prefix {
+				// The marker of the directory itself.
+				continue
+			}
+			infos = append(infos, &fileInfo{
+				name:    strings.TrimPrefix(key, prefix),
+				size:    aws.Int64Value(obj.Size),
+				modTime: aws.TimeValue(obj.LastModified),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, pathError("readdir", name, err)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name() < infos[j].Name()
+	})
+
+	fs.mu.Lock()
+	fs.listings[prefix] = cachedListing{infos: infos, expires: time.Now().Add(fs.ttl)}
+	fs.mu.Unlock()
+
+	return append([]os.FileInfo{}, infos...), nil
+}
+
+// Open implements afero.Fs.
+func (fs *Fs) Open(name string) (afero.File, error) {
+	return fs.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// OpenFile implements afero.Fs. Files opened for writing are buffered in
+// a temporary file and uploaded when they are closed.
+func (fs *Fs) OpenFile(name string, flag int, _ os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_TRUNC|os.O_APPEND) != 0 {
+		return fs.openWrite(name, flag)
+	}
+
+	info, err := fs.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return &dirFile{readOnlyFile: readOnlyFile{name: name}, fs: fs, info: info}, nil
+	}
+
+	return &objectFile{readOnlyFile: readOnlyFile{name: name}, fs: fs, info: info}, nil
+}
+
+func (fs *Fs) openWrite(name string, flag int) (afero.File, error) {
+	if fs.isRoot(name) {
+		return nil, pathError("open", name, syscall.EISDIR)
+	}
+
+	tmp, err := ioutil.TempFile("", "s3fs")
+	if err != nil {
+		return nil, err
+	}
+	file := &writeFile{File: tmp, fs: fs, name: name}
+
+	if flag&os.O_TRUNC == 0 {
+		err = fs.download(name, tmp)
+		switch {
+		case isNotFound(err) && flag&os.O_CREATE != 0:
+		case err != nil:
+			file.discard()
+			return nil, pathError("open", name, err)
+		case flag&os.O_APPEND == 0:
+			_, err = tmp.Seek(0, io.SeekStart)
+		}
+		if err != nil {
+			file.discard()
+			return nil, err
+		}
+	}
+
+	return file, nil
+}
+
+func (fs *Fs) download(name string, w io.Writer) error {
+	obj, err := fs.client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(fs.bucket),
+		Key:    aws.String(fs.key(name)),
+	})
+	if err != nil {
+		return err
+	}
+	defer obj.Body.Close()
+
+	_, err = io.Copy(w, obj.Body)
+	return err
+}
+
+// Create implements afero.Fs.
+func (fs *Fs) Create(name string) (afero.File, error) {
+	return fs.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0666)
+}
+
+// Mkdir implements afero.Fs.
+func (fs *Fs) Mkdir(name string, _ os.FileMode) error {
+	if _, err := fs.Stat(name); err == nil {
+		return pathError("mkdir", name, os.ErrExist)
+	}
+	return fs.putMarker(name)
+}
+
+// MkdirAll implements afero.Fs. Parent directories exist implicitly.
+func (fs *Fs) MkdirAll(name string, _ os.FileMode) error {
+	info, err := fs.Stat(name)
+	if err == nil {
+		if !info.IsDir() {
+			return pathError("mkdir", name, syscall.ENOTDIR)
+		}
+		return nil
+	}
+	return fs.putMarker(name)
+}
+
+func (fs *Fs) putMarker(name string) error {
+	if fs.isRoot(name) {
+		return nil
+	}
+
+	defer fs.invalidate()
+	_, err := fs.client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(fs.bucket),
+		Key:    aws.String(fs.dirKey(name)),
+		Body:   strings.NewReader(""),
+	})
+	if err != nil {
+		return pathError("mkdir", name, err)
+	}
+	return nil
+}
+
+// Remove implements afero.Fs.
+func (fs *Fs) Remove(name string) error {
+	info, err := fs.Stat(name)
+	if err != nil {
+		return err
+	}
+
+	key := fs.key(name)
+	if info.IsDir() {
+		list, err := fs.client.ListObjectsV2(&s3.ListObjectsV2Input{
+			Bucket:  aws.String(fs.bucket),
+			Prefix:  aws.String(fs.dirKey(name)),
+			MaxKeys: aws.Int64(2),
+		})
+		if err != nil {
+			return pathError("remove", name, err)
+		}
+		for _, obj := range list.Contents {
+			if aws.StringValue(obj.Key) != fs.dirKey(name) {
+				return pathError("remove", name, syscall.ENOTEMPTY)
+			}
+		}
+		key = fs.dirKey(name)
+	}
+
+	defer fs.invalidate()
+	_, err = fs.client.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(fs.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return pathError("remove", name, err)
+	}
+	return nil
+}
+
+// RemoveAll implements afero.Fs.
+func (fs *Fs) RemoveAll(name string) error {
+	defer fs.invalidate()
+
+	keys, err := fs.keys(name)
+	if err != nil {
+		return pathError("remove", name, err)
+	}
+
+	// DeleteObjects accepts up to 1000 keys at once.
+	for len(keys) > 0 {
+		n := len(keys)
+		if n > 1000 {
+			n = 1000
+		}
+
+		objects := make([]*s3.ObjectIdentifier, n)
+		for i, key := range keys[:n] {
+			objects[i] = &s3.ObjectIdentifier{Key: aws.String(key)}
+		}
+
+		_, err := fs.client.DeleteObjects(&s3.DeleteObjectsInput{
+			Bucket: aws.String(fs.bucket),
+			Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			return pathError("remove", name, err)
+		}
+		keys = keys[n:]
+	}
+
+	return nil
+}
+
+// keys returns the key of the file and the keys stored under it, if it
+// is a directory.
+func (fs *Fs) keys(name string) ([]string, error) {
+	var keys []string
+	if !fs.isRoot(name) {
+		if _, err := fs.client.HeadObject(&s3.HeadObjectInput{
+			Bucket: aws.String(fs.bucket),
+			Key:    aws.String(fs.key(name)),
+		}); err == nil {
+			keys = append(keys, fs.key(name))
+		} else if !isNotFound(err) {
+			return nil, err
+		}
+	}
+
+	err := fs.client.ListObjectsV2Pages(&s3.ListObjectsV2Input{
+		Bucket: aws.String(fs.bucket),
+		Prefix: aws.String(fs.dirKey(name)),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, obj := range page.Contents {
+			keys = append(keys, aws.StringValue(obj.Key))
+		}
+		return true
+	})
+	return keys, err
+}
+
+// Rename implements afero.Fs. S3 can't rename objects, so they are
+// copied and then deleted.
+func (fs *Fs) Rename(oldname, newname string) error {
+	if fs.isRoot(oldname) || fs.isRoot(newname) {
+		return pathError("rename", oldname, os.ErrPermission)
+	}
+
+	defer fs.invalidate()
+
+	keys, err := fs.keys(oldname)
+	if err != nil {
+		return pathError("rename", oldname, err)
+	}
+	if len(keys) == 0 {
+		return pathError("rename", oldname, os.ErrNotExist)
+	}
+
+	oldKey, newKey := fs.key(oldname), fs.key(newname)
+	for _, key := range keys {
+		_, err := fs.client.CopyObject(&s3.CopyObjectInput{
+			Bucket:     aws.String(fs.bucket),
+			CopySource: aws.String(url.PathEscape(fs.bucket) + "/" + escapeKey(key)),
+			Key:        aws.String(newKey + strings.TrimPrefix(key, oldKey)),
+		})
+		if err != nil {
+			return pathError("rename", oldname, err)
+		}
+	}
+
+	return fs.RemoveAll(oldname)
+}
+
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// Chmod implements afero.Fs. S3 objects have no modes, so it does
+// nothing.
+func (fs *Fs) Chmod(name string, _ os.FileMode) error {
+	_, err := fs.Stat(name)
+	return err
+}
+
+// Chtimes implements afero.Fs. The modification time of an object is
+// the time it was uploaded, so it can't be changed.
+func (fs *Fs) Chtimes(name string, _, _ time.Time) error {
+	return pathError("chtimes", name, ErrUnsupported)
+}
+
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (fi *fileInfo) Name() string       { return fi.name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi *fileInfo) IsDir() bool        { return fi.dir }
+func (fi *fileInfo) Sys() interface{}   { return nil }
+
+func (fi *fileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
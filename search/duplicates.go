@@ -0,0 +1,133 @@
+package search
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/rules"
+)
+
+// DuplicateGroup is a group of files with identical content.
+type DuplicateGroup struct {
+	Size  int64    `json:"size"`
+	Paths []string `json:"paths"`
+}
+
+// Duplicates walks scope looking for files with identical content. Files
+// are first grouped by size and only the ones sharing a size are hashed.
+// Directories deeper than maxDepth levels below scope are not walked,
+// unless maxDepth is negative. Empty files, symbolic links and the paths
+// rejected by the checker are ignored. The groups are sorted by size,
+// the largest first, and the walk stops as soon as ctx is done.
+func Duplicates(ctx context.Context, fs afero.Fs, scope string, maxDepth int, checker rules.Checker) ([]DuplicateGroup, error) {
+	scope = path.Join("/", filepath.ToSlash(filepath.Clean(scope)))
+	bySize := map[int64][]string{}
+
+	err := afero.Walk(fs, scope, func(fPath string, f os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if err := ctx.Err(); err != nil { //nolint:shadow
+			return err
+		}
+
+		fPath = path.Join("/", filepath.ToSlash(filepath.Clean(fPath)))
+		if fPath == scope {
+			return nil
+		}
+
+		if !checker.Check(fPath) {
+			if f.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if f.IsDir() {
+			depth := strings.Count(strings.Trim(strings.TrimPrefix(fPath, scope), "/"), "/") + 1
+			if maxDepth >= 0 && depth > maxDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if f.Mode().IsRegular() && f.Size() > 0 {
+			bySize[f.Size()] = append(bySize[f.Size()], fPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []DuplicateGroup{}
+	for size, paths := range bySize {
+		if len(paths) < 2 {
+			continue
+		}
+
+		byHash := map[string][]string{}
+		for _, fPath := range paths {
+			sum, err := hashFile(ctx, fs, fPath)
+			if err != nil {
+				return nil, err
+			}
+			byHash[sum] = append(byHash[sum], fPath)
+		}
+
+		for _, same := range byHash {
+			if len(same) > 1 {
+				sort.Strings(same)
+				groups = append(groups, DuplicateGroup{Size: size, Paths: same})
+			}
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Size != groups[j].Size {
+			return groups[i].Size > groups[j].Size
+		}
+		return groups[i].Paths[0] < groups[j].Paths[0]
+	})
+
+	return groups, nil
+}
+
+func hashFile(ctx context.Context, fs afero.Fs, fPath string) (string, error) {
+	file, err := fs.Open(fPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, &contextReader{ctx: ctx, r: file}); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// contextReader stops reading as soon as its context is done, so hashing
+// large files can be canceled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
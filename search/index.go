@@ -0,0 +1,253 @@
+package search
+
+import (
+	"context"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/filebrowser/filebrowser/v2/rules"
+)
+
+// Index is an in-memory index of the names of the files under a directory
+// of the local disk, kept up to date by watching it, so searches don't
+// walk the tree. It uses memory proportional to the number of files.
+type Index struct {
+	root string
+
+	mu    sync.RWMutex
+	ready bool
+	// files tells if the files, by real path, are directories.
+	files map[string]bool
+	// names are the real paths of the files by lower case name.
+	names map[string]map[string]bool
+}
+
+// NewIndex returns an index of the files under root, a directory of the
+// local disk. It is empty until Run builds it.
+func NewIndex(root string) *Index {
+	return &Index{
+		root:  filepath.Clean(root),
+		files: map[string]bool{},
+		names: map[string]map[string]bool{},
+	}
+}
+
+// Run builds the index, then keeps it up to date until the context is
+// done. If the changes can't be watched, such as when the system limit of
+// watched directories is reached, the index is dropped so the searches
+// walk the tree instead.
+func (i *Index) Run(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	if err := i.addTree(watcher, i.root); err != nil { //nolint:govet
+		i.drop()
+		return err
+	}
+	i.mu.Lock()
+	i.ready = true
+	i.mu.Unlock()
+
+	for {
+		select {
+		case event := <-watcher.Events:
+			if err := i.update(watcher, event); err != nil { //nolint:govet
+				i.drop()
+				return err
+			}
+		case err := <-watcher.Errors:
+			// Changes were missed, such as when the queue overflowed.
+			log.Printf("search index of %s: %v, rebuilding it", i.root, err)
+			i.drop()
+			if err := i.addTree(watcher, i.root); err != nil {
+				i.drop()
+				return err
+			}
+			i.mu.Lock()
+			i.ready = true
+			i.mu.Unlock()
+		case <-ctx.Done():
+			i.drop()
+			return nil
+		}
+	}
+}
+
+// Ready tells if the index is built and kept up to date.
+func (i *Index) Ready() bool {
+	if i == nil {
+		return false
+	}
+
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.ready
+}
+
+// Search searches the index for a query, like Search does, under scope of
+// the real directory base. The paths passed to the checker are relative to
+// base and the ones found to scope, sorted as a walk finds them. It
+// returns false, without searching, if the index isn't ready.
+func (i *Index) Search(base, scope, query string, checker rules.Checker, found func(path string, isDir bool) error) (bool, error) {
+	if i == nil {
+		return false, nil
+	}
+
+	search := parseSearch(query)
+	scope = path.Join("/", filepath.ToSlash(filepath.Clean(scope)))
+	realScope := filepath.Join(base, filepath.FromSlash(scope))
+
+	type match struct {
+		path  string
+		isDir bool
+	}
+	var matches []match
+
+	i.mu.RLock()
+	if !i.ready {
+		i.mu.RUnlock()
+		return false, nil
+	}
+	candidates := i.files
+	if len(search.Terms) > 0 {
+		candidates = map[string]bool{}
+		for name, paths := range i.names {
+			for _, term := range search.Terms {
+				if strings.Contains(name, strings.ToLower(term)) {
+					for p := range paths {
+						candidates[p] = i.files[p]
+					}
+					break
+				}
+			}
+		}
+	}
+	for p, isDir := range candidates {
+		rel, err := filepath.Rel(realScope, p)
+		if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		fPath := path.Join(scope, filepath.ToSlash(rel))
+		if !checker.Check(fPath) || !search.matches(fPath) {
+			continue
+		}
+		matches = append(matches, match{path: filepath.ToSlash(rel), isDir: isDir})
+	}
+	i.mu.RUnlock()
+
+	// A walk lists the files of a directory before the ones of the next.
+	sort.Slice(matches, func(a, b int) bool {
+		return strings.ReplaceAll(matches[a].path, "/", "\x00") < strings.ReplaceAll(matches[b].path, "/", "\x00")
+	})
+
+	for _, m := range matches {
+		if err := found(m.path, m.isDir); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// addTree indexes and watches the directory and what is under it. The
+// unreadable directories are skipped.
+func (i *Index) addTree(watcher *fsnotify.Watcher, dir string) error {
+	return filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+
+		if p != i.root {
+			i.add(p, info.IsDir())
+		}
+		if info.IsDir() {
+			return watcher.Add(p)
+		}
+		return nil
+	})
+}
+
+func (i *Index) update(watcher *fsnotify.Watcher, event fsnotify.Event) error {
+	if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 {
+		// The watch of a moved directory follows it, so its events may
+		// come under its new name, which exists.
+		if _, err := os.Lstat(event.Name); os.IsNotExist(err) {
+			i.remove(event.Name)
+		}
+	}
+
+	if event.Op&fsnotify.Create != 0 {
+		info, err := os.Lstat(event.Name)
+		if err != nil {
+			// Already gone.
+			return nil
+		}
+		if info.IsDir() {
+			return i.addTree(watcher, event.Name)
+		}
+		i.add(event.Name, false)
+	}
+
+	return nil
+}
+
+func (i *Index) add(p string, isDir bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.files[p] = isDir
+	name := strings.ToLower(filepath.Base(p))
+	if i.names[name] == nil {
+		i.names[name] = map[string]bool{}
+	}
+	i.names[name][p] = true
+}
+
+// remove removes the file from the index, and what was under it.
+func (i *Index) remove(p string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	isDir, ok := i.files[p]
+	if !ok {
+		return
+	}
+
+	removed := []string{p}
+	if isDir {
+		prefix := p + string(filepath.Separator)
+		for f := range i.files {
+			if strings.HasPrefix(f, prefix) {
+				removed = append(removed, f)
+			}
+		}
+	}
+
+	for _, f := range removed {
+		delete(i.files, f)
+		name := strings.ToLower(filepath.Base(f))
+		delete(i.names[name], f)
+		if len(i.names[name]) == 0 {
+			delete(i.names, name)
+		}
+	}
+}
+
+// drop empties the index, which isn't ready anymore.
+func (i *Index) drop() {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.ready = false
+	i.files = map[string]bool{}
+	i.names = map[string]map[string]bool{}
+}
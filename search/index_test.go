@@ -0,0 +1,103 @@
+package search
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// hideSecrets is a rules.Checker hiding the paths containing "secret".
+type hideSecrets struct{}
+
+func (hideSecrets) Check(p string) bool {
+	return !strings.Contains(p, "secret")
+}
+
+func TestIndex(t *testing.T) {
+	root := t.TempDir()
+	write := func(name string) {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, ioutil.WriteFile(p, []byte(name), 0644))
+	}
+	for _, name := range []string{
+		"alice/Report.pdf", "alice/photos/report.jpg", "alice/photos/cat.png", "alice/secret/report.txt",
+		"alice/reports/q1.txt", "bob/report.pdf",
+	} {
+		write(name)
+	}
+
+	index := NewIndex(root)
+	require.False(t, index.Ready())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- index.Run(ctx) }()
+	require.Eventually(t, index.Ready, 5*time.Second, 10*time.Millisecond)
+
+	base := filepath.Join(root, "alice")
+	indexed := func(scope, query string) []string {
+		paths := []string{}
+		searched, err := index.Search(base, scope, query, hideSecrets{}, func(p string, isDir bool) error {
+			if isDir {
+				p += "/"
+			}
+			paths = append(paths, p)
+			return nil
+		})
+		require.NoError(t, err)
+		require.True(t, searched)
+		return paths
+	}
+	walked := func(scope, query string) []string {
+		paths := []string{}
+		err := Search(afero.NewBasePathFs(afero.NewOsFs(), base), scope, query, hideSecrets{}, func(p string, f os.FileInfo) error {
+			if f.IsDir() {
+				p += "/"
+			}
+			paths = append(paths, p)
+			return nil
+		})
+		require.NoError(t, err)
+		return paths
+	}
+
+	for _, tc := range []struct{ scope, query string }{
+		{"/", "report"},
+		{"/", "Report case:sensitive"},
+		{"/", "type:image"},
+		{"/", ""},
+		{"/photos", "report"},
+		{"/", "nothing"},
+	} {
+		assert.Equal(t, walked(tc.scope, tc.query), indexed(tc.scope, tc.query), "%q in %s", tc.query, tc.scope)
+	}
+	assert.Equal(t, []string{"Report.pdf", "photos/report.jpg", "reports/"}, indexed("/", "report"))
+
+	// The index follows the changes.
+	write("alice/new/report.md")
+	require.NoError(t, os.RemoveAll(filepath.Join(root, "alice", "photos")))
+	require.NoError(t, os.Rename(filepath.Join(root, "alice", "reports"), filepath.Join(root, "alice", "archive")))
+	require.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual(walked("/", ""), indexed("/", ""))
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"Report.pdf", "new/report.md"}, indexed("/", "report"))
+	assert.Contains(t, indexed("/", "q1"), "archive/q1.txt")
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.False(t, index.Ready())
+
+	var nilIndex *Index
+	searched, err := nilIndex.Search(base, "/", "report", hideSecrets{}, nil)
+	require.NoError(t, err)
+	assert.False(t, searched)
+}
@@ -38,35 +38,46 @@ func Search(fs afero.Fs, scope, query string, checker rules.Checker, found func(
 			return nil
 		}
 
-		if len(search.Conditions) > 0 {
-			match := false
-
-			for _, t := range search.Conditions {
-				if t(fPath) {
-					match = true
-					break
-				}
-			}
+		if !search.matches(fPath) {
+			return nil
+		}
+
+		return found(relativePath, f)
+	})
+}
 
-			if !match {
-				return nil
+// matches tells if the file at fPath is one searched for: of one of the
+// types of the conditions, if any, and with one of the terms, if any, in
+// its name.
+func (s *searchOptions) matches(fPath string) bool {
+	if len(s.Conditions) > 0 {
+		match := false
+
+		for _, t := range s.Conditions {
+			if t(fPath) {
+				match = true
+				break
 			}
 		}
 
-		if len(search.Terms) > 0 {
-			for _, term := range search.Terms {
-				_, fileName := path.Split(fPath)
-				if !search.CaseSensitive {
-					fileName = strings.ToLower(fileName)
-					term = strings.ToLower(term)
-				}
-				if strings.Contains(fileName, term) {
-					return found(relativePath, f)
-				}
+		if !match {
+			return false
+		}
+	}
+
+	if len(s.Terms) > 0 {
+		for _, term := range s.Terms {
+			_, fileName := path.Split(fPath)
+			if !s.CaseSensitive {
+				fileName = strings.ToLower(fileName)
+				term = strings.ToLower(term)
+			}
+			if strings.Contains(fileName, term) {
+				return true
 			}
-			return nil
 		}
+		return false
+	}
 
-		return found(relativePath, f)
-	})
+	return true
 }
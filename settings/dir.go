@@ -8,6 +8,8 @@ import (
 	"strings"
 
 	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/users"
 )
 
 var (
@@ -28,7 +30,7 @@ func (s *Settings) MakeUserDir(username, userScope, serverRoot string) (string,
 		return userScope, nil
 	}
 
-	fs := afero.NewBasePathFs(afero.NewOsFs(), serverRoot)
+	fs := afero.NewBasePathFs(users.RootFs, serverRoot)
 
 	// Use the default auto create logic only if specific scope is not the default scope
 	if userScope != s.Defaults.Scope {
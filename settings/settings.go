@@ -13,6 +13,7 @@ type AuthMethod string
 // Settings contain the main settings of the application.
 type Settings struct {
 	Key           []byte              `json:"key"`
+	ShareKey      []byte              `json:"shareKey"`
 	Signup        bool                `json:"signup"`
 	CreateUserDir bool                `json:"createUserDir"`
 	Defaults      UserDefaults        `json:"defaults"`
@@ -30,18 +31,106 @@ func (s *Settings) GetRules() []rules.Rule {
 
 // Server specific settings.
 type Server struct {
-	Root                  string `json:"root"`
-	BaseURL               string `json:"baseURL"`
-	Socket                string `json:"socket"`
-	TLSKey                string `json:"tlsKey"`
-	TLSCert               string `json:"tlsCert"`
-	Port                  string `json:"port"`
-	Address               string `json:"address"`
-	Log                   string `json:"log"`
-	EnableThumbnails      bool   `json:"enableThumbnails"`
-	ResizePreview         bool   `json:"resizePreview"`
-	EnableExec            bool   `json:"enableExec"`
-	TypeDetectionByHeader bool   `json:"typeDetectionByHeader"`
+	Root                    string            `json:"root"`
+	BaseURL                 string            `json:"baseURL"`
+	Socket                  string            `json:"socket"`
+	TLSKey                  string            `json:"tlsKey"`
+	TLSCert                 string            `json:"tlsCert"`
+	Port                    string            `json:"port"`
+	Address                 string            `json:"address"`
+	Log                     string            `json:"log"`
+	EnableThumbnails        bool              `json:"enableThumbnails"`
+	ResizePreview           bool              `json:"resizePreview"`
+	EnableExec              bool              `json:"enableExec"`
+	TypeDetectionByHeader   bool              `json:"typeDetectionByHeader"`
+	SVGHandling             string            `json:"svgHandling"`
+	ReadmeNames             []string          `json:"readmeNames"`
+	ReadmeMaxSize           int64             `json:"readmeMaxSize"`
+	ServeIndexFiles         bool              `json:"serveIndexFiles"`
+	IndexNames              []string          `json:"indexNames"`
+	CacheMaxAge             map[string]int    `json:"cacheMaxAge"`
+	ShowXattrs              bool              `json:"showXattrs"`
+	MaxBatchSize            int               `json:"maxBatchSize"`
+	MaxConcurrentHeavyOps   int               `json:"maxConcurrentHeavyOps"`
+	HeavyOpsTimeout         int               `json:"heavyOpsTimeout"`
+	FollowSymlinks          bool              `json:"followSymlinks"`
+	HistoryLog              string            `json:"historyLog"`
+	HomePath                string            `json:"homePath"`
+	PinsPath                string            `json:"pinsPath"`
+	EnableWebDAV            bool              `json:"enableWebDAV"`
+	S3Endpoint              string            `json:"s3Endpoint"`
+	S3CacheTTL              int               `json:"s3CacheTTL"`
+	ListingColumns          []string          `json:"listingColumns"`
+	OperationTimeout        int               `json:"operationTimeout"`
+	QRSize                  int               `json:"qrSize"`
+	UploadAllowExtensions   []string          `json:"uploadAllowExtensions"`
+	UploadBlockExtensions   []string          `json:"uploadBlockExtensions"`
+	HexDumpMaxSize          int               `json:"hexDumpMaxSize"`
+	HeaderHTML              string            `json:"headerHTML"`
+	FooterHTML              string            `json:"footerHTML"`
+	LargeFileThreshold      int64             `json:"largeFileThreshold"`
+	DirMTimeFromContents    bool              `json:"dirMTimeFromContents"`
+	CanonicalSlash          bool              `json:"canonicalSlash"`
+	ImageDimensions         bool              `json:"imageDimensions"`
+	DefaultMimeType         string            `json:"defaultMimeType"`
+	ShowChildCounts         bool              `json:"showChildCounts"`
+	MaxPathDepth            int               `json:"maxPathDepth"`
+	DisplayNamePattern      string            `json:"displayNamePattern"`
+	DisplayNameReplacement  string            `json:"displayNameReplacement"`
+	PreviewMaxSize          int64             `json:"previewMaxSize"`
+	PreviewMaxSizes         map[string]int64  `json:"previewMaxSizes"`
+	HealthPath              string            `json:"healthPath"`
+	SymlinkResolveDepth     int               `json:"symlinkResolveDepth"`
+	OfficeConverter         string            `json:"officeConverter"`
+	Locale                  string            `json:"locale"`
+	ThumbSize               int               `json:"thumbSize"`
+	PDFRenderer             string            `json:"pdfRenderer"`
+	ChecksumStorePath       string            `json:"checksumStorePath"`
+	CORSOrigins             []string          `json:"corsOrigins"`
+	UploadHook              string            `json:"uploadHook"`
+	RejectOnHookFailure     bool              `json:"rejectOnHookFailure"`
+	Timezone                string            `json:"timezone"`
+	VersionsLayout          string            `json:"versionsLayout"`
+	KeepVersions            int               `json:"keepVersions"`
+	TextExtensions          []string          `json:"textExtensions"`
+	RequireSignedURLs       bool              `json:"requireSignedURLs"`
+	URLSigningSecret        string            `json:"urlSigningSecret"`
+	WarmPaths               []string          `json:"warmPaths"`
+	WarmInterval            int               `json:"warmInterval"`
+	BinaryThreshold         float64           `json:"binaryThreshold"`
+	ForceDownloadExtensions []string          `json:"forceDownloadExtensions"`
+	AutoView                bool              `json:"autoView"`
+	DisableFetch            bool              `json:"disableFetch"`
+	FetchTimeout            int               `json:"fetchTimeout"`
+	FetchMaxSize            int64             `json:"fetchMaxSize"`
+	GracefulPreviewErrors   bool              `json:"gracefulPreviewErrors"`
+	UILocales               []string          `json:"uiLocales"`
+	FlattenMaxFiles         int               `json:"flattenMaxFiles"`
+	CaseInsensitive         bool              `json:"caseInsensitive"`
+	MaxUploadFiles          int               `json:"maxUploadFiles"`
+	AllowSymlinkCreation    bool              `json:"allowSymlinkCreation"`
+	SaveLineEndings         string            `json:"saveLineEndings"`
+	SaveFinalNewline        bool              `json:"saveFinalNewline"`
+	TreeMaxNodes            int               `json:"treeMaxNodes"`
+	StreamBufferSize        int               `json:"streamBufferSize"`
+	BacklinksMaxDepth       int               `json:"backlinksMaxDepth"`
+	HeadExistsHeader        bool              `json:"headExistsHeader"`
+	BuildSearchIndex        bool              `json:"buildSearchIndex"`
+	MobileLimits            MobileLimits      `json:"mobileLimits"`
+	Aliases                 map[string]string `json:"aliases"`
+	HashOnStat              bool              `json:"hashOnStat"`
+}
+
+// MobileLimits are the limits of the previews sent to mobile devices,
+// which are the desktop ones where zero.
+type MobileLimits struct {
+	// PreviewMaxSize is the maximum size of the text previews, lowering
+	// the desktop ones.
+	PreviewMaxSize int64 `json:"previewMaxSize"`
+	// ThumbSize and BigPreviewSize are the sizes in pixels the images
+	// are resized to for thumbnails and big previews.
+	ThumbSize      int `json:"thumbSize"`
+	BigPreviewSize int `json:"bigPreviewSize"`
 }
 
 // Clean cleans any variables that might need cleaning.
@@ -35,6 +35,10 @@ var defaultEvents = []string{
 	"rename",
 	"upload",
 	"delete",
+	"extract",
+	"compress",
+	"touch",
+	"symlink",
 }
 
 // Save saves the settings for the current instance.
@@ -0,0 +1,69 @@
+package share
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+)
+
+// NewToken creates a stateless share token for the link, signed with
+// key using HMAC-SHA256 over the user, path and expiration.
+func NewToken(key []byte, l *Link) string {
+	payload := strconv.FormatUint(uint64(l.UserID), 10) + ":" +
+		strconv.FormatInt(l.Expire, 10) + ":" + l.Path
+
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(payload))
+	return encoded + "." + signToken(key, encoded)
+}
+
+// ParseToken verifies a token created by NewToken and returns the
+// link it grants access to. Tampered and expired tokens return
+// errors.ErrPermissionDenied.
+func ParseToken(key []byte, token string) (*Link, error) {
+	parts := strings.SplitN(token, ".", 2)
+	if len(parts) != 2 {
+		return nil, errors.ErrPermissionDenied
+	}
+
+	if !hmac.Equal([]byte(signToken(key, parts[0])), []byte(parts[1])) {
+		return nil, errors.ErrPermissionDenied
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.ErrPermissionDenied
+	}
+
+	fields := strings.SplitN(string(payload), ":", 3)
+	if len(fields) != 3 {
+		return nil, errors.ErrPermissionDenied
+	}
+
+	userID, err := strconv.ParseUint(fields[0], 10, 32)
+	if err != nil {
+		return nil, errors.ErrPermissionDenied
+	}
+
+	expire, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || expire <= time.Now().Unix() {
+		return nil, errors.ErrPermissionDenied
+	}
+
+	return &Link{
+		Path:   fields[2],
+		UserID: uint(userID),
+		Expire: expire,
+	}, nil
+}
+
+func signToken(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
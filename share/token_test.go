@@ -0,0 +1,69 @@
+package share
+
+import (
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+)
+
+var testKey = []byte("share-token-test-key")
+
+func TestTokenRoundTrip(t *testing.T) {
+	want := &Link{
+		Path:   "/docs/a:b.txt",
+		UserID: 7,
+		Expire: time.Now().Add(time.Hour).Unix(),
+	}
+
+	got, err := ParseToken(testKey, NewToken(testKey, want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *got != *want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestTokenRejected(t *testing.T) {
+	link := &Link{
+		Path:   "/docs",
+		UserID: 1,
+		Expire: time.Now().Add(time.Hour).Unix(),
+	}
+	token := NewToken(testKey, link)
+	parts := strings.SplitN(token, ".", 2)
+	expire := strconv.FormatInt(link.Expire, 10)
+
+	// resign keeps the original signature over a modified payload.
+	resign := func(payload string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + parts[1]
+	}
+
+	expired := *link
+	expired.Expire = time.Now().Add(-time.Minute).Unix()
+
+	tests := map[string]struct {
+		key   []byte
+		token string
+	}{
+		"expired":   {testKey, NewToken(testKey, &expired)},
+		"path":      {testKey, resign("1:" + expire + ":/")},
+		"expiry":    {testKey, resign("1:9999999999:/docs")},
+		"user":      {testKey, resign("2:" + expire + ":/docs")},
+		"signature": {testKey, parts[0] + "." + strings.Repeat("0", len(parts[1]))},
+		"key":       {[]byte("another key"), token},
+		"malformed": {testKey, parts[0]},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseToken(tt.key, tt.token); err != errors.ErrPermissionDenied {
+				t.Errorf("got %v, want %v", err, errors.ErrPermissionDenied)
+			}
+		})
+	}
+}
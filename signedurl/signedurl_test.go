@@ -0,0 +1,46 @@
+package signedurl
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerify(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	signed := sign("/api/raw/docs/a b.txt", now.Add(time.Minute).Unix(), "secret")
+
+	testCases := map[string]struct {
+		url    string
+		secret string
+		now    time.Time
+		want   error
+	}{
+		"valid":            {url: signed, secret: "secret", now: now},
+		"extra parameters": {url: signed + "&inline=true", secret: "secret", now: now},
+		"expired":          {url: signed, secret: "secret", now: now.Add(2 * time.Minute), want: ErrExpired},
+		"other secret":     {url: signed, secret: "other", now: now, want: ErrInvalidSignature},
+		"other path":       {url: "/api/raw/docs/b.txt?" + mustParse(t, signed).RawQuery, secret: "secret", now: now, want: ErrInvalidSignature},
+		"unsigned":         {url: "/api/raw/docs/a.txt", secret: "secret", now: now, want: ErrUnsigned},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.want, Verify(mustParse(t, tc.url), tc.secret, tc.now))
+		})
+	}
+}
+
+func TestSignURL(t *testing.T) {
+	u := mustParse(t, SignURL("/api/resources/docs/", time.Hour, "secret"))
+	require.Equal(t, "/api/resources/docs/", u.Path)
+	require.NoError(t, Verify(u, "secret", time.Now()))
+}
+
+func mustParse(t *testing.T, rawurl string) *url.URL {
+	u, err := url.Parse(rawurl)
+	require.NoError(t, err)
+	return u
+}
@@ -0,0 +1,72 @@
+// Package testutil provides helpers to test the handlers and the file
+// operations against in-memory filesystems instead of the disk.
+package testutil
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// NewFs creates an in-memory filesystem holding the given entries, which
+// map paths to file contents. Paths ending with a slash are directories.
+// Like the filesystems of the users, it is scoped with afero.BasePathFs so
+// it can be used everywhere the real ones are.
+func NewFs(t testing.TB, entries map[string]string) afero.Fs {
+	t.Helper()
+
+	fs := afero.NewBasePathFs(afero.NewMemMapFs(), "/")
+	for name, content := range entries {
+		var err error
+		if strings.HasSuffix(name, "/") {
+			err = fs.MkdirAll(name, 0755)
+		} else if err = fs.MkdirAll(path.Dir(name), 0755); err == nil {
+			err = afero.WriteFile(fs, name, []byte(content), 0644)
+		}
+
+		if err != nil {
+			t.Fatalf("couldn't create %s: %v", name, err)
+		}
+	}
+
+	return fs
+}
+
+// Tree returns the entries of the filesystem in the same form NewFs takes
+// them, so the result of an operation can be compared with the expected
+// one. The root directory is left out.
+func Tree(t testing.TB, fs afero.Fs) map[string]string {
+	t.Helper()
+
+	entries := map[string]string{}
+	err := afero.Walk(fs, "/", func(name string, info os.FileInfo, err error) error {
+		if err != nil || name == "/" {
+			return err
+		}
+
+		if info.IsDir() {
+			entries[name+"/"] = ""
+			return nil
+		}
+
+		content, err := afero.ReadFile(fs, name)
+		entries[name] = string(content)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("couldn't walk the filesystem: %v", err)
+	}
+
+	return entries
+}
+
+// AllowAll is a rules.Checker allowing every path.
+type AllowAll struct{}
+
+// Check implements rules.Checker.
+func (AllowAll) Check(string) bool {
+	return true
+}
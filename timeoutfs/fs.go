@@ -0,0 +1,215 @@
+// Package timeoutfs bounds the time the operations on a filesystem can
+// take, so a stalled network mount doesn't block the requests forever.
+package timeoutfs
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+)
+
+// Fs wraps a filesystem so opening, stating and reading directories fail
+// with errors.ErrOperationTimeout when they take longer than the timeout.
+// Such operations can't be interrupted: they are abandoned in the
+// background and the files they eventually open are closed.
+type Fs struct {
+	source  afero.Fs
+	timeout time.Duration
+}
+
+// New returns a filesystem bounding the operations on source by timeout.
+func New(source afero.Fs, timeout time.Duration) *Fs {
+	return &Fs{source: source, timeout: timeout}
+}
+
+// run runs fn, giving up after the timeout. If it gives up, abandon is
+// called, if not nil, with the value fn eventually returns. The results
+// go through a channel so an abandoned fn never races with the caller.
+func run(timeout time.Duration, op, name string, fn func() (interface{}, error), abandon func(interface{})) (interface{}, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type result struct {
+		value interface{}
+		err   error
+	}
+
+	done := make(chan result)
+	abandoned := make(chan struct{})
+
+	go func() {
+		value, err := fn()
+		select {
+		case done <- result{value, err}:
+		case <-abandoned:
+			if abandon != nil {
+				abandon(value)
+			}
+		}
+	}()
+
+	select {
+	case res := <-done:
+		return res.value, res.err
+	case <-ctx.Done():
+		close(abandoned)
+		return nil, &os.PathError{Op: op, Path: name, Err: errors.ErrOperationTimeout}
+	}
+}
+
+func (fs *Fs) openFile(op, name string, open func() (afero.File, error)) (afero.File, error) {
+	value, err := run(fs.timeout, op, name, func() (interface{}, error) {
+		return open()
+	}, func(value interface{}) {
+		if file, ok := value.(afero.File); ok && file != nil {
+			file.Close()
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &File{File: value.(afero.File), timeout: fs.timeout}, nil
+}
+
+// Create implements afero.Fs.
+func (fs *Fs) Create(name string) (afero.File, error) {
+	return fs.openFile("create", name, func() (afero.File, error) {
+		return fs.source.Create(name)
+	})
+}
+
+// Open implements afero.Fs.
+func (fs *Fs) Open(name string) (afero.File, error) {
+	return fs.openFile("open", name, func() (afero.File, error) {
+		return fs.source.Open(name)
+	})
+}
+
+// OpenFile implements afero.Fs.
+func (fs *Fs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	return fs.openFile("open", name, func() (afero.File, error) {
+		return fs.source.OpenFile(name, flag, perm)
+	})
+}
+
+// Stat implements afero.Fs.
+func (fs *Fs) Stat(name string) (os.FileInfo, error) {
+	value, err := run(fs.timeout, "stat", name, func() (interface{}, error) {
+		return fs.source.Stat(name)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return value.(os.FileInfo), nil
+}
+
+// LstatIfPossible implements afero.Lstater. It tells if the source
+// filesystem could lstat, so callers still know it is the OS one.
+func (fs *Fs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	lstater, ok := fs.source.(afero.Lstater)
+	if !ok {
+		info, err := fs.Stat(name)
+		return info, false, err
+	}
+
+	value, err := run(fs.timeout, "lstat", name, func() (interface{}, error) {
+		info, lstat, err := lstater.LstatIfPossible(name)
+		return lstatResult{info, lstat}, err
+	}, nil)
+	res, _ := value.(lstatResult)
+	return res.info, res.lstat, err
+}
+
+type lstatResult struct {
+	info  os.FileInfo
+	lstat bool
+}
+
+// InvalidateCache implements files.CacheInvalidator, for the source
+// filesystems which cache.
+func (fs *Fs) InvalidateCache(name string) {
+	if invalidator, ok := fs.source.(interface{ InvalidateCache(string) }); ok {
+		invalidator.InvalidateCache(name)
+	}
+}
+
+// Mkdir implements afero.Fs.
+func (fs *Fs) Mkdir(name string, perm os.FileMode) error {
+	return fs.source.Mkdir(name, perm)
+}
+
+// MkdirAll implements afero.Fs.
+func (fs *Fs) MkdirAll(name string, perm os.FileMode) error {
+	return fs.source.MkdirAll(name, perm)
+}
+
+// Remove implements afero.Fs.
+func (fs *Fs) Remove(name string) error {
+	return fs.source.Remove(name)
+}
+
+// RemoveAll implements afero.Fs.
+func (fs *Fs) RemoveAll(name string) error {
+	return fs.source.RemoveAll(name)
+}
+
+// Rename implements afero.Fs.
+func (fs *Fs) Rename(oldname, newname string) error {
+	return fs.source.Rename(oldname, newname)
+}
+
+// Name implements afero.Fs.
+func (fs *Fs) Name() string {
+	return "TimeoutFs"
+}
+
+// Chmod implements afero.Fs.
+func (fs *Fs) Chmod(name string, mode os.FileMode) error {
+	return fs.source.Chmod(name, mode)
+}
+
+// Chtimes implements afero.Fs.
+func (fs *Fs) Chtimes(name string, atime, mtime time.Time) error {
+	return fs.source.Chtimes(name, atime, mtime)
+}
+
+// File is a file whose directory reads and stats are bounded by the
+// timeout of its filesystem.
+type File struct {
+	afero.File
+	timeout time.Duration
+}
+
+// Readdir implements afero.File.
+func (f *File) Readdir(count int) ([]os.FileInfo, error) {
+	value, err := run(f.timeout, "readdir", f.Name(), func() (interface{}, error) {
+		return f.File.Readdir(count)
+	}, nil)
+	infos, _ := value.([]os.FileInfo)
+	return infos, err
+}
+
+// Readdirnames implements afero.File.
+func (f *File) Readdirnames(n int) ([]string, error) {
+	value, err := run(f.timeout, "readdir", f.Name(), func() (interface{}, error) {
+		return f.File.Readdirnames(n)
+	}, nil)
+	names, _ := value.([]string)
+	return names, err
+}
+
+// Stat implements afero.File.
+func (f *File) Stat() (os.FileInfo, error) {
+	value, err := run(f.timeout, "stat", f.Name(), func() (interface{}, error) {
+		return f.File.Stat()
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return value.(os.FileInfo), nil
+}
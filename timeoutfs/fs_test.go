@@ -0,0 +1,82 @@
+package timeoutfs
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	fbErrors "github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/files"
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+// sleepyFs is a filesystem whose directory reads hang, like the ones of
+// a stalled network mount.
+type sleepyFs struct {
+	afero.Fs
+	delay time.Duration
+}
+
+func (fs *sleepyFs) Open(name string) (afero.File, error) {
+	file, err := fs.Fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &sleepyFile{File: file, delay: fs.delay}, nil
+}
+
+type sleepyFile struct {
+	afero.File
+	delay time.Duration
+}
+
+func (f *sleepyFile) Readdir(count int) ([]os.FileInfo, error) {
+	time.Sleep(f.delay)
+	return f.File.Readdir(count)
+}
+
+func (f *sleepyFile) Readdirnames(n int) ([]string, error) {
+	time.Sleep(f.delay)
+	return f.File.Readdirnames(n)
+}
+
+func newSleepyFs(t *testing.T, delay, timeout time.Duration) afero.Fs {
+	source := testutil.NewFs(t, map[string]string{"/dir/a.txt": "a"})
+	return afero.NewBasePathFs(New(&sleepyFs{Fs: source, delay: delay}, timeout), "/")
+}
+
+func TestReaddirTimeout(t *testing.T) {
+	fs := newSleepyFs(t, time.Second, 50*time.Millisecond)
+
+	start := time.Now()
+	_, err := afero.ReadDir(fs, "/dir")
+	assert.True(t, errors.Is(err, fbErrors.ErrOperationTimeout), "got %v", err)
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+
+	_, err = files.NewFileInfo(files.FileOptions{
+		Fs:      fs,
+		Path:    "/dir",
+		Expand:  true,
+		Checker: testutil.AllowAll{},
+	})
+	assert.True(t, errors.Is(err, fbErrors.ErrOperationTimeout), "got %v", err)
+
+	// Operations that don't hang are unaffected.
+	info, err := fs.Stat("/dir/a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), info.Size())
+}
+
+func TestReaddirInTime(t *testing.T) {
+	fs := newSleepyFs(t, 10*time.Millisecond, time.Second)
+
+	infos, err := afero.ReadDir(fs, "/dir")
+	require.NoError(t, err)
+	require.Len(t, infos, 1)
+	assert.Equal(t, "a.txt", infos[0].Name())
+}
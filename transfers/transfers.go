@@ -0,0 +1,185 @@
+// Package transfers runs the copies and moves of large files in the
+// background, so their progress can be followed and they can be canceled.
+package transfers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status is the state of a transfer.
+type Status string
+
+const (
+	// Running transfers are still copying.
+	Running Status = "running"
+	// Done transfers completed successfully.
+	Done Status = "done"
+	// Failed transfers stopped on an error.
+	Failed Status = "failed"
+	// Canceled transfers were canceled before completing.
+	Canceled Status = "canceled"
+)
+
+// Transfer is a copy or move running in the background.
+type Transfer struct {
+	ID       string    `json:"id"`
+	Action   string    `json:"action"`
+	Src      string    `json:"src"`
+	Dst      string    `json:"dst"`
+	Status   Status    `json:"status"`
+	Done     int64     `json:"done"`
+	Total    int64     `json:"total"`
+	Error    string    `json:"error,omitempty"`
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished,omitempty"`
+
+	userID uint
+	cancel context.CancelFunc
+}
+
+// Func runs a transfer until it completes or the context is done, giving
+// the number of bytes it copies to progress as it goes.
+type Func func(ctx context.Context, progress func(n int64)) error
+
+// Registry keeps track of the transfers of all the users. The finished
+// ones are forgotten after a while.
+type Registry struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	transfers map[string]*Transfer
+}
+
+// NewRegistry creates a registry which keeps the finished transfers for
+// ttl.
+func NewRegistry(ttl time.Duration) *Registry {
+	return &Registry{
+		ttl:       ttl,
+		transfers: map[string]*Transfer{},
+	}
+}
+
+// Start runs fn in the background as a transfer of the user and returns
+// it as it starts. The total is the number of bytes to copy.
+func (r *Registry) Start(userID uint, action, src, dst string, total int64, fn Func) (Transfer, error) {
+	id, err := newID()
+	if err != nil {
+		return Transfer{}, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	transfer := &Transfer{
+		ID:      id,
+		Action:  action,
+		Src:     src,
+		Dst:     dst,
+		Status:  Running,
+		Total:   total,
+		Started: time.Now(),
+		userID:  userID,
+		cancel:  cancel,
+	}
+
+	r.mu.Lock()
+	r.prune()
+	r.transfers[id] = transfer
+	started := *transfer
+	r.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		err := fn(ctx, func(n int64) {
+			r.mu.Lock()
+			transfer.Done += n
+			r.mu.Unlock()
+		})
+		r.finish(ctx, transfer, err)
+	}()
+
+	return started, nil
+}
+
+func (r *Registry) finish(ctx context.Context, transfer *Transfer, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	transfer.Finished = time.Now()
+	switch {
+	case err == nil:
+		transfer.Status = Done
+	case ctx.Err() != nil:
+		transfer.Status = Canceled
+	default:
+		transfer.Status = Failed
+		transfer.Error = err.Error()
+	}
+}
+
+// Get returns the transfer of the user with the ID, or false if there is
+// none.
+func (r *Registry) Get(userID uint, id string) (Transfer, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	transfer, ok := r.transfers[id]
+	if !ok || transfer.userID != userID {
+		return Transfer{}, false
+	}
+	return *transfer, true
+}
+
+// List returns the transfers of the user, from the oldest.
+func (r *Registry) List(userID uint) []Transfer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune()
+	list := []Transfer{}
+	for _, transfer := range r.transfers {
+		if transfer.userID == userID {
+			list = append(list, *transfer)
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Started.Before(list[j].Started)
+	})
+	return list
+}
+
+// Cancel cancels the transfer of the user with the ID. It returns false
+// if there is no such transfer.
+func (r *Registry) Cancel(userID uint, id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	transfer, ok := r.transfers[id]
+	if !ok || transfer.userID != userID {
+		return false
+	}
+	transfer.cancel()
+	return true
+}
+
+// prune forgets the transfers finished for longer than the TTL. It must
+// be called with the lock held.
+func (r *Registry) prune() {
+	now := time.Now()
+	for id, transfer := range r.transfers {
+		if transfer.Status != Running && now.Sub(transfer.Finished) > r.ttl {
+			delete(r.transfers, id)
+		}
+	}
+}
+
+func newID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
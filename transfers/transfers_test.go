@@ -0,0 +1,85 @@
+package transfers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wait waits for the transfer to finish and returns it.
+func wait(t *testing.T, r *Registry, userID uint, id string) Transfer {
+	t.Helper()
+
+	for start := time.Now(); time.Since(start) < time.Second; time.Sleep(time.Millisecond) {
+		transfer, ok := r.Get(userID, id)
+		require.True(t, ok)
+		if transfer.Status != Running {
+			return transfer
+		}
+	}
+
+	t.Fatal("the transfer didn't finish")
+	return Transfer{}
+}
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry(time.Hour)
+
+	done, err := r.Start(1, "copy", "/a", "/b", 10, func(ctx context.Context, progress func(int64)) error {
+		progress(4)
+		progress(6)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Running, done.Status)
+
+	failed, err := r.Start(1, "rename", "/c", "/d", 10, func(ctx context.Context, progress func(int64)) error {
+		return errors.New("disk full")
+	})
+	require.NoError(t, err)
+
+	transfer := wait(t, r, 1, done.ID)
+	assert.Equal(t, Done, transfer.Status)
+	assert.Equal(t, int64(10), transfer.Done)
+
+	transfer = wait(t, r, 1, failed.ID)
+	assert.Equal(t, Failed, transfer.Status)
+	assert.Equal(t, "disk full", transfer.Error)
+
+	// The transfers of other users can't be seen nor canceled.
+	_, ok := r.Get(2, done.ID)
+	assert.False(t, ok)
+	assert.False(t, r.Cancel(2, done.ID))
+	assert.Empty(t, r.List(2))
+	assert.Len(t, r.List(1), 2)
+}
+
+func TestRegistryCancel(t *testing.T) {
+	r := NewRegistry(time.Hour)
+
+	started, err := r.Start(1, "copy", "/a", "/b", 10, func(ctx context.Context, progress func(int64)) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	require.NoError(t, err)
+
+	require.True(t, r.Cancel(1, started.ID))
+	assert.Equal(t, Canceled, wait(t, r, 1, started.ID).Status)
+}
+
+func TestRegistryPrune(t *testing.T) {
+	r := NewRegistry(time.Millisecond)
+
+	started, err := r.Start(1, "copy", "/a", "/b", 0, func(context.Context, func(int64)) error {
+		return nil
+	})
+	require.NoError(t, err)
+	wait(t, r, 1, started.ID)
+
+	time.Sleep(2 * time.Millisecond)
+	assert.Empty(t, r.List(1))
+}
@@ -19,6 +19,10 @@ const (
 	MosaicViewMode ViewMode = "mosaic"
 )
 
+// RootFs is the filesystem the scopes of the users are relative to. It
+// is the OS filesystem unless the server root is stored elsewhere.
+var RootFs afero.Fs = afero.NewOsFs()
+
 // User describes a user.
 type User struct {
 	ID           uint          `storm:"id,increment" json:"id"`
@@ -96,7 +100,7 @@ func (u *User) Clean(baseScope string, fields ...string) error {
 			scope = filepath.Join(baseScope, scope)
 		}
 
-		u.Fs = afero.NewBasePathFs(afero.NewOsFs(), scope)
+		u.Fs = afero.NewBasePathFs(RootFs, scope)
 	}
 
 	return nil
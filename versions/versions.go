@@ -0,0 +1,195 @@
+// Package versions finds the previous versions of files kept next to them,
+// such as the timestamped copies of a backup tool.
+package versions
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/fileutils"
+)
+
+// DefaultLayout keeps the versions of a file in the .versions directory
+// next to it, in a directory named after the file.
+const DefaultLayout = Layout(".versions/{name}/{version}")
+
+// Layout is the path of a version of a file, where {version} stands for
+// the version, {name} for the name of the file, {dir} for its directory
+// and {path} for its path. Layouts starting with a slash are relative to
+// the root, the others to the directory of the file. {version} must be in
+// the last element of the path.
+type Layout string
+
+// Version is a previous version of a file.
+type Version struct {
+	Version string    `json:"version"`
+	ModTime time.Time `json:"modified"`
+	Size    int64     `json:"size"`
+}
+
+// ParseLayout checks that the layout has one {version}, in its last
+// element.
+func ParseLayout(value string) (Layout, error) {
+	if strings.Count(value, "{version}") != 1 || !strings.Contains(path.Base(value), "{version}") {
+		return "", fmt.Errorf("the versions layout %q must have {version} in its last element", value)
+	}
+	return Layout(value), nil
+}
+
+// split returns the directory holding the versions of the file, and what
+// the names of the versions start and end with.
+func (l Layout) split(file string) (dir, prefix, suffix string) {
+	file = path.Clean("/" + file)
+
+	name := path.Base(file)
+	replacer := strings.NewReplacer("{name}", name, "{dir}", path.Dir(file), "{path}", file)
+
+	layout := string(l)
+	base := path.Base(layout)
+	dir = replacer.Replace(path.Dir(layout))
+	if !strings.HasPrefix(layout, "/") {
+		dir = path.Join(path.Dir(file), dir)
+	}
+
+	parts := strings.SplitN(base, "{version}", 2)
+	return path.Clean(dir), replacer.Replace(parts[0]), replacer.Replace(parts[1])
+}
+
+// Path returns the path of the version of the file. It returns
+// errors.ErrInvalidRequestParams if the version isn't a single path
+// element, so it can't point outside of the versions directory.
+func (l Layout) Path(file, version string) (string, error) {
+	if version == "" || version == "." || version == ".." || strings.ContainsAny(version, `/\`) {
+		return "", fmt.Errorf("invalid version %q: %w", version, errors.ErrInvalidRequestParams)
+	}
+
+	dir, prefix, suffix := l.split(file)
+	return path.Join(dir, prefix+version+suffix), nil
+}
+
+// List lists the versions of the file, the most recent first.
+func (l Layout) List(fs afero.Fs, file string) ([]Version, error) {
+	dir, prefix, suffix := l.split(file)
+
+	infos, err := afero.ReadDir(fs, dir)
+	if os.IsNotExist(err) {
+		return []Version{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []Version{}
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || len(name) <= len(prefix)+len(suffix) ||
+			!strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+
+		versions = append(versions, Version{
+			Version: strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix),
+			ModTime: info.ModTime(),
+			Size:    info.Size(),
+		})
+	}
+
+	sort.Slice(versions, func(i, j int) bool {
+		if !versions[i].ModTime.Equal(versions[j].ModTime) {
+			return versions[i].ModTime.After(versions[j].ModTime)
+		}
+		return versions[i].Version > versions[j].Version
+	})
+
+	return versions, nil
+}
+
+// TimeFormat is the format of the versions of the snapshots.
+const TimeFormat = "20060102T150405.000000000Z"
+
+// Snapshot keeps the current content of the file as a version named after
+// the time, and removes the oldest versions beyond keep. The version is
+// made with link if it succeeds, such as a hard link sharing the content
+// of the file, as a copy otherwise.
+func (l Layout) Snapshot(fs afero.Fs, file string, now time.Time, keep int, link func(oldname, newname string) error) error {
+	versionPath, err := l.Path(file, now.UTC().Format(TimeFormat))
+	if err != nil {
+		return err
+	}
+
+	if err := fs.MkdirAll(path.Dir(versionPath), 0775); err != nil {
+		return err
+	}
+
+	if link == nil || link(file, versionPath) != nil {
+		if err := fileutils.CopyFile(fs, file, versionPath); err != nil {
+			return err
+		}
+	}
+
+	return l.Prune(fs, file, keep)
+}
+
+// Prune removes the oldest versions of the file beyond keep.
+func (l Layout) Prune(fs afero.Fs, file string, keep int) error {
+	list, err := l.List(fs, file)
+	if err != nil || len(list) <= keep {
+		return err
+	}
+
+	for _, version := range list[keep:] {
+		versionPath, err := l.Path(file, version.Version)
+		if err != nil {
+			return err
+		}
+		if err := fs.Remove(versionPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Hides tells whether the path is in the directories keeping the versions,
+// which are never listed: for layouts relative to the files, those named
+// like the first element of the layout, such as .versions, and for the
+// others, the first directory of the layout.
+func (l Layout) Hides(p string) bool {
+	layout := string(l)
+	absolute := strings.HasPrefix(layout, "/")
+
+	// The fixed part of the layout ends with a complete element if it is
+	// followed by a slash, which {dir} and {path} start with.
+	fixed := layout
+	if i := strings.Index(layout, "{"); i != -1 {
+		fixed = layout[:i]
+		if strings.HasPrefix(layout[i:], "{dir}") || strings.HasPrefix(layout[i:], "{path}") {
+			fixed += "/"
+		}
+	}
+
+	elems := strings.SplitN(strings.TrimPrefix(fixed, "/"), "/", 2)
+	if len(elems) < 2 || elems[0] == "" {
+		return false
+	}
+	first := elems[0]
+
+	if absolute {
+		p = path.Clean("/" + p)
+		return p == "/"+first || strings.HasPrefix(p, "/"+first+"/")
+	}
+
+	for _, elem := range strings.Split(p, "/") {
+		if elem == first {
+			return true
+		}
+	}
+	return false
+}
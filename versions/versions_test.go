@@ -0,0 +1,113 @@
+package versions
+
+import (
+	stderrors "errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filebrowser/filebrowser/v2/errors"
+	"github.com/filebrowser/filebrowser/v2/testutil"
+)
+
+func TestLayoutPath(t *testing.T) {
+	testCases := []struct {
+		layout, file, version, want string
+	}{
+		{string(DefaultLayout), "/docs/a.txt", "20200102T030405Z", "/docs/.versions/a.txt/20200102T030405Z"},
+		{".versions/{name}.{version}", "/a.txt", "1", "/.versions/a.txt.1"},
+		{"/backups{path}@{version}", "/docs/a.txt", "2", "/backups/docs/a.txt@2"},
+	}
+
+	for _, tc := range testCases {
+		layout, err := ParseLayout(tc.layout)
+		require.NoError(t, err)
+
+		got, err := layout.Path(tc.file, tc.version)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, got)
+	}
+
+	for _, version := range []string{"", ".", "..", "../../etc/passwd", `..\x`, "a/b"} {
+		_, err := DefaultLayout.Path("/a.txt", version)
+		require.True(t, stderrors.Is(err, errors.ErrInvalidRequestParams), version)
+	}
+
+	for _, layout := range []string{".versions/{name}", "{version}/{name}", "{version}/{version}"} {
+		_, err := ParseLayout(layout)
+		require.Error(t, err, layout)
+	}
+}
+
+func TestLayoutList(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{
+		"/docs/a.txt":                 "current",
+		"/docs/.versions/a.txt/1":     "first",
+		"/docs/.versions/a.txt/2":     "second!",
+		"/docs/.versions/a.txt/dir/":  "",
+		"/docs/.versions/b.txt/1":     "other",
+		"/flat/.versions/a.txt.1":     "first",
+		"/flat/.versions/a.txt.2.bak": "second",
+		"/flat/.versions/ab.txt.1":    "other",
+	})
+	old := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, fs.Chtimes("/docs/.versions/a.txt/1", old, old))
+	require.NoError(t, fs.Chtimes("/docs/.versions/a.txt/2", old.Add(time.Hour), old.Add(time.Hour)))
+
+	list, err := DefaultLayout.List(fs, "/docs/a.txt")
+	require.NoError(t, err)
+	require.Equal(t, []Version{
+		{Version: "2", ModTime: old.Add(time.Hour), Size: 7},
+		{Version: "1", ModTime: old, Size: 5},
+	}, list)
+
+	list, err = Layout(".versions/{name}.{version}").List(fs, "/flat/a.txt")
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+
+	list, err = DefaultLayout.List(fs, "/none.txt")
+	require.NoError(t, err)
+	require.Empty(t, list)
+
+}
+
+func TestSnapshot(t *testing.T) {
+	fs := testutil.NewFs(t, map[string]string{"/docs/a.txt": "v1"})
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	for i, content := range []string{"v2", "v3", "v4"} {
+		require.NoError(t, DefaultLayout.Snapshot(fs, "/docs/a.txt", now.Add(time.Duration(i)*time.Second), 2, nil))
+		require.NoError(t, afero.WriteFile(fs, "/docs/a.txt", []byte(content), 0644))
+	}
+
+	require.Equal(t, map[string]string{
+		"/docs/":                 "",
+		"/docs/.versions/":       "",
+		"/docs/.versions/a.txt/": "",
+		"/docs/a.txt":            "v4",
+		"/docs/.versions/a.txt/20200102T030406.000000000Z": "v2",
+		"/docs/.versions/a.txt/20200102T030407.000000000Z": "v3",
+	}, testutil.Tree(t, fs))
+
+	// Linking falls back to copying.
+	failing := func(string, string) error { return os.ErrPermission }
+	require.NoError(t, DefaultLayout.Snapshot(fs, "/docs/a.txt", now.Add(time.Hour), 2, failing))
+	content, err := afero.ReadFile(fs, "/docs/.versions/a.txt/20200102T040405.000000000Z")
+	require.NoError(t, err)
+	require.Equal(t, "v4", string(content))
+}
+
+func TestLayoutHides(t *testing.T) {
+	require.True(t, DefaultLayout.Hides("/.versions"))
+	require.True(t, DefaultLayout.Hides("/docs/.versions/a.txt/1"))
+	require.False(t, DefaultLayout.Hides("/docs/.versions.txt"))
+	require.False(t, DefaultLayout.Hides("/docs/a.txt"))
+
+	require.True(t, Layout("/backups{path}@{version}").Hides("/backups/docs"))
+	require.False(t, Layout("/backups{path}@{version}").Hides("/docs/backups"))
+	require.False(t, Layout("{name}.{version}").Hides("/docs/a.txt.1"))
+	require.False(t, Layout("{dir}/old/{version}").Hides("/docs/old"))
+}
@@ -0,0 +1,45 @@
+// Package warmer lists directories in the background, so the listings of
+// slow filesystems are cached before users first open them.
+package warmer
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// Warm lists the directories, then again every interval until the context
+// is done. The directories which can't be listed are logged and skipped.
+// With an interval of zero, they are only listed once.
+func Warm(ctx context.Context, fs afero.Fs, dirs []string, interval time.Duration) {
+	warm(ctx, fs, dirs)
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			warm(ctx, fs, dirs)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func warm(ctx context.Context, fs afero.Fs, dirs []string) {
+	for _, dir := range dirs {
+		if ctx.Err() != nil {
+			return
+		}
+
+		if _, err := afero.ReadDir(fs, dir); err != nil {
+			log.Printf("couldn't warm the listing of %s: %v", dir, err)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package warmer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// countingFs counts the directories opened.
+type countingFs struct {
+	afero.Fs
+	mu    sync.Mutex
+	opens map[string]int
+}
+
+func (fs *countingFs) Open(name string) (afero.File, error) {
+	fs.mu.Lock()
+	fs.opens[name]++
+	fs.mu.Unlock()
+	return fs.Fs.Open(name)
+}
+
+func (fs *countingFs) count(name string) int {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.opens[name]
+}
+
+func TestWarm(t *testing.T) {
+	fs := &countingFs{Fs: afero.NewMemMapFs(), opens: map[string]int{}}
+	require.NoError(t, fs.MkdirAll("/photos", 0755))
+
+	// Once without an interval, skipping the missing directories.
+	Warm(context.Background(), fs, []string{"/missing", "/photos"}, 0)
+	require.Equal(t, 1, fs.count("/photos"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Warm(ctx, fs, []string{"/photos"}, time.Millisecond)
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool { return fs.count("/photos") >= 3 }, time.Second, time.Millisecond)
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the warmer didn't stop once the context was done")
+	}
+}